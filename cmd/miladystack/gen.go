@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/miladystack/miladystack/pkg/store/gen"
+)
+
+// newGenCommand creates the gen command, grouping the code generators.
+func newGenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate code",
+	}
+	cmd.AddCommand(newGenStoreCommand())
+	return cmd
+}
+
+// newGenStoreCommand creates the gen store command, generating typed store layers.
+func newGenStoreCommand() *cobra.Command {
+	var cfg gen.Config
+	cmd := &cobra.Command{
+		Use:   "store [model...]",
+		Short: "Generate typed stores for model structs",
+		Long: `Generate a typed XxxStore for model structs, wrapping store.IStore with
+lookup methods named after the indexed fields of the models: GetByXxx for the
+unique ones and ListByXxx for the others, along with their unit tests.
+
+The stores are generated into the package declaring the models, for the named
+models or every struct with gorm tags when none is named.`,
+		Example: `  miladystack gen store --dir ./internal/model User Order`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.Models = args
+			paths, err := gen.Write(cfg)
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				fmt.Fprintln(cmd.OutOrStdout(), path)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&cfg.Dir, "dir", ".", "Directory of the package declaring the models.")
+	cmd.Flags().BoolVar(&cfg.SkipTests, "skip-tests", false, "Do not generate the unit tests of the stores.")
+	return cmd
+}
@@ -0,0 +1,22 @@
+// Command miladystack provides the development tools of the miladystack framework,
+// such as the code generators.
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	cmd := &cobra.Command{
+		Use:          "miladystack",
+		Short:        "Development tools of the miladystack framework",
+		SilenceUsage: true,
+	}
+	cmd.AddCommand(newGenCommand())
+
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/miladystack/miladystack/pkg/store/logger/milady"
 	"github.com/miladystack/miladystack/pkg/store/where"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -25,20 +24,6 @@ type User struct {
 	DeletedAt gorm.DeletedAt `gorm:"column:is_deleted;comment:软删除时间;index" json:"is_deleted"` // 软删除字段，使用自定义列名
 }
 
-// MySQLProvider implements DBProvider interface
-type MySQLProvider struct {
-	db *gorm.DB
-}
-
-// DB returns the database instance
-func (p *MySQLProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
-	db := p.db.WithContext(ctx)
-	for _, where := range wheres {
-		db = where.Where(db)
-	}
-	return db
-}
-
 // LoggerType defines the type of logger to use
 type LoggerType string
 
@@ -51,20 +36,21 @@ const (
 func initDB(loggerType LoggerType) (*store.Store[User], context.Context, error) {
 	// Connect to MySQL database
 	dsn := "milady:milady(#)888@tcp(localhost:3306)/test?charset=utf8mb4&parseTime=True&loc=Local"
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	dbProvider, err := store.NewMySQLProvider(dsn,
+		store.WithMaxOpenConns(20),
+		store.WithConnMaxLifetime(time.Hour),
+		store.WithStartupRetry(5, nil),
+	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Auto migrate the User model
-	err = db.AutoMigrate(&User{})
+	err = dbProvider.DB(context.Background()).AutoMigrate(&User{})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	// Create DBProvider
-	dbProvider := &MySQLProvider{db: db}
-
 	// Create logger based on type
 	var logger store.Logger
 	switch loggerType {
@@ -77,7 +63,7 @@ func initDB(loggerType LoggerType) (*store.Store[User], context.Context, error)
 	}
 
 	// Create store instance for User model
-	userStore := store.NewStore[User](dbProvider, logger)
+	userStore := store.NewStore(dbProvider, store.WithLogger[User](logger))
 
 	ctx := context.Background()
 	return userStore, ctx, nil
@@ -344,18 +330,8 @@ func testSoftDelete(store *store.Store[User], ctx context.Context) error {
 
 	// 9. Restore the soft deleted user
 	fmt.Println("\n9. Restoring soft deleted user...")
-	// To restore a soft deleted record, we need to update the DeletedAt field to zero value
-	// Get the record with Unscoped first
-	restoredUser, err := store.Get(ctx, where.F("id", userID).U(true))
-	if err != nil {
-		log.Printf("Failed to get soft deleted user for restoration: %v", err)
-		return err
-	}
-
-	// Clear the DeletedAt field to restore
-	restoredUser.DeletedAt = gorm.DeletedAt{}
-	// Update the user
-	err = store.Update(ctx, restoredUser)
+	// Restore clears the soft delete column (is_deleted here) of the matching rows
+	_, err = store.Restore(ctx, where.F("id", userID))
 	if err != nil {
 		log.Printf("Failed to restore user: %v", err)
 		return err
@@ -364,7 +340,7 @@ func testSoftDelete(store *store.Store[User], ctx context.Context) error {
 
 	// 10. Verify restoration
 	fmt.Println("\n10. Verifying restoration with normal query...")
-	restoredUser, err = store.Get(ctx, where.F("id", userID))
+	restoredUser, err := store.Get(ctx, where.F("id", userID))
 	if err != nil {
 		fmt.Printf("   ❌ Normal query: User not found (unexpected): %v\n", err)
 		return err
@@ -19,3 +19,9 @@ func NewLogger() *Logger {
 func (l *Logger) Error(ctx context.Context, err error, msg string, kvs ...any) {
 	klog.FromContext(ctx).Error(err, msg, kvs...)
 }
+
+// Warn logs a warning message with the provided context using the log package.
+// logr has no warning level, so warnings are logged at info level.
+func (l *Logger) Warn(ctx context.Context, msg string, kvs ...any) {
+	klog.FromContext(ctx).Info(msg, kvs...)
+}
@@ -19,3 +19,8 @@ func (l *Logger) Error(ctx context.Context, err error, msg string, kvs ...any) {
 	kvs = append(kvs, "error", err)
 	slog.ErrorContext(ctx, msg, kvs...)
 }
+
+// Warn logs a warning message with the provided context using the log package.
+func (l *Logger) Warn(ctx context.Context, msg string, kvs ...any) {
+	slog.WarnContext(ctx, msg, kvs...)
+}
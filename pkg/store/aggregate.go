@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// Agg defines an SQL aggregate function supported by Aggregate.
+type Agg string
+
+const (
+	// AggSum computes the sum of the column values.
+	AggSum Agg = "SUM"
+	// AggAvg computes the average of the column values.
+	AggAvg Agg = "AVG"
+	// AggMin returns the smallest column value.
+	AggMin Agg = "MIN"
+	// AggMax returns the largest column value.
+	AggMax Agg = "MAX"
+)
+
+// Aggregate applies the aggregate function agg to column over the objects matching
+// the provided where options. It returns 0 when no object matches.
+// Pagination and ordering carried by opts are ignored.
+func (s *Store[T]) Aggregate(ctx context.Context, opts *where.Options, agg Agg, column string) (ret float64, err error) {
+	ctx, op := s.begin(ctx, "Aggregate")
+	defer func() { op.end(err) }()
+
+	switch agg {
+	case AggSum, AggAvg, AggMin, AggMax:
+	default:
+		return 0, fmt.Errorf("unsupported aggregate function %q", agg)
+	}
+
+	// The column is passed as a clause.Column so that it gets quoted by the dialect,
+	// COALESCE turns the NULL returned for an empty set into 0.
+	var value float64
+	err = s.retry(ctx, func() error {
+		return s.reader(ctx, conditions(opts)).Model(new(T)).
+			Select("COALESCE("+string(agg)+"(?), 0)", clause.Column{Name: column}).
+			Scan(&value).Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to aggregate objects in database",
+			"conditions", opts, "aggregate", agg, "column", column)
+		return 0, wrapError(err)
+	}
+	return value, nil
+}
+
+// GroupBy groups the objects matching the provided where options by groupColumns and
+// scans one row per group into dest, which must be a pointer to a slice of structs or
+// of map[string]any. selects lists the selected expressions, such as the grouped
+// columns and aggregates like "COUNT(*) AS total", and defaults to groupColumns.
+// Conditions on the groups are given with where.Having, and the groups can be ordered
+// and paginated with opts:
+//
+//	var rows []struct {
+//		Status string
+//		Total  int64
+//	}
+//	err := orders.GroupBy(ctx, where.Having("COUNT(*) > ?", 10).Or("total desc"),
+//		[]string{"status"}, []string{"status", "COUNT(*) AS total"}, &rows)
+//
+// selects, groupColumns and the HAVING conditions are SQL fragments, they must not
+// contain user input.
+func (s *Store[T]) GroupBy(ctx context.Context, opts *where.Options, groupColumns []string, selects []string, dest any) (err error) {
+	ctx, op := s.begin(ctx, "GroupBy")
+	defer func() { op.end(err) }()
+
+	if len(groupColumns) == 0 {
+		return errors.New("group by requires at least one column")
+	}
+	if len(selects) == 0 {
+		selects = groupColumns
+	}
+
+	err = s.retry(ctx, func() error {
+		db := s.reader(ctx, opts).Model(new(T)).Select(selects)
+		for _, column := range groupColumns {
+			db = db.Group(column)
+		}
+		return db.Scan(dest).Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to group objects in database",
+			"conditions", opts, "group", groupColumns, "selects", selects)
+		return wrapError(err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+)
+
+const (
+	defaultInterval  = time.Hour
+	defaultBatchSize = 1000
+)
+
+// Policy defines which rows of a table are archived.
+type Policy struct {
+	// Model is the model of the live table, whose single primary key identifies the rows.
+	Model any
+	// Column is the time column compared against the retention, such as created_at.
+	Column string
+	// MaxAge is the retention: rows whose Column is older than MaxAge are archived.
+	MaxAge time.Duration
+	// ArchiveTable is the table the rows are moved to, <table>_archive by default.
+	// It is ignored when the rows are written to a Sink.
+	ArchiveTable string
+}
+
+// Sink receives the archived rows when they are exported instead of being moved to
+// an archive table. Rows may be written more than once, when the chunk they belong to
+// fails to be committed after being written.
+type Sink interface {
+	Write(ctx context.Context, rows []map[string]any) error
+}
+
+// SinkFunc adapts an ordinary function to the Sink interface.
+type SinkFunc func(ctx context.Context, rows []map[string]any) error
+
+// Write calls f(ctx, rows).
+func (f SinkFunc) Write(ctx context.Context, rows []map[string]any) error {
+	return f(ctx, rows)
+}
+
+// NDJSONSink returns a Sink writing the rows to w as newline-delimited JSON, an
+// object per row. Writes to w are serialized.
+func NDJSONSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return SinkFunc(func(ctx context.Context, rows []map[string]any) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stats holds the counters of an Archiver.
+type Stats struct {
+	// Archived is the number of rows archived.
+	Archived uint64
+	// Chunks is the number of chunks committed.
+	Chunks uint64
+	// Failures is the number of chunks that failed and were rolled back.
+	Failures uint64
+	// LastArchivedAt is the time the last chunk was committed.
+	LastArchivedAt time.Time
+}
+
+// Option defines a function type for configuring the Archiver.
+type Option func(*Archiver)
+
+// WithInterval sets the delay between two archival runs.
+func WithInterval(interval time.Duration) Option {
+	return func(a *Archiver) {
+		a.interval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of rows archived per chunk.
+func WithBatchSize(batchSize int) Option {
+	return func(a *Archiver) {
+		a.batchSize = batchSize
+	}
+}
+
+// WithSink exports the archived rows to sink instead of moving them to an archive table.
+func WithSink(sink Sink) Option {
+	return func(a *Archiver) {
+		a.sink = sink
+	}
+}
+
+// WithLogger sets the logger used to report archival failures.
+func WithLogger(logger store.Logger) Option {
+	return func(a *Archiver) {
+		a.logger = logger
+	}
+}
+
+// Archiver moves the rows of a table older than a retention policy out of it.
+type Archiver struct {
+	provider  store.DBProvider
+	policy    Policy
+	logger    store.Logger
+	interval  time.Duration
+	batchSize int
+	sink      Sink
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New creates an Archiver applying policy to the table read through provider.
+func New(provider store.DBProvider, policy Policy, opts ...Option) *Archiver {
+	a := &Archiver{
+		provider:  provider,
+		policy:    policy,
+		logger:    empty.NewLogger(),
+		interval:  defaultInterval,
+		batchSize: defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Migrate creates or updates the archive table from the model of the policy, so that
+// its columns match the ones of the live table, in the same order. It is not needed
+// when the rows are written to a Sink.
+func (a *Archiver) Migrate(ctx context.Context) error {
+	tables, err := a.tables(a.provider.DB(ctx))
+	if err != nil {
+		return err
+	}
+	return a.provider.DB(ctx).Table(tables.archive).AutoMigrate(a.policy.Model)
+}
+
+// Stats returns the counters of the archiver.
+func (a *Archiver) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.stats
+}
+
+// Run archives the aged rows at every interval until ctx is canceled.
+func (a *Archiver) Run(ctx context.Context) error {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.Archive(ctx); err != nil && ctx.Err() == nil {
+			a.logger.Error(ctx, err, "Failed to archive rows", "column", a.policy.Column)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive archives the rows older than the retention chunk by chunk, until none is
+// left, and returns the number of rows archived. Chunks are committed as they go,
+// so that a canceled or failed archival resumes from the remaining rows.
+func (a *Archiver) Archive(ctx context.Context) (int64, error) {
+	var archived int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return archived, err
+		}
+		n, err := a.Process(ctx)
+		archived += int64(n)
+		if err != nil || n < a.batchSize {
+			return archived, err
+		}
+	}
+}
+
+// Process archives one chunk of at most the batch size of rows older than the
+// retention and returns the number of rows archived. The chunk is copied to the
+// archive table or written to the sink, then deleted from the live table, within a
+// single transaction. Rows are locked while being archived (FOR UPDATE SKIP LOCKED
+// where supported), so several archivers can run concurrently.
+func (a *Archiver) Process(ctx context.Context) (int, error) {
+	var archived int
+	err := a.provider.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		tables, err := a.tables(tx)
+		if err != nil {
+			return err
+		}
+		live := clause.Table{Name: tables.live}
+		key := clause.Column{Name: tables.key}
+
+		var keys []any
+		err = tx.Table(tables.live).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("? < ?", clause.Column{Name: a.policy.Column}, time.Now().Add(-a.policy.MaxAge)).
+			Order(tables.key).Limit(a.batchSize).Pluck(tables.key, &keys).Error
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+
+		if a.sink != nil {
+			var rows []map[string]any
+			if err := tx.Table(tables.live).Where("? IN ?", key, keys).Order(tables.key).Find(&rows).Error; err != nil {
+				return err
+			}
+			if err := a.sink.Write(ctx, rows); err != nil {
+				return fmt.Errorf("write archived rows: %w", err)
+			}
+		} else {
+			err := tx.Exec("INSERT INTO ? SELECT * FROM ? WHERE ? IN ?", clause.Table{Name: tables.archive}, live, key, keys).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM ? WHERE ? IN ?", live, key, keys).Error; err != nil {
+			return err
+		}
+		archived = len(keys)
+		return nil
+	})
+	a.record(archived, err)
+	return archived, err
+}
+
+// record updates the counters with the outcome of a chunk.
+func (a *Archiver) record(archived int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case err != nil:
+		a.stats.Failures++
+	case archived > 0:
+		a.stats.Archived += uint64(archived)
+		a.stats.Chunks++
+		a.stats.LastArchivedAt = time.Now()
+	}
+}
+
+// tables holds the names of the tables and the primary key of a policy.
+type tables struct {
+	live    string
+	archive string
+	key     string
+}
+
+// tables resolves the tables and the primary key of the policy from its model.
+func (a *Archiver) tables(db *gorm.DB) (tables, error) {
+	if a.policy.Model == nil || a.policy.Column == "" {
+		return tables{}, errors.New("archive policy requires a model and a column")
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(a.policy.Model); err != nil {
+		return tables{}, err
+	}
+	if len(stmt.Schema.PrimaryFields) != 1 {
+		return tables{}, fmt.Errorf("archive policy requires a single primary key, model %s has %d", stmt.Schema.Name, len(stmt.Schema.PrimaryFields))
+	}
+
+	ret := tables{
+		live:    stmt.Schema.Table,
+		archive: a.policy.ArchiveTable,
+		key:     stmt.Schema.PrimaryFields[0].DBName,
+	}
+	if ret.archive == "" {
+		ret.archive = ret.live + "_archive"
+	}
+	return ret, nil
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testEvent struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+type testProvider struct {
+	db *gorm.DB
+}
+
+func (p *testProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return p.db.WithContext(ctx)
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&testEvent{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	now := time.Now()
+	events := make([]testEvent, 0, 10)
+	for i := range 10 {
+		// Events 1 to 7 are older than 30 days.
+		events = append(events, testEvent{Name: "event", CreatedAt: now.AddDate(0, 0, -64+5*i)})
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatalf("Failed to create events: %v", err)
+	}
+	return &testProvider{db: db}
+}
+
+func TestArchiveTable(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+	archiver := New(provider, Policy{Model: &testEvent{}, Column: "created_at", MaxAge: 30 * 24 * time.Hour}, WithBatchSize(3))
+	if err := archiver.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	archived, err := archiver.Archive(ctx)
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if archived != 7 {
+		t.Errorf("expected 7 archived rows, got %d", archived)
+	}
+
+	var live, moved []testEvent
+	provider.db.Order("id").Find(&live)
+	provider.db.Table("test_events_archive").Order("id").Find(&moved)
+	if len(live) != 3 || live[0].ID != 8 {
+		t.Errorf("expected events 8 to 10 to stay live, got %+v", live)
+	}
+	if len(moved) != 7 || moved[0].ID != 1 || moved[6].ID != 7 {
+		t.Errorf("expected events 1 to 7 to be archived, got %+v", moved)
+	}
+
+	stats := archiver.Stats()
+	if stats.Archived != 7 || stats.Chunks != 3 || stats.Failures != 0 || stats.LastArchivedAt.IsZero() {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Nothing is left to archive.
+	if archived, err := archiver.Archive(ctx); err != nil || archived != 0 {
+		t.Errorf("expected nothing to archive, got %d, %v", archived, err)
+	}
+}
+
+func TestArchiveSink(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+	policy := Policy{Model: &testEvent{}, Column: "created_at", MaxAge: 30 * 24 * time.Hour}
+
+	// A failing chunk is rolled back and archived again by the next run.
+	failing := New(provider, policy, WithBatchSize(5), WithSink(SinkFunc(func(ctx context.Context, rows []map[string]any) error {
+		return errors.New("disk full")
+	})))
+	if _, err := failing.Archive(ctx); err == nil {
+		t.Fatal("expected the sink failure to be returned")
+	}
+	if stats := failing.Stats(); stats.Failures != 1 || stats.Archived != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	var buf bytes.Buffer
+	archived, err := New(provider, policy, WithBatchSize(5), WithSink(NDJSONSink(&buf))).Archive(ctx)
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if archived != 7 {
+		t.Errorf("expected 7 archived rows, got %d", archived)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 exported rows, got %d", len(lines))
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("Failed to decode exported row: %v", err)
+	}
+	if row["id"] != float64(1) || row["name"] != "event" {
+		t.Errorf("unexpected exported row %v", row)
+	}
+
+	var count int64
+	provider.db.Model(&testEvent{}).Count(&count)
+	if count != 3 {
+		t.Errorf("expected 3 live rows, got %d", count)
+	}
+}
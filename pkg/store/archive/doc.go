@@ -0,0 +1,4 @@
+// Package archive moves the rows of a table older than a retention policy to an
+// archive table or an export file, in transactional chunks, so that operational
+// tables stay small. An interrupted archival resumes from the remaining rows.
+package archive // import "github.com/miladystack/miladystack/pkg/store/archive"
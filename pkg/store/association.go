@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Association manages an association of an object, such as the members of a
+// many-to-many relationship, through the store so that the changes join the
+// transaction carried by the context and are logged and traced consistently.
+type Association[T any] struct {
+	store *Store[T]
+	ctx   context.Context
+	obj   *T
+	name  string
+}
+
+// Association returns the manager of the association called name of obj, for example
+// s.Association(ctx, user, "Roles").Append(&admin). obj must have its primary key set.
+func (s *Store[T]) Association(ctx context.Context, obj *T, name string) *Association[T] {
+	return &Association[T]{store: s, ctx: ctx, obj: obj, name: name}
+}
+
+// Find loads the associated objects into dest, which must be a pointer to a slice
+// or a struct matching the association type.
+func (a *Association[T]) Find(dest any) error {
+	return a.run("Find", func(assoc *gorm.Association) error { return assoc.Find(dest) })
+}
+
+// Count returns the number of associated objects.
+func (a *Association[T]) Count() (count int64, err error) {
+	err = a.run("Count", func(assoc *gorm.Association) error {
+		count = assoc.Count()
+		return assoc.Error
+	})
+	return count, err
+}
+
+// Append adds values to the association. Values that do not exist yet are created.
+func (a *Association[T]) Append(values ...any) error {
+	return a.run("Append", func(assoc *gorm.Association) error { return assoc.Append(values...) })
+}
+
+// Replace replaces the associated objects with values.
+func (a *Association[T]) Replace(values ...any) error {
+	return a.run("Replace", func(assoc *gorm.Association) error { return assoc.Replace(values...) })
+}
+
+// Delete removes values from the association. Only the references are removed:
+// the objects themselves are not deleted.
+func (a *Association[T]) Delete(values ...any) error {
+	return a.run("Delete", func(assoc *gorm.Association) error { return assoc.Delete(values...) })
+}
+
+// Clear removes every reference of the association, without deleting the objects.
+func (a *Association[T]) Clear() error {
+	return a.run("Clear", func(assoc *gorm.Association) error { return assoc.Clear() })
+}
+
+// run runs the association operation called name.
+func (a *Association[T]) run(name string, fn func(*gorm.Association) error) (err error) {
+	ctx, op := a.store.begin(a.ctx, "Association."+name)
+	defer func() { op.end(err) }()
+
+	assoc := a.store.db(ctx).Model(a.obj).Association(a.name)
+	if assoc.Error == nil {
+		err = fn(assoc)
+	} else {
+		err = assoc.Error
+	}
+	if err != nil {
+		a.store.logger.Error(ctx, err, "Failed to run association operation",
+			"operation", name, "association", a.name, "object", a.obj)
+		return wrapError(err)
+	}
+	return nil
+}
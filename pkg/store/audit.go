@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// operatorKey is the context key holding the identity of the operator of the current request.
+type operatorKey struct{}
+
+// WithOperator returns a context carrying the identity of the operator, such as the
+// user ID extracted from the request token, used by stores configured with WithAudit
+// to fill the audit columns.
+func WithOperator(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, operatorKey{}, id)
+}
+
+// OperatorFromContext returns the operator identity carried by ctx, if any.
+func OperatorFromContext(ctx context.Context) (any, bool) {
+	id := ctx.Value(operatorKey{})
+	return id, id != nil
+}
+
+// AuditColumns names the columns filled with the operator carried by the context.
+// An empty name disables the corresponding column.
+type AuditColumns struct {
+	// CreatedBy is filled by the operations inserting objects.
+	CreatedBy string
+	// UpdatedBy is filled by the operations inserting or modifying objects.
+	UpdatedBy string
+	// DeletedBy is filled when objects are soft-deleted.
+	DeletedBy string
+}
+
+// DefaultAuditColumns holds the conventional names of the audit columns.
+var DefaultAuditColumns = AuditColumns{
+	CreatedBy: "created_by",
+	UpdatedBy: "updated_by",
+	DeletedBy: "deleted_by",
+}
+
+// WithAudit returns an Option filling the audit columns with the operator carried
+// by the context (see WithOperator). Create, CreateBatch, GetOrCreate and Upsert fill
+// the created by and updated by columns, Update and UpdateWhere the updated by column,
+// and Delete the deleted by column of soft-deleted objects. Upsert updating all
+// columns overwrites the created by column of existing objects: list the update
+// columns to preserve it. Columns missing from the model are ignored, and operations
+// called with a context carrying no operator leave the columns untouched.
+func WithAudit[T any](columns AuditColumns) Option[T] {
+	return func(s *Store[T]) {
+		s.audit = &columns
+	}
+}
+
+// operator returns the operator recorded by the operations called with ctx.
+func (s *Store[T]) operator(ctx context.Context) (any, bool) {
+	if s.audit == nil {
+		return nil, false
+	}
+	return OperatorFromContext(ctx)
+}
+
+// fillAudit sets the updated by column of objs, and their created by column when
+// they are being inserted, to the operator carried by ctx.
+func (s *Store[T]) fillAudit(ctx context.Context, created bool, objs ...*T) error {
+	id, ok := s.operator(ctx)
+	if !ok {
+		return nil
+	}
+
+	columns := []string{s.audit.UpdatedBy}
+	if created {
+		columns = append(columns, s.audit.CreatedBy)
+	}
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		field := sch.LookUpField(column)
+		if column == "" || field == nil {
+			continue
+		}
+		for _, obj := range objs {
+			if err := field.Set(ctx, reflect.ValueOf(obj).Elem(), id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// auditFields returns fields with the updated by column set to the operator carried
+// by ctx, unless fields already sets it.
+func (s *Store[T]) auditFields(ctx context.Context, fields map[string]any) map[string]any {
+	id, ok := s.operator(ctx)
+	if !ok || !s.hasColumn(ctx, s.audit.UpdatedBy) {
+		return fields
+	}
+	if _, set := fields[s.audit.UpdatedBy]; set {
+		return fields
+	}
+
+	fields = maps.Clone(fields)
+	fields[s.audit.UpdatedBy] = id
+	return fields
+}
+
+// softDelete soft-deletes the objects matching opts, recording the operator carried
+// by ctx in the deleted by column, and returns the number of rows affected. It reports
+// false when the deletion does not need to be audited, in which case nothing is done.
+func (s *Store[T]) softDelete(ctx context.Context, opts *where.Options) (audited bool, affected int64, err error) {
+	id, ok := s.operator(ctx)
+	if !ok || (opts != nil && opts.Unscoped) || !s.hasColumn(ctx, s.audit.DeletedBy) {
+		return false, 0, nil
+	}
+	field, err := softDeleteField[T](s.db(ctx))
+	if err != nil {
+		return false, 0, nil
+	}
+
+	// Set both columns in a single statement, as GORM soft delete only sets its own.
+	collect := func() {}
+	err = s.retry(ctx, func() error {
+		var db *gorm.DB
+		db, collect = s.returningModel(ctx, s.db(ctx, opts))
+		result := db.UpdateColumns(map[string]any{
+			field.DBName:      gorm.DeletedAt{Time: time.Now(), Valid: true},
+			s.audit.DeletedBy: id,
+		})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err == nil {
+		collect()
+	}
+	return true, affected, err
+}
+
+// hasColumn reports whether the model T has the given column.
+func (s *Store[T]) hasColumn(ctx context.Context, column string) bool {
+	if column == "" {
+		return false
+	}
+	sch, err := schemaOf[T](s.db(ctx))
+	return err == nil && sch.LookUpField(column) != nil
+}
@@ -0,0 +1,527 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+const (
+	// defaultBreakerThreshold defines the number of consecutive failures opening the circuit.
+	defaultBreakerThreshold = 5
+	// defaultBreakerOpenTimeout defines how long the circuit stays open before probing.
+	defaultBreakerOpenTimeout = 30 * time.Second
+	// defaultBreakerProbes defines the number of concurrent probes allowed when half-open.
+	defaultBreakerProbes = 1
+)
+
+// ErrCircuitOpen is returned by a BreakerStore while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState defines the state of a CircuitBreaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every operation through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects every operation with ErrCircuitOpen.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a limited number of probe operations through to decide
+	// whether the circuit is closed again.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerStats holds the counters of a CircuitBreaker.
+type BreakerStats struct {
+	// State is the current state of the circuit.
+	State BreakerState
+	// Successes is the number of operations that succeeded.
+	Successes uint64
+	// Failures is the number of operations that failed with a database failure.
+	Failures uint64
+	// Rejected is the number of operations rejected with ErrCircuitOpen.
+	Rejected uint64
+	// Opened is the number of times the circuit has been opened.
+	Opened uint64
+}
+
+// BreakerOption defines a function type for configuring the CircuitBreaker.
+type BreakerOption func(*CircuitBreaker)
+
+// WithBreakerThreshold sets the number of consecutive failures opening the circuit.
+func WithBreakerThreshold(threshold int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.threshold = threshold
+	}
+}
+
+// WithBreakerOpenTimeout sets how long the circuit stays open before probing the database.
+func WithBreakerOpenTimeout(timeout time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.openTimeout = timeout
+	}
+}
+
+// WithBreakerProbes sets the number of concurrent probe operations allowed when half-open.
+func WithBreakerProbes(probes int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.maxProbes = probes
+	}
+}
+
+// WithBreakerFailure sets the function reporting whether an error counts as a
+// database failure. Defaults to the failures of the database itself: transient errors
+// (see IsTransient), network errors, unavailable or overloaded servers and statement
+// timeouts. Other errors, such as not found, duplicate key or invalid options, and the
+// errors returned by the functions passed to Each and Tx, are outcomes of the caller's
+// request. The errors of the operations of a BreakerStore whose context is done are
+// never counted, as the caller gave up or ran out of time.
+func WithBreakerFailure(isFailure func(err error) bool) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.isFailure = isFailure
+	}
+}
+
+// WithBreakerStateChange sets a function called on every state transition, e.g. to
+// export metrics or log the transition. It is called with the breaker lock held and
+// must not call the breaker.
+func WithBreakerStateChange(fn func(from, to BreakerState)) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.onStateChange = fn
+	}
+}
+
+// CircuitBreaker opens after a number of consecutive database failures and rejects
+// operations until the open timeout elapses. It then lets probe operations through,
+// closing again on success and reopening on failure.
+// A CircuitBreaker can be shared by the stores of the same database.
+type CircuitBreaker struct {
+	threshold     int
+	openTimeout   time.Duration
+	maxProbes     int
+	isFailure     func(err error) bool
+	onStateChange func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	stats    BreakerStats
+}
+
+// NewCircuitBreaker creates a CircuitBreaker in the closed state.
+func NewCircuitBreaker(opts ...BreakerOption) *CircuitBreaker {
+	b := &CircuitBreaker{
+		threshold:   defaultBreakerThreshold,
+		openTimeout: defaultBreakerOpenTimeout,
+		maxProbes:   defaultBreakerProbes,
+		isFailure:   isBreakerFailure,
+		state:       BreakerClosed,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Do runs fn when the circuit allows it and records its outcome, or returns
+// ErrCircuitOpen without calling fn.
+func (b *CircuitBreaker) Do(fn func() error) error {
+	return b.do(context.Background(), fn)
+}
+
+// do runs fn like Do, without recording the outcome of fn when ctx is done, as the
+// caller gave up on the operation or ran out of time.
+func (b *CircuitBreaker) do(ctx context.Context, fn func() error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil && ctx.Err() != nil {
+		b.release(probe)
+		return err
+	}
+	b.record(probe, err)
+	return err
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState(time.Now())
+}
+
+// Stats returns the counters of the breaker.
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.State = b.currentState(time.Now())
+	return stats
+}
+
+// allow reports whether an operation may run, and whether it is a probe.
+func (b *CircuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(time.Now()) {
+	case BreakerClosed:
+		return false, nil
+	case BreakerHalfOpen:
+		if b.state == BreakerOpen {
+			b.transition(BreakerHalfOpen)
+		}
+		if b.probes < b.maxProbes {
+			b.probes++
+			return true, nil
+		}
+	}
+	b.stats.Rejected++
+	return false, ErrCircuitOpen
+}
+
+// release ends an operation without recording its outcome.
+func (b *CircuitBreaker) release(probe bool) {
+	if probe {
+		b.mu.Lock()
+		b.probes--
+		b.mu.Unlock()
+	}
+}
+
+// record updates the circuit with the outcome of an operation.
+func (b *CircuitBreaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probes--
+	}
+
+	if err != nil && b.isFailure(err) {
+		b.stats.Failures++
+		b.failures++
+		if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+			b.openedAt = time.Now()
+			b.stats.Opened++
+			b.transition(BreakerOpen)
+		}
+		return
+	}
+
+	b.stats.Successes++
+	b.failures = 0
+	if probe && b.state == BreakerHalfOpen {
+		b.transition(BreakerClosed)
+	}
+}
+
+// currentState returns the state at now, turning an expired open state into half-open.
+// The caller must hold the lock.
+func (b *CircuitBreaker) currentState(now time.Time) BreakerState {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// transition moves the circuit to state. The caller must hold the lock.
+func (b *CircuitBreaker) transition(state BreakerState) {
+	from := b.state
+	b.state = state
+	if state == BreakerClosed {
+		b.failures = 0
+	}
+	if b.onStateChange != nil && from != state {
+		b.onStateChange(from, state)
+	}
+}
+
+// isBreakerFailure reports whether err is a failure of the database rather than an
+// outcome of the caller's request, which includes invalid options, incomplete keys,
+// rejections of hooks and errors returned by the caller's functions.
+func isBreakerFailure(err error) bool {
+	// Deadlines of callers are filtered out by CircuitBreaker.do: the remaining ones
+	// are statement timeouts.
+	if IsTransient(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// MySQL: 1040 too many connections, 1053 server shutdown
+	if code, ok := mysqlErrorNumber(err); ok {
+		return code == 1040 || code == 1053
+	}
+	// PostgreSQL: insufficient resources (class 53), operator intervention (class 57),
+	// which includes query_canceled by statement timeouts and admin shutdowns
+	if state, ok := sqlState(err); ok {
+		return strings.HasPrefix(state, "53") || strings.HasPrefix(state, "57")
+	}
+	return false
+}
+
+// BreakerStore is a circuit breaking decorator for Store. Once the database fails
+// repeatedly, operations fail fast with ErrCircuitOpen instead of piling up on an
+// unavailable database, until probe operations succeed again.
+type BreakerStore[T any] struct {
+	next    IStore[T]
+	breaker *CircuitBreaker
+}
+
+// NewBreakerStore wraps s with breaker.
+func NewBreakerStore[T any](s IStore[T], breaker *CircuitBreaker) *BreakerStore[T] {
+	return &BreakerStore[T]{next: s, breaker: breaker}
+}
+
+// Unwrap returns the store wrapped by the breaker.
+func (b *BreakerStore[T]) Unwrap() IStore[T] {
+	return b.next
+}
+
+// Breaker returns the circuit breaker of the store.
+func (b *BreakerStore[T]) Breaker() *CircuitBreaker {
+	return b.breaker
+}
+
+// Create inserts a new object into the database.
+func (b *BreakerStore[T]) Create(ctx context.Context, obj *T) error {
+	return b.breaker.do(ctx, func() error { return b.next.Create(ctx, obj) })
+}
+
+// CreateBatch inserts objs into the database in batches of batchSize rows.
+func (b *BreakerStore[T]) CreateBatch(ctx context.Context, objs []*T, batchSize int) (inserted int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		inserted, err = b.next.CreateBatch(ctx, objs, batchSize)
+		return err
+	})
+	return inserted, err
+}
+
+// Upsert inserts or updates obj.
+func (b *BreakerStore[T]) Upsert(ctx context.Context, obj *T, conflictColumns []string, updateColumns []string) error {
+	return b.breaker.do(ctx, func() error { return b.next.Upsert(ctx, obj, conflictColumns, updateColumns) })
+}
+
+// UpsertBatch inserts or updates objs.
+func (b *BreakerStore[T]) UpsertBatch(ctx context.Context, objs []*T, conflictColumns []string, updateColumns []string) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.UpsertBatch(ctx, objs, conflictColumns, updateColumns)
+		return err
+	})
+	return affected, err
+}
+
+// Update modifies an existing object in the database.
+func (b *BreakerStore[T]) Update(ctx context.Context, obj *T) error {
+	return b.breaker.do(ctx, func() error { return b.next.Update(ctx, obj) })
+}
+
+// UpdateNonZero updates the non-zero fields of obj, or the given columns.
+func (b *BreakerStore[T]) UpdateNonZero(ctx context.Context, obj *T, columns ...string) error {
+	return b.breaker.do(ctx, func() error { return b.next.UpdateNonZero(ctx, obj, columns...) })
+}
+
+// UpdateWhere updates the given columns of every object matching opts.
+func (b *BreakerStore[T]) UpdateWhere(ctx context.Context, opts *where.Options, fields map[string]any) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.UpdateWhere(ctx, opts, fields)
+		return err
+	})
+	return affected, err
+}
+
+// UpdateMany updates the given columns of every object matching opts.
+func (b *BreakerStore[T]) UpdateMany(ctx context.Context, opts *where.Options, fields map[string]any) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.UpdateMany(ctx, opts, fields)
+		return err
+	})
+	return affected, err
+}
+
+// UpdateExpr sets column to a SQL expression on every object matching opts.
+func (b *BreakerStore[T]) UpdateExpr(ctx context.Context, opts *where.Options, column string, expr string, args ...any) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.UpdateExpr(ctx, opts, column, expr, args...)
+		return err
+	})
+	return affected, err
+}
+
+// Increment adds delta to column on every object matching opts.
+func (b *BreakerStore[T]) Increment(ctx context.Context, opts *where.Options, column string, delta int64) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.Increment(ctx, opts, column, delta)
+		return err
+	})
+	return affected, err
+}
+
+// Delete removes the objects matching opts.
+func (b *BreakerStore[T]) Delete(ctx context.Context, opts *where.Options) error {
+	return b.breaker.do(ctx, func() error { return b.next.Delete(ctx, opts) })
+}
+
+// DeleteByKey removes the object identified by key.
+func (b *BreakerStore[T]) DeleteByKey(ctx context.Context, key map[string]any) error {
+	return b.breaker.do(ctx, func() error { return b.next.DeleteByKey(ctx, key) })
+}
+
+// DeleteInChunks removes the objects matching opts by chunks of chunkSize rows.
+func (b *BreakerStore[T]) DeleteInChunks(ctx context.Context, opts *where.Options, chunkSize int, progress func(deleted int64)) (deleted int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		deleted, err = b.next.DeleteInChunks(ctx, opts, chunkSize, progress)
+		return err
+	})
+	return deleted, err
+}
+
+// Purge permanently removes the objects matching opts.
+func (b *BreakerStore[T]) Purge(ctx context.Context, opts *where.Options) error {
+	return b.breaker.do(ctx, func() error { return b.next.Purge(ctx, opts) })
+}
+
+// Restore brings back the soft-deleted objects matching opts.
+func (b *BreakerStore[T]) Restore(ctx context.Context, opts *where.Options) (restored int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		restored, err = b.next.Restore(ctx, opts)
+		return err
+	})
+	return restored, err
+}
+
+// Get retrieves a single object matching opts.
+func (b *BreakerStore[T]) Get(ctx context.Context, opts *where.Options) (ret *T, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, err = b.next.Get(ctx, opts)
+		return err
+	})
+	return ret, err
+}
+
+// GetOrNil retrieves a single object matching opts, or nil when none matches.
+func (b *BreakerStore[T]) GetOrNil(ctx context.Context, opts *where.Options) (ret *T, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, err = b.next.GetOrNil(ctx, opts)
+		return err
+	})
+	return ret, err
+}
+
+// GetMany retrieves the objects whose primary key is in ids.
+func (b *BreakerStore[T]) GetMany(ctx context.Context, ids []any) (ret []*T, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, err = b.next.GetMany(ctx, ids)
+		return err
+	})
+	return ret, err
+}
+
+// GetByKey retrieves the object identified by key.
+func (b *BreakerStore[T]) GetByKey(ctx context.Context, key map[string]any) (ret *T, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, err = b.next.GetByKey(ctx, key)
+		return err
+	})
+	return ret, err
+}
+
+// List retrieves the objects matching opts.
+func (b *BreakerStore[T]) List(ctx context.Context, opts *where.Options) (count int64, ret []*T, err error) {
+	err = b.breaker.do(ctx, func() error {
+		count, ret, err = b.next.List(ctx, opts)
+		return err
+	})
+	return count, ret, err
+}
+
+// ListByCursor retrieves a page of objects matching opts after cursor.
+func (b *BreakerStore[T]) ListByCursor(ctx context.Context, opts *where.Options, cursor string, limit int) (ret []*T, next string, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, next, err = b.next.ListByCursor(ctx, opts, cursor, limit)
+		return err
+	})
+	return ret, next, err
+}
+
+// Count returns the number of objects matching opts.
+func (b *BreakerStore[T]) Count(ctx context.Context, opts *where.Options) (count int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		count, err = b.next.Count(ctx, opts)
+		return err
+	})
+	return count, err
+}
+
+// Exists reports whether at least one object matches opts.
+func (b *BreakerStore[T]) Exists(ctx context.Context, opts *where.Options) (exists bool, err error) {
+	err = b.breaker.do(ctx, func() error {
+		exists, err = b.next.Exists(ctx, opts)
+		return err
+	})
+	return exists, err
+}
+
+// GetOrCreate retrieves the object matching opts, creating obj when none matches.
+func (b *BreakerStore[T]) GetOrCreate(ctx context.Context, opts *where.Options, obj *T) (ret *T, created bool, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, created, err = b.next.GetOrCreate(ctx, opts, obj)
+		return err
+	})
+	return ret, created, err
+}
+
+// Each iterates over the objects matching opts in batches.
+// Errors returned by fn only count as failures when they are database failures.
+func (b *BreakerStore[T]) Each(ctx context.Context, opts *where.Options, batchSize int, fn func([]*T) error) error {
+	return b.breaker.do(ctx, func() error { return b.next.Each(ctx, opts, batchSize, fn) })
+}
+
+// Pluck queries a single column of the objects matching opts.
+func (b *BreakerStore[T]) Pluck(ctx context.Context, column string, dest any, opts *where.Options) error {
+	return b.breaker.do(ctx, func() error { return b.next.Pluck(ctx, column, dest, opts) })
+}
+
+// Aggregate computes agg over column for the objects matching opts.
+func (b *BreakerStore[T]) Aggregate(ctx context.Context, opts *where.Options, agg Agg, column string) (ret float64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		ret, err = b.next.Aggregate(ctx, opts, agg, column)
+		return err
+	})
+	return ret, err
+}
+
+// RawQuery runs a raw SQL query and scans its result into dest.
+func (b *BreakerStore[T]) RawQuery(ctx context.Context, dest any, sql string, args ...any) error {
+	return b.breaker.do(ctx, func() error { return b.next.RawQuery(ctx, dest, sql, args...) })
+}
+
+// Exec runs a raw SQL statement and returns the number of rows affected.
+func (b *BreakerStore[T]) Exec(ctx context.Context, sql string, args ...any) (affected int64, err error) {
+	err = b.breaker.do(ctx, func() error {
+		affected, err = b.next.Exec(ctx, sql, args...)
+		return err
+	})
+	return affected, err
+}
+
+// Tx runs fn inside a transaction.
+// Errors returned by fn only count as failures when they are database failures.
+func (b *BreakerStore[T]) Tx(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return b.breaker.do(ctx, func() error { return b.next.Tx(ctx, fn) })
+}
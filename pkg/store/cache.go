@@ -0,0 +1,530 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+const (
+	// defaultCacheTTL defines the time objects are cached for when no TTL is configured.
+	defaultCacheTTL = 5 * time.Minute
+	// defaultCachePrefix defines the prefix of the cache keys when none is configured.
+	defaultCachePrefix = "store"
+)
+
+// ErrCacheMiss is returned by a CacheBackend when the key is not cached.
+var ErrCacheMiss = errors.New("cache miss")
+
+// CacheBackend defines the key-value storage used by CachedStore.
+type CacheBackend interface {
+	// Get returns the value cached under key, or ErrCacheMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set caches value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Del removes the given keys.
+	Del(ctx context.Context, keys ...string) error
+}
+
+// redisCache is a CacheBackend storing values in Redis.
+type redisCache struct {
+	client redis.UniversalClient
+}
+
+// RedisCache returns a CacheBackend storing values in Redis.
+func RedisCache(client redis.UniversalClient) CacheBackend {
+	return &redisCache{client: client}
+}
+
+// Get implements CacheBackend.
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return value, err
+}
+
+// Set implements CacheBackend.
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Del implements CacheBackend.
+func (c *redisCache) Del(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// CacheOption defines a function type for configuring the CachedStore.
+type CacheOption func(*cacheOptions)
+
+// cacheOptions holds the configuration of a CachedStore.
+type cacheOptions struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	prefix      string
+}
+
+// WithCacheTTL sets the time objects are cached for. Defaults to 5 minutes.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithNegativeCacheTTL enables negative caching: lookups of missing objects are
+// cached for ttl, so repeated lookups of unknown keys do not hit the database.
+func WithNegativeCacheTTL(ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithCachePrefix sets the prefix of the cache keys. Defaults to "store".
+func WithCachePrefix(prefix string) CacheOption {
+	return func(o *cacheOptions) {
+		o.prefix = prefix
+	}
+}
+
+// CacheStats holds the lookup statistics of a CachedStore.
+type CacheStats struct {
+	// Hits is the number of lookups served from the cache, including negatively cached ones.
+	Hits uint64
+	// Misses is the number of cacheable lookups served from the database.
+	Misses uint64
+}
+
+// HitRatio returns the ratio of lookups served from the cache, between 0 and 1.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// CachedStore is a read-through cache decorator for Store. Get calls looking an
+// object up by primary key only, e.g. where.F("id", 42), are served from the cache,
+// every other operation is passed to the store. Cached objects are invalidated by the
+// writes made through the CachedStore, once the enclosing transaction is committed.
+// As the rows modified by raw statements are unknown, Exec invalidates all the cached
+// objects of T at once, by moving their keys to a new generation.
+// Writes made through the underlying Store and writes made by other processes are only
+// picked up once the entries expire.
+type CachedStore[T any] struct {
+	IStore[T]
+
+	store  *Store[T]
+	cache  CacheBackend
+	opts   cacheOptions
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedStore wraps s with a read-through cache stored in cache, such as
+// RedisCache for shared caches or MemoryCache for single-instance services.
+// s must be a Store or a decorator wrapping one, the cache keys are derived from
+// its primary key, tenancy and transactions. It panics otherwise.
+func NewCachedStore[T any](s IStore[T], cache CacheBackend, opts ...CacheOption) *CachedStore[T] {
+	base, ok := baseStore(s)
+	if !ok {
+		panic(fmt.Sprintf("store: NewCachedStore requires a decorated *Store, got %T", s))
+	}
+
+	o := cacheOptions{
+		ttl:    defaultCacheTTL,
+		prefix: defaultCachePrefix,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &CachedStore[T]{
+		IStore: s,
+		store:  base,
+		cache:  cache,
+		opts:   o,
+	}
+}
+
+// Unwrap returns the store wrapped by the cache.
+func (c *CachedStore[T]) Unwrap() IStore[T] {
+	return c.IStore
+}
+
+// Get retrieves a single object, from the cache when it is looked up by primary key.
+func (c *CachedStore[T]) Get(ctx context.Context, opts *where.Options) (*T, error) {
+	key, ok := c.lookupKey(ctx, opts)
+	if !ok {
+		return c.IStore.Get(ctx, opts)
+	}
+
+	if value, err := c.cache.Get(ctx, key); err == nil {
+		if len(value) == 0 {
+			c.hits.Add(1)
+			return nil, wrapError(gorm.ErrRecordNotFound)
+		}
+		var obj T
+		if err := json.Unmarshal(value, &obj); err == nil {
+			c.hits.Add(1)
+			return &obj, nil
+		}
+	} else if !errors.Is(err, ErrCacheMiss) {
+		c.store.logger.Error(ctx, err, "Failed to retrieve object from cache", "key", key)
+	}
+
+	c.misses.Add(1)
+	obj, err := c.IStore.Get(ctx, opts)
+	switch {
+	case err == nil:
+		c.set(ctx, key, obj)
+	case IsNotFound(err) && c.opts.negativeTTL > 0:
+		if err := c.cache.Set(ctx, key, nil, c.opts.negativeTTL); err != nil {
+			c.store.logger.Error(ctx, err, "Failed to cache missing object", "key", key)
+		}
+	}
+	return obj, err
+}
+
+// Stats returns the lookup statistics of the cache.
+func (c *CachedStore[T]) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Create inserts a new object and invalidates its negatively cached lookups.
+func (c *CachedStore[T]) Create(ctx context.Context, obj *T) error {
+	if err := c.IStore.Create(ctx, obj); err != nil {
+		return err
+	}
+	c.invalidate(ctx, obj)
+	return nil
+}
+
+// CreateBatch inserts objs in batches and invalidates their negatively cached lookups.
+func (c *CachedStore[T]) CreateBatch(ctx context.Context, objs []*T, batchSize int) (int64, error) {
+	inserted, err := c.IStore.CreateBatch(ctx, objs, batchSize)
+	c.invalidate(ctx, objs...)
+	return inserted, err
+}
+
+// Upsert inserts or updates obj and invalidates its cached state.
+func (c *CachedStore[T]) Upsert(ctx context.Context, obj *T, conflictColumns []string, updateColumns []string) error {
+	if err := c.IStore.Upsert(ctx, obj, conflictColumns, updateColumns); err != nil {
+		return err
+	}
+	c.invalidate(ctx, obj)
+	return nil
+}
+
+// UpsertBatch inserts or updates objs and invalidates their cached state.
+func (c *CachedStore[T]) UpsertBatch(ctx context.Context, objs []*T, conflictColumns []string, updateColumns []string) (int64, error) {
+	affected, err := c.IStore.UpsertBatch(ctx, objs, conflictColumns, updateColumns)
+	c.invalidate(ctx, objs...)
+	return affected, err
+}
+
+// GetOrCreate retrieves or creates obj and invalidates its negatively cached lookups.
+func (c *CachedStore[T]) GetOrCreate(ctx context.Context, opts *where.Options, obj *T) (*T, bool, error) {
+	ret, created, err := c.IStore.GetOrCreate(ctx, opts, obj)
+	if created {
+		c.invalidate(ctx, obj)
+	}
+	return ret, created, err
+}
+
+// Update modifies an existing object and invalidates its cached state.
+func (c *CachedStore[T]) Update(ctx context.Context, obj *T) error {
+	if err := c.IStore.Update(ctx, obj); err != nil {
+		return err
+	}
+	c.invalidate(ctx, obj)
+	return nil
+}
+
+// UpdateWhere updates the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) UpdateWhere(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := c.IStore.UpdateWhere(ctx, opts, fields)
+	c.invalidateKeys(ctx, keys)
+	return affected, err
+}
+
+// UpdateNonZero updates the non-zero fields of obj, or the given columns, and invalidates its cached state.
+func (c *CachedStore[T]) UpdateNonZero(ctx context.Context, obj *T, columns ...string) error {
+	if err := c.IStore.UpdateNonZero(ctx, obj, columns...); err != nil {
+		return err
+	}
+	c.invalidate(ctx, obj)
+	return nil
+}
+
+// UpdateMany updates the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) UpdateMany(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := c.IStore.UpdateMany(ctx, opts, fields)
+	c.invalidateKeys(ctx, keys)
+	return affected, err
+}
+
+// UpdateExpr updates the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) UpdateExpr(ctx context.Context, opts *where.Options, column string, expr string, args ...any) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := c.IStore.UpdateExpr(ctx, opts, column, expr, args...)
+	c.invalidateKeys(ctx, keys)
+	return affected, err
+}
+
+// Increment updates the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) Increment(ctx context.Context, opts *where.Options, column string, delta int64) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := c.IStore.Increment(ctx, opts, column, delta)
+	c.invalidateKeys(ctx, keys)
+	return affected, err
+}
+
+// Delete removes the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) Delete(ctx context.Context, opts *where.Options) error {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return err
+	}
+
+	err = c.IStore.Delete(ctx, opts)
+	c.invalidateKeys(ctx, keys)
+	return err
+}
+
+// DeleteByKey removes the object identified by key and invalidates its cached state.
+func (c *CachedStore[T]) DeleteByKey(ctx context.Context, key map[string]any) error {
+	opts, err := c.store.keyWhere(ctx, key)
+	if err != nil {
+		return err
+	}
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return err
+	}
+
+	err = c.IStore.DeleteByKey(ctx, key)
+	c.invalidateKeys(ctx, keys)
+	return err
+}
+
+// DeleteInChunks removes the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) DeleteInChunks(ctx context.Context, opts *where.Options, chunkSize int, progress func(deleted int64)) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, false)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := c.IStore.DeleteInChunks(ctx, opts, chunkSize, progress)
+	c.invalidateKeys(ctx, keys)
+	return deleted, err
+}
+
+// Purge permanently removes the objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) Purge(ctx context.Context, opts *where.Options) error {
+	keys, err := c.keysWhere(ctx, opts, true)
+	if err != nil {
+		return err
+	}
+
+	err = c.IStore.Purge(ctx, opts)
+	c.invalidateKeys(ctx, keys)
+	return err
+}
+
+// Restore brings back the soft-deleted objects matching opts and invalidates their cached state.
+func (c *CachedStore[T]) Restore(ctx context.Context, opts *where.Options) (int64, error) {
+	keys, err := c.keysWhere(ctx, opts, true)
+	if err != nil {
+		return 0, err
+	}
+
+	restored, err := c.IStore.Restore(ctx, opts)
+	c.invalidateKeys(ctx, keys)
+	return restored, err
+}
+
+// Exec runs a raw SQL statement and invalidates all the cached objects, as the rows it
+// modified are unknown.
+func (c *CachedStore[T]) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	affected, err := c.IStore.Exec(ctx, sql, args...)
+	c.bumpGeneration(ctx)
+	if _, ok := txFromContext(ctx, c.store.storage); ok {
+		afterCommit(ctx, c.store.storage, func() { c.bumpGeneration(ctx) })
+	}
+	return affected, err
+}
+
+// set caches obj under key.
+func (c *CachedStore[T]) set(ctx context.Context, key string, obj *T) {
+	value, err := json.Marshal(obj)
+	if err == nil {
+		err = c.cache.Set(ctx, key, value, c.opts.ttl)
+	}
+	if err != nil {
+		c.store.logger.Error(ctx, err, "Failed to cache object", "key", key)
+	}
+}
+
+// invalidate removes the cached state of objs.
+func (c *CachedStore[T]) invalidate(ctx context.Context, objs ...*T) {
+	pk, err := primaryField[T](c.store.db(ctx))
+	if err != nil {
+		c.store.logger.Error(ctx, err, "Failed to invalidate cached objects")
+		return
+	}
+
+	prefix := c.keyPrefix(ctx)
+	keys := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		if value, zero := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem()); !zero {
+			keys = append(keys, prefix+fmt.Sprint(value))
+		}
+	}
+	c.invalidateKeys(ctx, keys)
+}
+
+// invalidateKeys removes keys from the cache now and once the enclosing transaction,
+// if any, has been committed, so that concurrent lookups cannot cache stale state.
+func (c *CachedStore[T]) invalidateKeys(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	del := func() {
+		if err := c.cache.Del(ctx, keys...); err != nil {
+			c.store.logger.Error(ctx, err, "Failed to invalidate cached objects", "keys", keys)
+		}
+	}
+	del()
+	if _, ok := txFromContext(ctx, c.store.storage); ok {
+		afterCommit(ctx, c.store.storage, del)
+	}
+}
+
+// keysWhere returns the cache keys of the objects matching opts.
+func (c *CachedStore[T]) keysWhere(ctx context.Context, opts *where.Options, unscoped bool) ([]string, error) {
+	db := c.store.db(ctx, conditions(opts))
+	pk, err := primaryField[T](db)
+	if err != nil {
+		return nil, err
+	}
+	if unscoped {
+		db = db.Unscoped()
+	}
+
+	ids := reflect.New(reflect.SliceOf(pk.FieldType))
+	if err := db.Model(new(T)).Pluck(pk.DBName, ids.Interface()).Error; err != nil {
+		c.store.logger.Error(ctx, err, "Failed to retrieve cached objects keys", "conditions", opts)
+		return nil, wrapError(err)
+	}
+
+	prefix := c.keyPrefix(ctx)
+	keys := make([]string, 0, ids.Elem().Len())
+	for i := 0; i < ids.Elem().Len(); i++ {
+		keys = append(keys, prefix+fmt.Sprint(ids.Elem().Index(i).Interface()))
+	}
+	return keys, nil
+}
+
+// lookupKey returns the cache key of a Get call when opts looks an object up by
+// primary key only and the call is not part of a transaction.
+func (c *CachedStore[T]) lookupKey(ctx context.Context, opts *where.Options) (string, bool) {
+	if opts == nil || len(opts.Filters) != 1 || len(opts.Clauses) > 0 || len(opts.Queries) > 0 ||
+		len(opts.Joins) > 0 || len(opts.Preloads) > 0 || len(opts.Scopes) > 0 || len(opts.Columns) > 0 || opts.TableName != "" || len(opts.Searches) > 0 ||
+		opts.Unscoped || opts.SkipDefaultWhere || opts.Locking != "" || opts.Offset > 0 {
+		return "", false
+	}
+	// Transactions read their own uncommitted writes, which must not be cached.
+	if _, ok := txFromContext(ctx, c.store.storage); ok {
+		return "", false
+	}
+
+	pk, err := primaryField[T](c.store.db(ctx))
+	if err != nil {
+		return "", false
+	}
+	value, ok := opts.Filters[pk.DBName]
+	if !ok || value == nil {
+		return "", false
+	}
+	// Slices match several rows and pointers are formatted as addresses, neither
+	// identifies the cached object.
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Pointer:
+		return "", false
+	}
+	return c.key(ctx, value), true
+}
+
+// key returns the cache key of the object with the given primary key value.
+// Keys are scoped to the tenant carried by ctx when the store is configured with WithTenancy.
+func (c *CachedStore[T]) key(ctx context.Context, pk any) string {
+	return c.keyPrefix(ctx) + fmt.Sprint(pk)
+}
+
+// keyPrefix returns the prefix of the cache keys of the objects visible from ctx.
+func (c *CachedStore[T]) keyPrefix(ctx context.Context) string {
+	parts := []string{c.opts.prefix, reflect.TypeFor[T]().Name()}
+	if generation := c.generation(ctx); generation != "" {
+		parts = append(parts, generation)
+	}
+	if tenant, ok := c.store.tenant(ctx); ok {
+		parts = append(parts, fmt.Sprint(tenant))
+	}
+	return strings.Join(parts, ":") + ":"
+}
+
+// generationKey returns the cache key holding the current generation of the keys.
+func (c *CachedStore[T]) generationKey() string {
+	return strings.Join([]string{c.opts.prefix, reflect.TypeFor[T]().Name(), "generation"}, ":")
+}
+
+// generation returns the current generation of the keys, empty until Exec is called.
+func (c *CachedStore[T]) generation(ctx context.Context) string {
+	value, err := c.cache.Get(ctx, c.generationKey())
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		c.store.logger.Error(ctx, err, "Failed to retrieve cache generation", "key", c.generationKey())
+	}
+	return string(value)
+}
+
+// bumpGeneration moves the keys to a new generation, so that all the objects cached
+// so far are no longer found and expire. The generation outlives them, so that keys
+// never go back to a previous generation still holding cached objects.
+func (c *CachedStore[T]) bumpGeneration(ctx context.Context) {
+	ttl := max(c.opts.ttl, c.opts.negativeTTL)
+	generation := "g" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := c.cache.Set(ctx, c.generationKey(), []byte(generation), ttl); err != nil {
+		c.store.logger.Error(ctx, err, "Failed to invalidate cached objects", "key", c.generationKey())
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ListByCursor retrieves up to limit objects matching the provided where options,
+// ordered by primary key descending, and starting right after the object encoded in cursor.
+// An empty cursor starts from the beginning. The returned next cursor is empty when
+// there are no more objects.
+//
+// Unlike offset pagination, keyset pagination does not skip or repeat objects when rows
+// are inserted while iterating, and its cost does not grow with the page number.
+// Offset, limit and order carried by opts are ignored.
+func (s *Store[T]) ListByCursor(ctx context.Context, opts *where.Options, cursor string, limit int) (ret []*T, next string, err error) {
+	ctx, op := s.begin(ctx, "ListByCursor")
+	defer func() { op.end(err) }()
+
+	if limit <= 0 {
+		limit = defaultBatchSize
+	}
+
+	db := s.reader(ctx, conditions(opts))
+	pk, err := primaryField[T](db)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to list objects by cursor", "conditions", opts)
+		return nil, "", err
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	if cursor != "" {
+		key, err := decodeCursor(cursor, pk)
+		if err != nil {
+			return nil, "", err
+		}
+		db = db.Where(clause.Lt{Column: column, Value: key})
+	}
+
+	// Fetch one extra object to find out whether there is a next page.
+	err = db.Order(clause.OrderByColumn{Column: column, Desc: true}).Limit(limit + 1).Find(&ret).Error
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to list objects by cursor", "conditions", opts, "cursor", cursor)
+		return nil, "", wrapError(err)
+	}
+
+	if len(ret) > limit {
+		ret = ret[:limit]
+		if next, err = encodeCursor(ctx, pk, ret[limit-1]); err != nil {
+			return nil, "", err
+		}
+	}
+	return ret, next, nil
+}
+
+// cursorSorts returns the sort of the objects listed by ListByCursor, which its
+// cursors are tied to.
+func cursorSorts(pk *schema.Field) []where.SortColumn {
+	return []where.SortColumn{{Column: pk.DBName, Direction: where.Desc}}
+}
+
+// encodeCursor builds the opaque cursor pointing right after obj.
+func encodeCursor[T any](ctx context.Context, pk *schema.Field, obj *T) (string, error) {
+	value, _ := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+	return where.Cursor(cursorSorts(pk), value)
+}
+
+// decodeCursor extracts the primary key value encoded in cursor, typed after the primary key field.
+func decodeCursor(cursor string, pk *schema.Field) (any, error) {
+	values, err := where.DecodeCursor(cursor, cursorSorts(pk))
+	if err != nil {
+		return nil, err
+	}
+
+	key := reflect.New(pk.FieldType)
+	if err := json.Unmarshal(values[0], key.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return key.Elem().Interface(), nil
+}
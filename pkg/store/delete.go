@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// DeleteInChunks deletes the objects matching the provided where options by chunks of
+// chunkSize rows, until none remains, and returns the number of rows deleted. Unlike
+// Delete, which removes every row in a single statement locking them all until it
+// completes, each chunk is deleted by its own short statement, so large deletions do
+// not block concurrent writers for minutes.
+//
+// progress, when not nil, is called after every chunk with the number of rows deleted
+// so far. Deletion stops between two chunks when ctx is canceled, leaving the rows
+// deleted so far deleted. Objects are soft-deleted when T supports it, unless opts is
+// unscoped. Offset, limit and order carried by opts are ignored.
+func (s *Store[T]) DeleteInChunks(ctx context.Context, opts *where.Options, chunkSize int, progress func(deleted int64)) (deleted int64, err error) {
+	ctx, op := s.begin(ctx, "DeleteInChunks")
+	defer func() { op.end(err) }()
+
+	if chunkSize <= 0 {
+		chunkSize = defaultBatchSize
+	}
+	if err := runDeleteHooks(ctx, s.hooks.beforeDelete, opts); err != nil {
+		return 0, err
+	}
+
+	pk, err := primaryField[T](s.db(ctx))
+	if err != nil {
+		return 0, err
+	}
+	selection := conditions(opts)
+	selection.Limit = chunkSize
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		// Select the keys of the next chunk, then delete the chunk by primary key, which
+		// is portable across databases unlike DELETE ... LIMIT.
+		ids := reflect.New(reflect.SliceOf(pk.FieldType))
+		err := s.retry(ctx, func() error {
+			return s.db(ctx, selection).Model(new(T)).Pluck(pk.DBName, ids.Interface()).Error
+		})
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to select objects to delete", "conditions", opts)
+			return deleted, wrapError(err)
+		}
+		if ids.Elem().Len() == 0 {
+			break
+		}
+
+		chunk := where.F(pk.DBName, ids.Elem().Interface()).U(selection.Unscoped)
+		before, err := s.snapshotWhere(ctx, chunk, selection.Unscoped)
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to retrieve objects state before delete", "conditions", opts)
+			return deleted, wrapError(err)
+		}
+		affected, err := s.remove(ctx, chunk)
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to delete chunk from database", "conditions", opts, "deleted", deleted)
+			return deleted, wrapError(err)
+		}
+		deleted += affected
+		s.publish(ctx, OperationDelete, before, nil)
+		if progress != nil {
+			progress(deleted)
+		}
+
+		// A short chunk is the last one, and a chunk deleting nothing would repeat forever.
+		if ids.Elem().Len() < chunkSize || affected == 0 {
+			break
+		}
+	}
+	return deleted, runDeleteHooks(ctx, s.hooks.afterDelete, opts)
+}
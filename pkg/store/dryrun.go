@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// dryRunKey is the context key holding the DryRunResult of a dry run.
+type dryRunKey struct{}
+
+// Statement is an SQL statement generated by a store operation.
+type Statement struct {
+	// SQL is the statement with placeholders for its bind variables.
+	SQL string
+	// Vars holds the bind variables of the statement.
+	Vars []any
+}
+
+// DryRunResult collects the statements generated by the store operations called
+// with a dry-run context.
+type DryRunResult struct {
+	mu         sync.Mutex
+	statements []Statement
+}
+
+// Statements returns the statements generated so far, in order.
+func (r *DryRunResult) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.statements)
+}
+
+// add records a statement.
+func (r *DryRunResult) add(statement Statement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statements = append(r.statements, statement)
+}
+
+// DryRun returns a context in which store operations build their SQL statements
+// without executing them, for debugging and code review. The statements are
+// collected in the returned DryRunResult:
+//
+//	ctx, result := store.DryRun(ctx)
+//	_, _ = users.UpdateWhere(ctx, where.F("status", "expired"), map[string]any{"status": "closed"})
+//	fmt.Println(result.Statements())
+//
+// Reads return no objects and writes report no affected rows, so operations
+// depending on the outcome of a previous statement may take a different path than
+// they would on a real database. Transactions opened by Tx are still begun and
+// rolled back or committed on the database, only the statements they run are dry.
+func DryRun(ctx context.Context) (context.Context, *DryRunResult) {
+	result := &DryRunResult{}
+	return context.WithValue(ctx, dryRunKey{}, result), result
+}
+
+// IsDryRun reports whether ctx is a dry-run context returned by DryRun.
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(*DryRunResult)
+	return ok
+}
+
+// DryRunDB returns db building its statements into the DryRunResult carried by ctx,
+// or db itself outside of a dry run. Packages running their own queries next to the
+// store, such as migrate, use it to honor DryRun.
+func DryRunDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return dryRun(ctx, db)
+}
+
+// dryRun makes db build its statements into the DryRunResult carried by ctx, if any,
+// instead of executing them.
+func dryRun(ctx context.Context, db *gorm.DB) *gorm.DB {
+	result, ok := ctx.Value(dryRunKey{}).(*DryRunResult)
+	if !ok {
+		return db
+	}
+	return db.Session(&gorm.Session{DryRun: true, Logger: &dryRunLogger{Interface: db.Logger, result: result}})
+}
+
+// dryRunLogger is a GORM logger recording the statements built in dry-run mode,
+// then delegating to the wrapped logger.
+type dryRunLogger struct {
+	gormlogger.Interface
+	result *DryRunResult
+}
+
+// LogMode implements gorm logger.Interface.
+func (l *dryRunLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &dryRunLogger{Interface: l.Interface.LogMode(level), result: l.result}
+}
+
+// Trace implements gorm logger.Interface. Evaluating fc records the statement through
+// ParamsFilter, the wrapped logger gets the evaluated values so it is recorded once.
+func (l *dryRunLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, rows := fc()
+	l.Interface.Trace(ctx, begin, func() (string, int64) { return sql, rows }, err)
+}
+
+// ParamsFilter implements gorm.ParamsFilter, which GORM calls with the statement
+// and its bind variables before interpolating them for logging.
+func (l *dryRunLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	l.result.add(Statement{SQL: sql, Vars: slices.Clone(params)})
+	if filter, ok := l.Interface.(gorm.ParamsFilter); ok {
+		return filter.ParamsFilter(ctx, sql, params...)
+	}
+	return sql, params
+}
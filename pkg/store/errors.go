@@ -0,0 +1,122 @@
+package store
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+var (
+	// ErrNotFound is returned when no record matches the given conditions.
+	ErrNotFound = errors.New("record not found")
+
+	// ErrDuplicateKey is returned when a write violates a unique constraint.
+	ErrDuplicateKey = errors.New("duplicate key")
+
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+	// It is where.ErrInvalidCursor, returned by where.DecodeCursor.
+	ErrInvalidCursor = where.ErrInvalidCursor
+
+	// ErrNoShardKey is returned when the shard of a query cannot be determined.
+	ErrNoShardKey = errors.New("no shard key")
+
+	// ErrNoTenant is returned when a store scoped to tenants is called without tenant.
+	ErrNoTenant = errors.New("no tenant")
+
+	// ErrIncompleteKey is returned when a primary key lookup misses a part of the key.
+	ErrIncompleteKey = errors.New("incomplete primary key")
+)
+
+// storeError attaches a store sentinel error to the original driver error.
+// Both errors stay reachable through errors.Is and errors.As, so existing callers
+// checking gorm.ErrRecordNotFound keep working.
+type storeError struct {
+	kind error
+	err  error
+}
+
+// Error returns the message of the original error.
+func (e *storeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the store sentinel error together with the original error.
+func (e *storeError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// IsNotFound reports whether err indicates that no record was found.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// IsDuplicateKey reports whether err indicates a unique constraint violation.
+func IsDuplicateKey(err error) bool {
+	return errors.Is(err, ErrDuplicateKey) || isDuplicateKeyError(err)
+}
+
+// wrapError classifies err and wraps it with the matching store sentinel error.
+// Errors that cannot be classified are returned unchanged.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var se *storeError
+	if errors.As(err, &se) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &storeError{kind: ErrNotFound, err: err}
+	case isDuplicateKeyError(err):
+		return &storeError{kind: ErrDuplicateKey, err: err}
+	}
+	return err
+}
+
+// isDuplicateKeyError detects unique constraint violations reported by the supported drivers.
+func isDuplicateKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	// MySQL: Error 1062 (23000): Duplicate entry
+	if code, ok := mysqlErrorNumber(err); ok {
+		return code == 1062
+	}
+
+	// PostgreSQL: unique_violation
+	if state, ok := sqlState(err); ok {
+		return state == "23505"
+	}
+
+	// SQLite does not expose a stable error type without cgo bindings, fall back to the message.
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// mysqlErrorNumber extracts the server error number from a MySQL driver error.
+func mysqlErrorNumber(err error) (uint16, bool) {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return me.Number, true
+	}
+	return 0, false
+}
+
+// sqlState extracts the SQLSTATE code from drivers exposing it, such as pgx.
+func sqlState(err error) (string, bool) {
+	var se interface{ SQLState() string }
+	if errors.As(err, &se) {
+		return se.SQLState(), true
+	}
+	return "", false
+}
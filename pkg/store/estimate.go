@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// estimateQueries holds, per dialect, the query returning the number of rows of a
+// table estimated by the statistics of the database.
+var estimateQueries = map[string]string{
+	"postgres": "SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)",
+	"mysql":    "SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+}
+
+// estimateCount returns the number of objects matching opts estimated by the table
+// statistics, when opts asks for an estimate with where.ApproxCount and restricts the
+// query to no condition. It returns false when the count must be exact.
+func (s *Store[T]) estimateCount(ctx context.Context, opts *where.Options) (int64, bool) {
+	if opts == nil || !opts.EstimateCount || len(opts.Filters) > 0 || len(opts.Clauses) > 0 ||
+		len(opts.Queries) > 0 || len(opts.Joins) > 0 || len(opts.Scopes) > 0 || len(opts.Havings) > 0 || len(opts.Searches) > 0 || opts.Distinct {
+		return 0, false
+	}
+	if _, ok := s.tenant(ctx); ok || s.checkTenant(ctx) != nil || (s.defaultWhere != nil && !opts.SkipDefaultWhere) {
+		return 0, false
+	}
+
+	db := s.reader(ctx)
+	query, ok := estimateQueries[db.Dialector.Name()]
+	if !ok {
+		return 0, false
+	}
+	// The table of the store, resolved by WithTableFunc, is overridden by opts.
+	table := db.Statement.Table
+	if opts.TableName != "" {
+		table = opts.TableName
+	}
+	if table == "" {
+		sch, err := schemaOf[T](db)
+		if err != nil {
+			return 0, false
+		}
+		table = sch.Table
+	}
+
+	// Tables never analyzed have no estimate, which PostgreSQL reports as -1.
+	var estimate sql.NullInt64
+	if err := db.Raw(query, table).Scan(&estimate).Error; err != nil || !estimate.Valid || estimate.Int64 <= 0 {
+		return 0, false
+	}
+	return estimate.Int64, true
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// Operation defines the kind of change described by an Event.
+type Operation string
+
+const (
+	// OperationCreate describes an inserted object.
+	OperationCreate Operation = "create"
+	// OperationUpdate describes an updated object.
+	OperationUpdate Operation = "update"
+	// OperationDelete describes a deleted object.
+	OperationDelete Operation = "delete"
+)
+
+// Event describes a change made to an object through the store.
+type Event[T any] struct {
+	// Entity is the name of the changed model type.
+	Entity string
+	// Operation is the kind of change.
+	Operation Operation
+	// Before is the state of the object before the change, nil for creations.
+	Before *T
+	// After is the state of the object after the change, nil for deletions.
+	After *T
+	// Time is the time at which the change was made.
+	Time time.Time
+}
+
+// ChangePublisher receives the change events emitted by a store.
+// Events of changes made within a transaction are published once the transaction
+// has been committed, and dropped when it is rolled back.
+type ChangePublisher[T any] func(ctx context.Context, event Event[T])
+
+// WithChangePublisher returns an Option that publishes an Event for every object
+// created by Create, CreateBatch, Upsert, UpsertBatch or GetOrCreate, updated by
+// Update, UpdateNonZero, UpdateWhere, UpdateExpr, Upsert or UpsertBatch, or removed
+// by Delete or Purge. Capturing the state before updates and deletions costs an
+// extra query per operation, and the state after updates made by conditions another.
+func WithChangePublisher[T any](publisher ChangePublisher[T]) Option[T] {
+	return func(s *Store[T]) {
+		s.publisher = publisher
+	}
+}
+
+// ChannelPublisher returns a ChangePublisher sending events to ch.
+// Sending blocks until the event is received or ctx is done, so ch should be buffered.
+func ChannelPublisher[T any](ch chan<- Event[T]) ChangePublisher[T] {
+	return func(ctx context.Context, event Event[T]) {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// publish emits a change event for every given before/after pair.
+func (s *Store[T]) publish(ctx context.Context, op Operation, before []*T, after []*T) {
+	if s.publisher == nil {
+		return
+	}
+
+	entity := reflect.TypeFor[T]().Name()
+	now := time.Now()
+	events := make([]Event[T], 0, max(len(before), len(after)))
+	for i := 0; i < max(len(before), len(after)); i++ {
+		event := Event[T]{Entity: entity, Operation: op, Time: now}
+		if i < len(before) {
+			event.Before = before[i]
+		}
+		if i < len(after) {
+			// Copy the object so that later changes made by the caller do not leak into the event.
+			obj := *after[i]
+			event.After = &obj
+		}
+		events = append(events, event)
+	}
+
+	afterCommit(ctx, s.storage, func() {
+		for _, event := range events {
+			s.publisher(ctx, event)
+		}
+	})
+}
+
+// snapshot loads the stored state of obj by primary key before it is changed.
+// It returns nil when no change publisher is configured or the object does not exist yet.
+func (s *Store[T]) snapshot(ctx context.Context, obj *T) (*T, error) {
+	if s.publisher == nil {
+		return nil, nil
+	}
+
+	db := s.db(ctx)
+	pk, err := primaryField[T](db)
+	if err != nil {
+		return nil, err
+	}
+	value, zero := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+	if zero {
+		return nil, nil
+	}
+
+	var before []*T
+	column := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	if err := db.Where(clause.Eq{Column: column, Value: value}).Limit(1).Find(&before).Error; err != nil {
+		return nil, err
+	}
+	if len(before) == 0 {
+		return nil, nil
+	}
+	return before[0], nil
+}
+
+// snapshotWhere loads the objects matching opts before they are updated or deleted.
+// It returns nil when no change publisher is configured.
+func (s *Store[T]) snapshotWhere(ctx context.Context, opts *where.Options, unscoped bool) ([]*T, error) {
+	if s.publisher == nil {
+		return nil, nil
+	}
+
+	var before []*T
+	db := s.db(ctx, opts)
+	if unscoped {
+		db = db.Unscoped()
+	}
+	if err := db.Find(&before).Error; err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// snapshotConflicts loads the stored state of the objects conflicting with objs on
+// conflictColumns, the primary key by default, before they are upserted. The result
+// is aligned with objs and holds nil for the objects to be inserted. It returns nil
+// when no change publisher is configured.
+func (s *Store[T]) snapshotConflicts(ctx context.Context, objs []*T, conflictColumns []string) ([]*T, error) {
+	if s.publisher == nil {
+		return nil, nil
+	}
+
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	fields := sch.PrimaryFields
+	if len(conflictColumns) > 0 {
+		fields = make([]*schema.Field, 0, len(conflictColumns))
+		for _, column := range conflictColumns {
+			field := sch.LookUpField(column)
+			if field == nil {
+				return nil, fmt.Errorf("unknown column %s of model %s", column, sch.Name)
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	// keyOf returns the values of the conflict columns of obj, printed so that they can be
+	// compared, with the conditions matching them. Objects with a zero primary key cannot conflict.
+	keyOf := func(obj *T) (string, clause.Expression, bool) {
+		parts := make([]string, 0, len(fields))
+		eqs := make([]clause.Expression, 0, len(fields))
+		for _, field := range fields {
+			value, zero := field.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+			if zero && field.PrimaryKey {
+				return "", nil, false
+			}
+			parts = append(parts, fmt.Sprint(value))
+			eqs = append(eqs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: value})
+		}
+		return strings.Join(parts, "\x00"), clause.And(eqs...), true
+	}
+
+	keys := make([]string, len(objs))
+	var conds []clause.Expression
+	for i, obj := range objs {
+		if key, cond, ok := keyOf(obj); ok {
+			keys[i], conds = key, append(conds, cond)
+		}
+	}
+	before := make([]*T, len(objs))
+	if len(conds) == 0 {
+		return before, nil
+	}
+
+	// Soft-deleted rows still conflict with the objects upserted.
+	var stored []*T
+	if err := s.db(ctx).Unscoped().Where(clause.Or(conds...)).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*T, len(stored))
+	for _, obj := range stored {
+		key, _, _ := keyOf(obj)
+		byKey[key] = obj
+	}
+	for i, key := range keys {
+		if key != "" {
+			before[i] = byKey[key]
+		}
+	}
+	return before, nil
+}
+
+// publishChanges publishes the update of the objects whose state before the change
+// is before, reloading their state after the change by primary key. Objects no longer
+// existing are skipped. As the change was made, failures to reload are only logged.
+func (s *Store[T]) publishChanges(ctx context.Context, before []*T) {
+	if s.publisher == nil || len(before) == 0 {
+		return
+	}
+
+	db := s.db(ctx)
+	pk, err := primaryField[T](db)
+	if err == nil {
+		ids := make([]any, 0, len(before))
+		for _, obj := range before {
+			id, _ := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+			ids = append(ids, id)
+		}
+		var objs []*T
+		column := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+		if err = db.Unscoped().Where(clause.IN{Column: column, Values: ids}).Find(&objs).Error; err == nil {
+			// Index the objects by key, printed so that keys of different integer types match.
+			byKey := make(map[string]*T, len(objs))
+			for _, obj := range objs {
+				id, _ := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+				byKey[fmt.Sprint(id)] = obj
+			}
+			changed := make([]*T, 0, len(before))
+			after := make([]*T, 0, len(before))
+			for i, obj := range before {
+				if updated, ok := byKey[fmt.Sprint(ids[i])]; ok {
+					changed, after = append(changed, obj), append(after, updated)
+				}
+			}
+			s.publish(ctx, OperationUpdate, changed, after)
+			return
+		}
+	}
+	s.logger.Error(ctx, err, "Failed to retrieve objects state after update")
+}
+
+// publishUpserted publishes the creation of the objects of objs which had no stored
+// state in before, as returned by snapshotConflicts, and the update of the others.
+func (s *Store[T]) publishUpserted(ctx context.Context, before []*T, objs []*T) {
+	if s.publisher == nil {
+		return
+	}
+
+	var created, updated []*T
+	for i, obj := range objs {
+		if i < len(before) && before[i] != nil {
+			updated = append(updated, before[i])
+		} else {
+			created = append(created, obj)
+		}
+	}
+	s.publish(ctx, OperationCreate, nil, created)
+	s.publishChanges(ctx, updated)
+}
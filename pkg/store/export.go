@@ -0,0 +1,139 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"time"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ExportFormat defines the encoding of the objects written by Export.
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header row holding the column names, then a row per object.
+	ExportCSV ExportFormat = "csv"
+	// ExportNDJSON writes an object per line, encoded in JSON like encoding/json does.
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// Export writes the objects matching the provided where options to w in the given
+// format, fetching and writing them in batches ordered by primary key, so that whole
+// tables can be downloaded without being held in memory. CSV exports hold the columns
+// selected with where.Select, or all the columns of T. Export stops at the first
+// error, having written the previous batches.
+func (s *Store[T]) Export(ctx context.Context, opts *where.Options, w io.Writer, format ExportFormat) (err error) {
+	ctx, op := s.begin(ctx, "Export")
+	defer func() { op.end(err) }()
+
+	var write func(batch []*T) error
+	switch format {
+	case ExportCSV:
+		write, err = s.csvWriter(ctx, opts, w)
+		if err != nil {
+			return err
+		}
+	case ExportNDJSON:
+		write = ndjsonWriter[T](w)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+	return s.Each(ctx, opts, defaultBatchSize, write)
+}
+
+// csvWriter writes the header row of a CSV export to w and returns the function
+// writing the batches of objects.
+func (s *Store[T]) csvWriter(ctx context.Context, opts *where.Options, w io.Writer) (func(batch []*T) error, error) {
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var fields []*schema.Field
+	for _, field := range sch.Fields {
+		if field.DBName != "" && (opts == nil || len(opts.Columns) == 0 || slices.Contains(opts.Columns, field.DBName)) {
+			fields = append(fields, field)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		record[i] = field.DBName
+	}
+	// The header is written even when no object is exported, so that the output can
+	// be imported back.
+	if err := cw.Write(record); err != nil {
+		return nil, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+
+	return func(batch []*T) error {
+		for _, obj := range batch {
+			row := reflect.ValueOf(obj).Elem()
+			for i, field := range fields {
+				value, _ := field.ValueOf(ctx, row)
+				record[i] = csvValue(value)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}, nil
+}
+
+// ndjsonWriter returns the function writing the batches of objects of an NDJSON export to w.
+func ndjsonWriter[T any](w io.Writer) func(batch []*T) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	return func(batch []*T) error {
+		for _, obj := range batch {
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+}
+
+// csvValue formats a column value as a CSV field. NULL values are empty.
+func csvValue(value any) string {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(valuer); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+			value, _ = valuer.Value()
+		}
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+
+	switch v := rv.Interface().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,3 @@
+// Package fake provides an in-memory implementation of the store operations for
+// unit tests, so that service-layer tests do not need a real database.
+package fake // import "github.com/miladystack/miladystack/pkg/store/fake"
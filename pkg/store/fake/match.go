@@ -0,0 +1,414 @@
+package fake
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ErrUnsupported is returned for where options the fake store cannot evaluate,
+// such as raw SQL conditions, joins or preloads.
+var ErrUnsupported = errors.New("unsupported by the fake store")
+
+// condition reports whether a row matches.
+type condition func(row reflect.Value) bool
+
+// compile builds the condition of a clause expression.
+func (s *Store[T]) compile(expr clause.Expression) (condition, error) {
+	switch e := expr.(type) {
+	case clause.Eq:
+		if normalize(e.Value) == nil {
+			return s.null(e.Column, true)
+		}
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c == 0 })
+	case clause.Neq:
+		if normalize(e.Value) == nil {
+			return s.null(e.Column, false)
+		}
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c != 0 })
+	case clause.Gt:
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c > 0 })
+	case clause.Gte:
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c >= 0 })
+	case clause.Lt:
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c < 0 })
+	case clause.Lte:
+		return s.comparison(e.Column, e.Value, func(c int) bool { return c <= 0 })
+	case clause.IN:
+		return s.in(e.Column, e.Values)
+	case clause.Like:
+		pattern, ok := e.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: like %v", ErrUnsupported, e.Value)
+		}
+		field, err := s.field(e.Column)
+		if err != nil {
+			return nil, err
+		}
+		re := likeToRegexp(pattern)
+		return func(row reflect.Value) bool {
+			return re.MatchString(fmt.Sprint(s.value(field, row)))
+		}, nil
+	case where.LikeExpr:
+		field, err := s.field(e.Column)
+		if err != nil {
+			return nil, err
+		}
+		re := regexp.MustCompile(e.Regexp())
+		return func(row reflect.Value) bool {
+			value := normalize(s.value(field, row))
+			return value != nil && re.MatchString(fmt.Sprint(value))
+		}, nil
+	case where.BetweenExpr:
+		field, err := s.field(e.Column)
+		if err != nil {
+			return nil, err
+		}
+		return func(row reflect.Value) bool {
+			value := s.value(field, row)
+			from, ok := compare(value, e.From)
+			if !ok || from < 0 {
+				return false
+			}
+			to, ok := compare(value, e.To)
+			return ok && to <= 0
+		}, nil
+	case where.JSONEqExpr:
+		keys, err := where.JSONPathKeys(e.Path)
+		if err != nil {
+			return nil, err
+		}
+		return s.json(e.Column, e.Value, func(doc, value any) bool {
+			for _, key := range keys {
+				var ok bool
+				if doc, ok = jsonChild(doc, key); !ok {
+					return false
+				}
+			}
+			return reflect.DeepEqual(doc, value)
+		})
+	case where.JSONContainsExpr:
+		return s.json(e.Column, e.Value, jsonContains)
+	case where.NotExpr:
+		cond, err := s.compile(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return func(row reflect.Value) bool { return !cond(row) }, nil
+	case clause.AndConditions:
+		return s.combine(e.Exprs, true)
+	case clause.Where:
+		return s.combine(e.Exprs, true)
+	case clause.OrConditions:
+		return s.combine(e.Exprs, false)
+	case clause.NotConditions:
+		or, err := s.combine(e.Exprs, false)
+		if err != nil {
+			return nil, err
+		}
+		return func(row reflect.Value) bool { return !or(row) }, nil
+	}
+	return nil, fmt.Errorf("%w: clause %T", ErrUnsupported, expr)
+}
+
+// search builds the condition matching the rows containing the keyword of term in any
+// of its columns, ignoring case like the default collations of MySQL and SQLite.
+func (s *Store[T]) search(term where.SearchTerm) (condition, error) {
+	fields := make([]*schema.Field, 0, len(term.Columns))
+	for _, column := range term.Columns {
+		field, err := s.field(column)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	keyword := strings.ToLower(term.Keyword)
+	return func(row reflect.Value) bool {
+		for _, field := range fields {
+			if value := normalize(s.value(field, row)); value != nil && strings.Contains(strings.ToLower(fmt.Sprint(value)), keyword) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// equal builds the condition matching column against value, any element of slices.
+func (s *Store[T]) equal(column any, value any) (condition, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]any, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+		return s.in(column, values)
+	}
+	if normalize(value) == nil {
+		return s.null(column, true)
+	}
+	return s.comparison(column, value, func(c int) bool { return c == 0 })
+}
+
+// null builds the condition matching column against NULL, like IS NULL when isNull
+// and IS NOT NULL otherwise.
+func (s *Store[T]) null(column any, isNull bool) (condition, error) {
+	field, err := s.field(column)
+	if err != nil {
+		return nil, err
+	}
+	return func(row reflect.Value) bool {
+		return (normalize(s.value(field, row)) == nil) == isNull
+	}, nil
+}
+
+// json builds the condition matching the JSON document held by column against value,
+// both decoded to the types of encoding/json.
+func (s *Store[T]) json(column string, value any, ok func(doc, value any) bool) (condition, error) {
+	field, err := s.field(column)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var want any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return nil, err
+	}
+	return func(row reflect.Value) bool {
+		var text []byte
+		switch v := normalize(s.value(field, row)).(type) {
+		case string:
+			text = []byte(v)
+		case []byte:
+			text = v
+		default:
+			return false
+		}
+		var doc any
+		return json.Unmarshal(text, &doc) == nil && ok(doc, want)
+	}, nil
+}
+
+// comparison builds the condition comparing column to value.
+func (s *Store[T]) comparison(column any, value any, ok func(int) bool) (condition, error) {
+	field, err := s.field(column)
+	if err != nil {
+		return nil, err
+	}
+	return func(row reflect.Value) bool {
+		c, comparable := compare(s.value(field, row), value)
+		return comparable && ok(c)
+	}, nil
+}
+
+// in builds the condition matching column against any of values.
+func (s *Store[T]) in(column any, values []any) (condition, error) {
+	field, err := s.field(column)
+	if err != nil {
+		return nil, err
+	}
+	return func(row reflect.Value) bool {
+		current := s.value(field, row)
+		for _, value := range values {
+			if c, ok := compare(current, value); ok && c == 0 {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// combine builds the condition joining exprs with AND or OR.
+func (s *Store[T]) combine(exprs []clause.Expression, and bool) (condition, error) {
+	conds := make([]condition, 0, len(exprs))
+	for _, expr := range exprs {
+		cond, err := s.compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	return func(row reflect.Value) bool {
+		for _, cond := range conds {
+			if cond(row) != and {
+				return !and
+			}
+		}
+		return and
+	}, nil
+}
+
+// field resolves a column given as a name or a clause.Column.
+func (s *Store[T]) field(column any) (*schema.Field, error) {
+	var name string
+	switch c := column.(type) {
+	case string:
+		name = c
+	case clause.Column:
+		name = c.Name
+	default:
+		return nil, fmt.Errorf("%w: column %v", ErrUnsupported, column)
+	}
+
+	// Accept table qualified names, such as users.name.
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	field := s.schema.LookUpField(name)
+	if field == nil {
+		return nil, fmt.Errorf("unknown column %s of model %s", name, s.schema.Name)
+	}
+	return field, nil
+}
+
+// compare compares two column values, converting numbers, times and driver values.
+// It reports false when the values are not comparable, like NULL in SQL.
+func compare(a, b any) (int, bool) {
+	a, b = normalize(a), normalize(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return cmp.Compare(af, bf), true
+		}
+	}
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Compare(bt), true
+		}
+	}
+	if ab, ok := a.(bool); ok {
+		if bb, ok := b.(bool); ok {
+			if ab == bb {
+				return 0, true
+			}
+			return 1, true
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)), true
+}
+
+// normalize dereferences pointers and resolves driver.Valuer values.
+func normalize(v any) any {
+	for {
+		if valuer, ok := v.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return nil
+			}
+			if _, same := value.(driver.Valuer); same {
+				return value
+			}
+			v = value
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Pointer {
+			return v
+		}
+		if rv.IsNil() {
+			return nil
+		}
+		v = rv.Elem().Interface()
+	}
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// likeToRegexp converts an SQL LIKE pattern into an anchored regular expression.
+func likeToRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// jsonChild returns the member of a decoded JSON object or the element of a decoded
+// JSON array designated by key.
+func jsonChild(doc any, key string) (any, bool) {
+	switch d := doc.(type) {
+	case map[string]any:
+		child, ok := d[key]
+		return child, ok
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i >= len(d) {
+			return nil, false
+		}
+		return d[i], true
+	}
+	return nil, false
+}
+
+// jsonContains reports whether the decoded JSON document doc contains value, like
+// JSON_CONTAINS: objects contain the members of value, arrays its elements, and a
+// scalar value is contained in the arrays holding it.
+func jsonContains(doc, value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		d, ok := doc.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, member := range v {
+			if child, ok := d[key]; !ok || !jsonContains(child, member) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		d, ok := doc.([]any)
+		if !ok {
+			return false
+		}
+		for _, elem := range v {
+			if !slices.ContainsFunc(d, func(child any) bool { return jsonContains(child, elem) }) {
+				return false
+			}
+		}
+		return true
+	}
+	if d, ok := doc.([]any); ok {
+		return slices.ContainsFunc(d, func(child any) bool { return reflect.DeepEqual(child, value) })
+	}
+	return reflect.DeepEqual(doc, value)
+}
@@ -0,0 +1,986 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// defaultBatchSize defines the batch size used when the caller does not provide a valid one.
+const defaultBatchSize = 100
+
+// deletedAtType is the type of the standard GORM soft delete field.
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+var _ store.IStore[any] = (*Store[any])(nil)
+
+// Store is an in-memory store of T objects for unit tests. It understands the gorm
+// tags of T to resolve column names, primary keys, unique indexes, timestamps and
+// soft delete, and evaluates where filters, comparison clauses, ordering and pagination.
+// Objects are copied in and out, so callers cannot change stored objects by mistake.
+// Store implements store.IStore, so it can stand in for a database backed store in
+// the tests of services depending on the interface.
+type Store[T any] struct {
+	mu      sync.RWMutex
+	schema  *schema.Schema
+	rows    map[string]*T
+	nextID  int64
+	deleted *schema.Field
+	uniques [][]*schema.Field
+}
+
+// NewStore creates an empty Store. It panics when T is not a valid gorm model.
+func NewStore[T any]() *Store[T] {
+	sch, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("fake: invalid model: %v", err))
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		panic(fmt.Sprintf("fake: model %s has no primary key", sch.Name))
+	}
+
+	s := &Store[T]{schema: sch, rows: make(map[string]*T)}
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			s.deleted = field
+		}
+		if field.Unique {
+			s.uniques = append(s.uniques, []*schema.Field{field})
+		}
+	}
+	for _, index := range sch.ParseIndexes() {
+		if index.Class != "UNIQUE" {
+			continue
+		}
+		fields := make([]*schema.Field, 0, len(index.Fields))
+		for _, opt := range index.Fields {
+			fields = append(fields, opt.Field)
+		}
+		s.uniques = append(s.uniques, fields)
+	}
+	return s
+}
+
+// Create inserts a copy of obj. A zero integer primary key is assigned automatically.
+func (s *Store[T]) Create(ctx context.Context, obj *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insert(ctx, obj)
+}
+
+// CreateBatch inserts copies of objs and returns the number of objects inserted.
+// It stops at the first object that cannot be inserted.
+func (s *Store[T]) CreateBatch(ctx context.Context, objs []*T, _ int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, obj := range objs {
+		if err := s.insert(ctx, obj); err != nil {
+			return int64(i), err
+		}
+	}
+	return int64(len(objs)), nil
+}
+
+// Update replaces the stored object with the same primary key as obj, or inserts
+// obj when no object has its primary key, like gorm Save.
+func (s *Store[T]) Update(ctx context.Context, obj *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, zero := s.key(ctx, reflect.ValueOf(obj).Elem())
+	if _, ok := s.rows[key]; zero || !ok {
+		return s.insert(ctx, obj)
+	}
+
+	row := *obj
+	s.touch(ctx, reflect.ValueOf(&row).Elem(), false)
+	if err := s.checkUnique(ctx, key, reflect.ValueOf(&row).Elem()); err != nil {
+		return err
+	}
+	s.rows[key] = &row
+	*obj = row
+	return nil
+}
+
+// UpdateWhere sets the given columns of every object matching opts and returns
+// the number of objects updated.
+func (s *Store[T]) UpdateWhere(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.match(conditions(opts))
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		row := *s.rows[key]
+		rv := reflect.ValueOf(&row).Elem()
+		for column, value := range fields {
+			field, err := s.field(column)
+			if err != nil {
+				return 0, err
+			}
+			if err := field.Set(ctx, rv, value); err != nil {
+				return 0, err
+			}
+		}
+		s.touch(ctx, rv, false)
+		if err := s.checkUnique(ctx, key, rv); err != nil {
+			return 0, err
+		}
+		s.rows[key] = &row
+	}
+	return int64(len(keys)), nil
+}
+
+// UpdateNonZero sets the non-zero fields of obj, or exactly the given columns, on the
+// stored object with the same primary key. It returns an error matching
+// store.ErrNotFound when no object has its primary key.
+func (s *Store[T]) UpdateNonZero(ctx context.Context, obj *T, columns ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src := reflect.ValueOf(obj).Elem()
+	key, zero := s.key(ctx, src)
+	stored, ok := s.rows[key]
+	if zero || !ok || s.isDeleted(reflect.ValueOf(stored).Elem()) {
+		return fmt.Errorf("%w: %w", store.ErrNotFound, gorm.ErrRecordNotFound)
+	}
+
+	fields := make([]*schema.Field, 0, len(columns))
+	for _, column := range columns {
+		field, err := s.field(column)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+	}
+	if len(columns) == 0 {
+		for _, field := range s.schema.Fields {
+			if _, zero := field.ValueOf(ctx, src); field.DBName != "" && !field.PrimaryKey && !zero {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	row := *stored
+	rv := reflect.ValueOf(&row).Elem()
+	for _, field := range fields {
+		if err := field.Set(ctx, rv, s.value(field, src)); err != nil {
+			return err
+		}
+	}
+	s.touch(ctx, rv, false)
+	if err := s.checkUnique(ctx, key, rv); err != nil {
+		return err
+	}
+	s.rows[key] = &row
+	*obj = row
+	return nil
+}
+
+// UpdateMany sets the given columns of every object matching opts, like UpdateWhere.
+func (s *Store[T]) UpdateMany(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error) {
+	return s.UpdateWhere(ctx, opts, fields)
+}
+
+// UpdateExpr is not supported by the fake store, which cannot evaluate SQL
+// expressions, and returns ErrUnsupported. Use Increment for counters.
+func (s *Store[T]) UpdateExpr(_ context.Context, _ *where.Options, column string, expr string, _ ...any) (int64, error) {
+	return 0, fmt.Errorf("%w: expression %q of column %s", ErrUnsupported, expr, column)
+}
+
+// Increment adds delta to the numeric column of every object matching opts and
+// returns the number of objects updated.
+func (s *Store[T]) Increment(ctx context.Context, opts *where.Options, column string, delta int64) (int64, error) {
+	field, err := s.field(column)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.match(conditions(opts))
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		row := *s.rows[key]
+		rv := reflect.ValueOf(&row).Elem()
+		value, ok := toFloat(normalize(s.value(field, rv)))
+		if !ok {
+			return 0, fmt.Errorf("column %s of model %s is not numeric", column, s.schema.Name)
+		}
+		if err := field.Set(ctx, rv, value+float64(delta)); err != nil {
+			return 0, err
+		}
+		s.touch(ctx, rv, false)
+		s.rows[key] = &row
+	}
+	return int64(len(keys)), nil
+}
+
+// Delete removes the objects matching opts, or marks them as deleted when T has a
+// gorm.DeletedAt field.
+func (s *Store[T]) Delete(ctx context.Context, opts *where.Options) error {
+	if s.deleted == nil || (opts != nil && opts.Unscoped) {
+		return s.Purge(ctx, opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.match(conditions(opts))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		row := *s.rows[key]
+		if err := s.deleted.Set(ctx, reflect.ValueOf(&row).Elem(), time.Now()); err != nil {
+			return err
+		}
+		s.rows[key] = &row
+	}
+	return nil
+}
+
+// DeleteByKey removes the object identified by key, which maps every primary key
+// column to its value.
+func (s *Store[T]) DeleteByKey(ctx context.Context, key map[string]any) error {
+	opts, err := s.keyWhere(key)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, opts)
+}
+
+// DeleteInChunks removes the objects matching opts like Delete, calls progress once
+// with the number of objects deleted and returns it.
+func (s *Store[T]) DeleteInChunks(ctx context.Context, opts *where.Options, _ int, progress func(deleted int64)) (int64, error) {
+	deleted, err := s.Count(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Delete(ctx, opts); err != nil {
+		return 0, err
+	}
+	if progress != nil && deleted > 0 {
+		progress(deleted)
+	}
+	return deleted, nil
+}
+
+// Purge permanently removes the objects matching opts, including soft-deleted ones.
+func (s *Store[T]) Purge(_ context.Context, opts *where.Options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.match(conditions(opts).U(true))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(s.rows, key)
+	}
+	return nil
+}
+
+// Restore brings back the soft-deleted objects matching opts and returns the number
+// of objects restored.
+func (s *Store[T]) Restore(ctx context.Context, opts *where.Options) (int64, error) {
+	if s.deleted == nil {
+		return 0, fmt.Errorf("model %s has no soft delete field", s.schema.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.match(conditions(opts).U(true))
+	if err != nil {
+		return 0, err
+	}
+	var restored int64
+	for _, key := range keys {
+		row := *s.rows[key]
+		rv := reflect.ValueOf(&row).Elem()
+		if s.isDeleted(rv) {
+			if err := s.deleted.Set(ctx, rv, gorm.DeletedAt{}); err != nil {
+				return restored, err
+			}
+			s.rows[key] = &row
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+// Get returns a copy of the first object matching opts.
+// It returns an error matching store.ErrNotFound when no object matches.
+func (s *Store[T]) Get(ctx context.Context, opts *where.Options) (*T, error) {
+	page := conditions(opts)
+	if opts != nil {
+		page.Offset, page.Order = opts.Offset, opts.Ordering()
+	}
+	page.Limit = 1
+
+	ret, err := s.find(page)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("%w: %w", store.ErrNotFound, gorm.ErrRecordNotFound)
+	}
+	return ret[0], s.project(ctx, ret, opts)
+}
+
+// GetOrNil returns a copy of the first object matching opts, or nil when no object matches.
+func (s *Store[T]) GetOrNil(ctx context.Context, opts *where.Options) (*T, error) {
+	ret, err := s.Get(ctx, opts)
+	if store.IsNotFound(err) {
+		return nil, nil
+	}
+	return ret, err
+}
+
+// GetMany returns copies of the objects whose primary key is in ids, in the order of
+// ids. Ids matching no object are skipped.
+func (s *Store[T]) GetMany(ctx context.Context, ids []any) ([]*T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		stored, ok := s.rows[fmt.Sprint(normalize(id))]
+		if !ok || s.isDeleted(reflect.ValueOf(stored).Elem()) {
+			continue
+		}
+		row := *stored
+		ret = append(ret, &row)
+	}
+	return ret, nil
+}
+
+// GetByKey returns a copy of the object identified by key, which maps every primary
+// key column to its value.
+func (s *Store[T]) GetByKey(ctx context.Context, key map[string]any) (*T, error) {
+	opts, err := s.keyWhere(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, opts)
+}
+
+// List returns copies of the objects matching opts, together with the number of
+// objects matching regardless of pagination, or -1 when opts skips counting. Objects are sorted by primary key in
+// descending order when no order is specified.
+func (s *Store[T]) List(ctx context.Context, opts *where.Options) (int64, []*T, error) {
+	count := int64(-1)
+	if opts == nil || !opts.SkipCount {
+		var err error
+		if count, err = s.Count(ctx, opts); err != nil {
+			return 0, nil, err
+		}
+	}
+	page := conditions(opts)
+	if opts != nil {
+		page.Offset, page.Limit, page.Order = opts.Offset, opts.Limit, opts.Ordering()
+	}
+	if page.Order == "" {
+		page.Order = s.schema.PrioritizedPrimaryField.DBName + " desc"
+	}
+	ret, err := s.find(page)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, ret, s.project(ctx, ret, opts)
+}
+
+// Count returns the number of objects matching opts, regardless of pagination.
+func (s *Store[T]) Count(_ context.Context, opts *where.Options) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, err := s.match(conditions(opts))
+	return int64(len(keys)), err
+}
+
+// Exists reports whether at least one object matches opts.
+func (s *Store[T]) Exists(ctx context.Context, opts *where.Options) (bool, error) {
+	count, err := s.Count(ctx, opts)
+	return count > 0, err
+}
+
+// ListByCursor retrieves up to limit objects matching opts, ordered by primary key
+// descending and starting right after the object encoded in cursor, and returns the
+// cursor of the next page, empty on the last page. Offset, limit and order carried by
+// opts are ignored.
+func (s *Store[T]) ListByCursor(_ context.Context, opts *where.Options, cursor string, limit int) ([]*T, string, error) {
+	if limit <= 0 {
+		limit = defaultBatchSize
+	}
+
+	pk := s.schema.PrioritizedPrimaryField
+	page := conditions(opts)
+	page.Order, page.Limit = pk.DBName+" desc", limit+1
+	if cursor != "" {
+		key, err := decodeCursor(cursor, pk)
+		if err != nil {
+			return nil, "", err
+		}
+		page.Clauses = append(slices.Clip(page.Clauses), clause.Lt{Column: clause.Column{Name: pk.DBName}, Value: key})
+	}
+
+	ret, err := s.find(page)
+	if err != nil || len(ret) <= limit {
+		return ret, "", err
+	}
+	ret = ret[:limit]
+	next, err := encodeCursor(pk, ret[limit-1])
+	return ret, next, err
+}
+
+// GetOrCreate retrieves the object matching opts, creating obj when no object matches.
+// The returned bool reports whether obj was created.
+func (s *Store[T]) GetOrCreate(ctx context.Context, opts *where.Options, obj *T) (*T, bool, error) {
+	ret, err := s.Get(ctx, opts)
+	if err == nil || !store.IsNotFound(err) {
+		return ret, false, err
+	}
+
+	if err := s.Create(ctx, obj); err != nil {
+		if store.IsDuplicateKey(err) {
+			ret, err := s.Get(ctx, opts)
+			return ret, false, err
+		}
+		return nil, false, err
+	}
+	return obj, true, nil
+}
+
+// Upsert inserts obj or, when an object with the same conflictColumns already exists,
+// updates its updateColumns instead. When updateColumns is empty all columns except the
+// primary key and the creation time are updated. The primary key is used when
+// conflictColumns is empty.
+func (s *Store[T]) Upsert(ctx context.Context, obj *T, conflictColumns []string, updateColumns []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pk := s.schema.PrioritizedPrimaryField
+	if len(conflictColumns) == 0 {
+		conflictColumns = []string{pk.DBName}
+	}
+	conflicts := make([]*schema.Field, 0, len(conflictColumns))
+	for _, column := range conflictColumns {
+		field, err := s.field(column)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, field)
+	}
+
+	var updates []*schema.Field
+	for _, column := range updateColumns {
+		field, err := s.field(column)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, field)
+	}
+	if len(updates) == 0 {
+		for _, field := range s.schema.Fields {
+			if field.DBName != "" && !field.PrimaryKey && field.AutoCreateTime == 0 {
+				updates = append(updates, field)
+			}
+		}
+	}
+
+	rv := reflect.ValueOf(obj).Elem()
+	for _, key := range slices.Sorted(maps.Keys(s.rows)) {
+		existing := reflect.ValueOf(s.rows[key]).Elem()
+		if !s.same(conflicts, rv, existing) {
+			continue
+		}
+
+		row := *s.rows[key]
+		target := reflect.ValueOf(&row).Elem()
+		for _, field := range updates {
+			if err := field.Set(ctx, target, s.value(field, rv)); err != nil {
+				return err
+			}
+		}
+		s.touch(ctx, target, false)
+		if err := s.checkUnique(ctx, key, target); err != nil {
+			return err
+		}
+		s.rows[key] = &row
+		*obj = row
+		return nil
+	}
+	return s.insert(ctx, obj)
+}
+
+// UpsertBatch upserts every object of objs like Upsert and returns the number of
+// objects upserted. It stops at the first object that cannot be upserted.
+func (s *Store[T]) UpsertBatch(ctx context.Context, objs []*T, conflictColumns []string, updateColumns []string) (int64, error) {
+	for i, obj := range objs {
+		if err := s.Upsert(ctx, obj, conflictColumns, updateColumns); err != nil {
+			return int64(i), err
+		}
+	}
+	return int64(len(objs)), nil
+}
+
+// Each calls fn for every batch of batchSize objects matching opts, ordered by
+// primary key. Iteration stops at the first error returned by fn or when ctx is
+// canceled. Order carried by opts is ignored.
+func (s *Store[T]) Each(ctx context.Context, opts *where.Options, batchSize int, fn func([]*T) error) error {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	page := conditions(opts)
+	if opts != nil {
+		page.Offset, page.Limit = opts.Offset, opts.Limit
+	}
+	rows, err := s.find(page)
+	if err != nil {
+		return err
+	}
+	for batch := range slices.Chunk(rows, batchSize) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pluck scans a single column of the objects matching opts into dest, which must be
+// a pointer to a slice. Values are deduplicated when opts is distinct.
+func (s *Store[T]) Pluck(_ context.Context, column string, dest any, opts *where.Options) error {
+	field, err := s.field(column)
+	if err != nil {
+		return err
+	}
+	out := reflect.ValueOf(dest)
+	if out.Kind() != reflect.Pointer || out.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("pluck destination must be a pointer to a slice, got %T", dest)
+	}
+	elem := out.Elem().Type().Elem()
+
+	rows, err := s.find(opts)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	values := reflect.MakeSlice(out.Elem().Type(), 0, len(rows))
+	for _, row := range rows {
+		value := normalize(s.value(field, reflect.ValueOf(row).Elem()))
+		if opts != nil && opts.Distinct {
+			if seen[fmt.Sprint(value)] {
+				continue
+			}
+			seen[fmt.Sprint(value)] = true
+		}
+
+		v := reflect.New(elem).Elem()
+		if value != nil {
+			rv := reflect.ValueOf(value)
+			if !rv.Type().ConvertibleTo(elem) {
+				return fmt.Errorf("cannot scan column %s of type %s into %s", column, rv.Type(), elem)
+			}
+			v = rv.Convert(elem)
+		}
+		values = reflect.Append(values, v)
+	}
+	out.Elem().Set(values)
+	return nil
+}
+
+// Aggregate applies agg to column over the objects matching opts. NULL values are
+// ignored and 0 is returned when no value matches.
+func (s *Store[T]) Aggregate(_ context.Context, opts *where.Options, agg store.Agg, column string) (float64, error) {
+	switch agg {
+	case store.AggSum, store.AggAvg, store.AggMin, store.AggMax:
+	default:
+		return 0, fmt.Errorf("unsupported aggregate function %q", agg)
+	}
+	field, err := s.field(column)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.find(conditions(opts))
+	if err != nil {
+		return 0, err
+	}
+
+	var values []float64
+	for _, row := range rows {
+		if value, ok := toFloat(normalize(s.value(field, reflect.ValueOf(row).Elem()))); ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	switch agg {
+	case store.AggAvg:
+		return sum / float64(len(values)), nil
+	case store.AggMin:
+		return slices.Min(values), nil
+	case store.AggMax:
+		return slices.Max(values), nil
+	}
+	return sum, nil
+}
+
+// Tx runs fn and restores the objects as they were before the call when fn returns
+// an error or panics. Transactions are not isolated: writes made concurrently outside
+// of fn are visible to fn and are rolled back along with it.
+func (s *Store[T]) Tx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	s.mu.RLock()
+	rows, nextID := maps.Clone(s.rows), s.nextID
+	s.mu.RUnlock()
+
+	committed := false
+	defer func() {
+		if !committed {
+			s.mu.Lock()
+			s.rows, s.nextID = rows, nextID
+			s.mu.Unlock()
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// RawQuery is not supported by the fake store and returns ErrUnsupported.
+func (s *Store[T]) RawQuery(_ context.Context, _ any, sql string, _ ...any) error {
+	return fmt.Errorf("%w: raw query %q", ErrUnsupported, sql)
+}
+
+// Exec is not supported by the fake store and returns ErrUnsupported.
+func (s *Store[T]) Exec(_ context.Context, sql string, _ ...any) (int64, error) {
+	return 0, fmt.Errorf("%w: raw statement %q", ErrUnsupported, sql)
+}
+
+// keyWhere returns the options matching the object identified by key, after checking
+// that key holds a non-zero value for every primary key column and nothing else.
+func (s *Store[T]) keyWhere(key map[string]any) (*where.Options, error) {
+	for _, field := range s.schema.PrimaryFields {
+		if value, ok := key[field.DBName]; !ok || value == nil || reflect.ValueOf(value).IsZero() {
+			return nil, fmt.Errorf("%w: missing %s of model %s", store.ErrIncompleteKey, field.DBName, s.schema.Name)
+		}
+	}
+	for column := range key {
+		if !slices.ContainsFunc(s.schema.PrimaryFields, func(f *schema.Field) bool { return f.DBName == column }) {
+			return nil, fmt.Errorf("column %s is not part of the primary key of model %s", column, s.schema.Name)
+		}
+	}
+	return where.Key(key), nil
+}
+
+// find returns copies of the objects matching opts.
+func (s *Store[T]) find(opts *where.Options) ([]*T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, err := s.match(opts)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*T, 0, len(keys))
+	for _, key := range keys {
+		row := *s.rows[key]
+		ret = append(ret, &row)
+	}
+	return ret, nil
+}
+
+// project zeroes the fields of objs that are not among the columns selected by opts.
+func (s *Store[T]) project(ctx context.Context, objs []*T, opts *where.Options) error {
+	if opts == nil || len(opts.Columns) == 0 {
+		return nil
+	}
+	selected := make(map[*schema.Field]bool, len(opts.Columns))
+	for _, column := range opts.Columns {
+		field, err := s.field(column)
+		if err != nil {
+			return err
+		}
+		selected[field] = true
+	}
+	for _, obj := range objs {
+		row := reflect.ValueOf(obj).Elem()
+		for _, field := range s.schema.Fields {
+			if field.DBName != "" && !selected[field] {
+				field.ReflectValueOf(ctx, row).SetZero()
+			}
+		}
+	}
+	return nil
+}
+
+// same reports whether a and b hold equal values for all fields.
+func (s *Store[T]) same(fields []*schema.Field, a, b reflect.Value) bool {
+	for _, field := range fields {
+		if c, ok := compare(s.value(field, a), s.value(field, b)); !ok || c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// insert stores a copy of obj. The caller must hold the lock.
+func (s *Store[T]) insert(ctx context.Context, obj *T) error {
+	rv := reflect.ValueOf(obj).Elem()
+	pk := s.schema.PrioritizedPrimaryField
+	if _, zero := pk.ValueOf(ctx, rv); zero {
+		s.nextID++
+		if err := pk.Set(ctx, rv, s.nextID); err != nil {
+			return fmt.Errorf("model %s has a zero primary key that cannot be generated: %w", s.schema.Name, err)
+		}
+	} else if id, ok := toFloat(normalize(must(pk.ValueOf(ctx, rv)))); ok && int64(id) > s.nextID {
+		s.nextID = int64(id)
+	}
+
+	key, _ := s.key(ctx, rv)
+	if _, ok := s.rows[key]; ok {
+		return fmt.Errorf("%w: %s %s already exists", store.ErrDuplicateKey, s.schema.Name, key)
+	}
+
+	row := *obj
+	s.touch(ctx, reflect.ValueOf(&row).Elem(), true)
+	if err := s.checkUnique(ctx, key, reflect.ValueOf(&row).Elem()); err != nil {
+		return err
+	}
+	s.rows[key] = &row
+	*obj = row
+	return nil
+}
+
+// touch sets the automatic timestamps of row.
+func (s *Store[T]) touch(ctx context.Context, row reflect.Value, created bool) {
+	now := time.Now()
+	for _, field := range s.schema.Fields {
+		if field.AutoUpdateTime > 0 || (created && field.AutoCreateTime > 0) {
+			if _, zero := field.ValueOf(ctx, row); zero || field.AutoUpdateTime > 0 {
+				_ = field.Set(ctx, row, now)
+			}
+		}
+	}
+}
+
+// checkUnique reports a duplicate key error when row violates a unique index.
+func (s *Store[T]) checkUnique(_ context.Context, key string, row reflect.Value) error {
+	for _, fields := range s.uniques {
+		for other, stored := range s.rows {
+			if other != key && s.same(fields, row, reflect.ValueOf(stored).Elem()) {
+				return fmt.Errorf("%w: unique constraint failed on %s", store.ErrDuplicateKey, fields[0].DBName)
+			}
+		}
+	}
+	return nil
+}
+
+// match returns the keys of the objects matching opts, ordered and paginated.
+// The caller must hold the lock.
+func (s *Store[T]) match(opts *where.Options) ([]string, error) {
+	if opts == nil {
+		opts = &where.Options{}
+	}
+	if len(opts.Joins) > 0 || len(opts.Preloads) > 0 || len(opts.Scopes) > 0 {
+		return nil, fmt.Errorf("%w: joins, preloads and scopes", ErrUnsupported)
+	}
+	if err := opts.CheckAllowed(); err != nil {
+		return nil, err
+	}
+
+	var conds []condition
+	for key, value := range opts.Filters {
+		cond, err := s.equal(fmt.Sprint(key), value)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	for _, query := range opts.Queries {
+		m, ok := query.Query.(map[string]any)
+		if !ok || len(query.Args) > 0 {
+			return nil, fmt.Errorf("%w: query %v", ErrUnsupported, query.Query)
+		}
+		for column, value := range m {
+			cond, err := s.equal(column, value)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+	}
+	for _, expr := range opts.Clauses {
+		cond, err := s.compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	for _, search := range opts.Searches {
+		if search.Keyword == "" || len(search.Columns) == 0 {
+			continue
+		}
+		cond, err := s.search(search)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	var keys []string
+	for _, key := range slices.Sorted(maps.Keys(s.rows)) {
+		row := reflect.ValueOf(s.rows[key]).Elem()
+		if !opts.Unscoped && s.isDeleted(row) {
+			continue
+		}
+		if !slices.ContainsFunc(conds, func(cond condition) bool { return !cond(row) }) {
+			keys = append(keys, key)
+		}
+	}
+
+	if err := s.sort(keys, opts.Order); err != nil {
+		return nil, err
+	}
+	if opts.Offset > 0 {
+		keys = keys[min(opts.Offset, len(keys)):]
+	}
+	if opts.Limit > 0 {
+		keys = keys[:min(opts.Limit, len(keys))]
+	}
+	return keys, nil
+}
+
+// sort orders keys according to an SQL ORDER BY list, such as "name desc, id".
+func (s *Store[T]) sort(keys []string, order string) error {
+	type orderBy struct {
+		field *schema.Field
+		desc  bool
+	}
+
+	var orders []orderBy
+	for _, part := range strings.Split(order, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 || (len(fields) == 2 && !strings.EqualFold(fields[1], "asc") && !strings.EqualFold(fields[1], "desc")) {
+			return fmt.Errorf("%w: order %q", ErrUnsupported, part)
+		}
+		field, err := s.field(fields[0])
+		if err != nil {
+			return err
+		}
+		orders = append(orders, orderBy{field: field, desc: len(fields) == 2 && strings.EqualFold(fields[1], "desc")})
+	}
+	// Like databases do in practice, fall back to the primary key order.
+	orders = append(orders, orderBy{field: s.schema.PrioritizedPrimaryField})
+
+	slices.SortStableFunc(keys, func(a, b string) int {
+		ra, rb := reflect.ValueOf(s.rows[a]).Elem(), reflect.ValueOf(s.rows[b]).Elem()
+		for _, o := range orders {
+			c, _ := compare(s.value(o.field, ra), s.value(o.field, rb))
+			if o.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// key returns the map key of row and whether its primary key is zero.
+func (s *Store[T]) key(ctx context.Context, row reflect.Value) (string, bool) {
+	value, zero := s.schema.PrioritizedPrimaryField.ValueOf(ctx, row)
+	return fmt.Sprint(normalize(value)), zero
+}
+
+// value returns the value of field in row.
+func (s *Store[T]) value(field *schema.Field, row reflect.Value) any {
+	value, _ := field.ValueOf(context.Background(), row)
+	return value
+}
+
+// isDeleted reports whether row is soft-deleted.
+func (s *Store[T]) isDeleted(row reflect.Value) bool {
+	return s.deleted != nil && normalize(s.value(s.deleted, row)) != nil
+}
+
+// conditions returns a copy of opts without pagination and ordering.
+func conditions(opts *where.Options) *where.Options {
+	var whr where.Options
+	if opts != nil {
+		whr = *opts
+	}
+	whr.Offset, whr.Limit, whr.Order, whr.Sorts = 0, -1, "", nil
+	return &whr
+}
+
+// must drops the second result of a function.
+func must[V any, B any](value V, _ B) V {
+	return value
+}
+
+// encodeCursor builds the opaque cursor pointing right after obj, like the store does.
+func encodeCursor[T any](pk *schema.Field, obj *T) (string, error) {
+	value, _ := pk.ValueOf(context.Background(), reflect.ValueOf(obj).Elem())
+	return where.Cursor(cursorSorts(pk), value)
+}
+
+// decodeCursor extracts the primary key value encoded in cursor, typed after the primary key field.
+func decodeCursor(cursor string, pk *schema.Field) (any, error) {
+	values, err := where.DecodeCursor(cursor, cursorSorts(pk))
+	if err != nil {
+		return nil, err
+	}
+	key := reflect.New(pk.FieldType)
+	if err := json.Unmarshal(values[0], key.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %w", store.ErrInvalidCursor, err)
+	}
+	return key.Elem().Interface(), nil
+}
+
+// cursorSorts returns the sort of the objects listed by ListByCursor, which its
+// cursors are tied to.
+func cursorSorts(pk *schema.Field) []where.SortColumn {
+	return []where.SortColumn{{Column: pk.DBName, Direction: where.Desc}}
+}
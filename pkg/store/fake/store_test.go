@@ -0,0 +1,249 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testUser struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:255"`
+	Email     string `gorm:"size:255;uniqueIndex"`
+	Age       int
+	Meta      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore[testUser]()
+	ctx := context.Background()
+
+	for i, name := range []string{"alice", "bob", "carol"} {
+		user := &testUser{Name: name, Email: name + "@example.com", Age: 20 + i*10, Meta: `{"tags":["` + name + `"]}`}
+		if err := s.Create(ctx, user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if user.ID != uint(i+1) || user.CreatedAt.IsZero() {
+			t.Errorf("Expected ID %d and creation time to be set, got %+v", i+1, user)
+		}
+	}
+
+	if err := s.Create(ctx, &testUser{Name: "dup", Email: "bob@example.com"}); !store.IsDuplicateKey(err) {
+		t.Errorf("Expected duplicate key error, got %v", err)
+	}
+
+	user, err := s.Get(ctx, where.F("name", "bob"))
+	if err != nil || user.ID != 2 {
+		t.Fatalf("Expected bob, got %+v, %v", user, err)
+	}
+	user.Name = "changed"
+	if again, _ := s.Get(ctx, where.F("id", 2)); again.Name != "bob" {
+		t.Errorf("Expected stored object to be unaffected by changes to a returned copy")
+	}
+
+	count, users, err := s.List(ctx, where.C(clause.Gte{Column: "age", Value: 30}).L(1))
+	if err != nil || count != 2 || len(users) != 1 || users[0].Name != "carol" {
+		t.Fatalf("Expected carol out of 2 objects, got %d, %v, %v", count, users, err)
+	}
+
+	if user, _ := s.Get(ctx, where.Select("id", "name").F("name", "bob")); user.Name != "bob" || user.Email != "" {
+		t.Errorf("Expected only the selected columns to be fetched, got %+v", user)
+	}
+
+	_, users, _ = s.List(ctx, where.Or("age asc").L(10))
+	if len(users) != 3 || users[0].Name != "alice" {
+		t.Errorf("Expected objects ordered by age, got %v", users)
+	}
+	_, users, _ = s.List(ctx, where.Sort("age", where.Desc).ThenBy("id", where.Asc))
+	if len(users) != 3 || users[0].Name != "carol" {
+		t.Errorf("Expected objects sorted by age, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.C(clause.Like{Column: "email", Value: "%o%@%"}).L(10))
+	if len(users) != 2 {
+		t.Errorf("Expected 2 objects matching like pattern, got %d", len(users))
+	}
+
+	_, users, _ = s.List(ctx, where.Search("CAR", "name", "email"))
+	if len(users) != 1 || users[0].Name != "carol" {
+		t.Errorf("Expected carol to match the search, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.ILike("name", where.Prefix("BO")).NotLike("email", "%.org"))
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("Expected bob to match ILike and NotLike, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.Between("age", 25, 40).NotBetween("id", 3, 5))
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("Expected bob to match Between and NotBetween, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.Gt("age", 20).Lt("age", 40).Neq("name", "carol"))
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("Expected bob to match Gt, Lt and Neq, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.JSONEq("meta", "$.tags[0]", "bob").JSONContains("meta", map[string]any{"tags": []string{"bob"}}))
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("Expected bob to match JSONEq and JSONContains, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.Not(where.F("name", "alice")).Not(where.C(clause.Gte{Column: "age", Value: 40})))
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Errorf("Expected bob to match Not, got %v", users)
+	}
+
+	_, users, _ = s.List(ctx, where.In("name", []string{"alice", "carol"}).NotIn("age", []int{20}))
+	if len(users) != 1 || users[0].Name != "carol" {
+		t.Errorf("Expected carol to match In and NotIn, got %v", users)
+	}
+
+	affected, err := s.UpdateWhere(ctx, where.F("id", []uint{1, 3}), map[string]any{"age": 99})
+	if err != nil || affected != 2 {
+		t.Fatalf("Expected 2 objects updated, got %d, %v", affected, err)
+	}
+
+	if err := s.Delete(ctx, where.F("age", 99)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if n, _ := s.Count(ctx, nil); n != 1 {
+		t.Errorf("Expected 1 object after soft delete, got %d", n)
+	}
+	if n, _ := s.Count(ctx, where.U(true)); n != 3 {
+		t.Errorf("Expected 3 objects including soft-deleted ones, got %d", n)
+	}
+	if n, _ := s.Count(ctx, where.U(true).NotNull("deleted_at")); n != 2 {
+		t.Errorf("Expected 2 soft-deleted objects, got %d", n)
+	}
+	if n, _ := s.Count(ctx, where.U(true).IsNull("deleted_at")); n != 1 {
+		t.Errorf("Expected 1 object not deleted, got %d", n)
+	}
+	if n, _ := s.Count(ctx, where.U(true).F("deleted_at", nil)); n != 1 {
+		t.Errorf("Expected nil filters to match NULL, got %d", n)
+	}
+
+	if restored, err := s.Restore(ctx, where.F("id", 1)); err != nil || restored != 1 {
+		t.Errorf("Expected 1 object restored, got %d, %v", restored, err)
+	}
+
+	if err := s.Purge(ctx, where.F("id", 3)); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if _, err := s.Get(ctx, where.U(true).F("id", 3)); !store.IsNotFound(err) {
+		t.Errorf("Expected not found error after purge, got %v", err)
+	}
+
+	if _, err := s.Get(ctx, where.NewWhere().Q("age > ?", 10)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected unsupported error for raw SQL conditions, got %v", err)
+	}
+}
+
+func TestStoreUpsertAndTx(t *testing.T) {
+	s := NewStore[testUser]()
+	ctx := context.Background()
+
+	if err := s.Upsert(ctx, &testUser{Name: "alice", Email: "alice@example.com", Age: 20}, []string{"email"}, nil); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := s.Upsert(ctx, &testUser{Name: "ignored", Email: "alice@example.com", Age: 21}, []string{"email"}, []string{"age"}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if got, _ := s.Get(ctx, where.F("email", "alice@example.com")); got.ID != 1 || got.Age != 21 || got.Name != "alice" {
+		t.Errorf("Expected only the age of alice to be updated, got %+v", got)
+	}
+
+	errRollback := errors.New("rollback")
+	err := s.Tx(ctx, func(txCtx context.Context) error {
+		if err := s.Create(txCtx, &testUser{Name: "bob", Email: "bob@example.com"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expected rollback error, got %v", err)
+	}
+	if exists, _ := s.Exists(ctx, where.F("name", "bob")); exists {
+		t.Errorf("Expected objects created in a failed transaction to be rolled back")
+	}
+
+	for _, name := range []string{"bob", "carol", "dave"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@example.com", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	var names []string
+	for cursor := ""; ; {
+		page, next, err := s.ListByCursor(ctx, nil, cursor, 3)
+		if err != nil {
+			t.Fatalf("ListByCursor failed: %v", err)
+		}
+		for _, user := range page {
+			names = append(names, user.Name)
+		}
+		if cursor = next; cursor == "" {
+			break
+		}
+	}
+	if strings.Join(names, ",") != "dave,carol,bob,alice" {
+		t.Errorf("Expected every object once by descending primary key, got %v", names)
+	}
+
+	if sum, err := s.Aggregate(ctx, where.F("age", 30), store.AggSum, "age"); err != nil || sum != 90 {
+		t.Errorf("Expected a sum of 90, got %v, %v", sum, err)
+	}
+	var ages []int
+	if err := s.Pluck(ctx, "age", &ages, where.D(true).Or("age")); err != nil || len(ages) != 2 || ages[0] != 21 {
+		t.Errorf("Expected distinct ages [21 30], got %v, %v", ages, err)
+	}
+}
+
+func TestStoreUpdatesAndKeys(t *testing.T) {
+	s := NewStore[testUser]()
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@example.com", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	if err := s.UpdateNonZero(ctx, &testUser{ID: 1, Name: "alicia"}); err != nil {
+		t.Fatalf("UpdateNonZero failed: %v", err)
+	}
+	if got, _ := s.GetByKey(ctx, map[string]any{"id": 1}); got.Name != "alicia" || got.Age != 30 {
+		t.Errorf("Expected only the name of alice to be updated, got %+v", got)
+	}
+	if _, err := s.GetByKey(ctx, map[string]any{"id": 0}); !errors.Is(err, store.ErrIncompleteKey) {
+		t.Errorf("Expected ErrIncompleteKey, got %v", err)
+	}
+
+	if n, err := s.Increment(ctx, where.F("age", 30), "age", -5); err != nil || n != 3 {
+		t.Fatalf("Expected 3 objects incremented, got %d, %v", n, err)
+	}
+	users, err := s.GetMany(ctx, []any{3, 9, 1})
+	if err != nil || len(users) != 2 || users[0].Name != "carol" || users[1].Age != 25 {
+		t.Errorf("Expected carol and alicia aged 25, got %+v, %v", users, err)
+	}
+
+	if err := s.DeleteByKey(ctx, map[string]any{"id": 2}); err != nil {
+		t.Fatalf("DeleteByKey failed: %v", err)
+	}
+	if got, err := s.GetOrNil(ctx, where.F("id", 2)); got != nil || err != nil {
+		t.Errorf("Expected no object after DeleteByKey, got %+v, %v", got, err)
+	}
+	if _, err := s.UpdateExpr(ctx, nil, "age", "age * 2"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+// Package gen generates typed store layers from model structs. For every model it
+// writes a XxxStore wrapping store.IStore with lookup methods named after the
+// indexed fields of the model, such as GetByEmail or ListByTenantID, and the unit
+// tests of these methods running on the in-memory fake store.
+package gen // import "github.com/miladystack/miladystack/pkg/store/gen"
@@ -0,0 +1,386 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gorm.io/gorm/schema"
+)
+
+// Config defines the models a typed store layer is generated for.
+type Config struct {
+	// Dir is the directory of the Go package declaring the models. The typed stores
+	// are generated into the same package.
+	Dir string
+	// Models lists the names of the model structs. It defaults to every struct of the
+	// package with a gorm tag or an embedded gorm.Model.
+	Models []string
+	// SkipTests disables the generation of the unit tests of the typed stores.
+	SkipTests bool
+}
+
+// File is a generated Go source file.
+type File struct {
+	// Name is the name of the file in the package directory, such as user_store.go.
+	Name string
+	// Content is the formatted source of the file.
+	Content []byte
+}
+
+// model describes a model struct a typed store is generated for.
+type model struct {
+	Name    string
+	Package string
+	Imports []string
+	Lookups []lookup
+	// HasKey reports whether the model has a primary key, which the fake store
+	// running the generated tests requires.
+	HasKey bool
+}
+
+// lookup describes a generated lookup method on a field of a model.
+type lookup struct {
+	// Unique reports whether the field identifies a single object, which is retrieved
+	// by a GetBy method rather than listed by a ListBy method.
+	Unique bool
+	Field  string
+	Column string
+	Param  string
+	Type   string
+	// Sample is the literal of a sample value used by the generated tests, empty when
+	// the type of the field has none.
+	Sample string
+}
+
+// Method returns the name of the generated lookup method.
+func (l lookup) Method() string {
+	if l.Unique {
+		return "GetBy" + l.Field
+	}
+	return "ListBy" + l.Field
+}
+
+// Generate returns the files of the typed stores of the models configured by cfg:
+// a <model>_store.go file per model and, unless disabled, a <model>_store_test.go file.
+// Files generated before are ignored when reading the models, so that generating
+// again overwrites them.
+func Generate(cfg Config) ([]File, error) {
+	models, err := parseModels(cfg.Dir, cfg.Models)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, m := range models {
+		base := schema.NamingStrategy{}.ColumnName("", m.Name) + "_store"
+		content, err := render(storeTemplate, m)
+		if err != nil {
+			return nil, fmt.Errorf("generate store of %s: %w", m.Name, err)
+		}
+		files = append(files, File{Name: base + ".go", Content: content})
+
+		if cfg.SkipTests || !m.HasKey || !slices.ContainsFunc(m.Lookups, func(l lookup) bool { return l.Sample != "" }) {
+			continue
+		}
+		content, err = render(testTemplate, m)
+		if err != nil {
+			return nil, fmt.Errorf("generate tests of %s: %w", m.Name, err)
+		}
+		files = append(files, File{Name: base + "_test.go", Content: content})
+	}
+	return files, nil
+}
+
+// Write generates the files of the typed stores configured by cfg into cfg.Dir and
+// returns their paths.
+func Write(cfg Config) ([]string, error) {
+	files, err := Generate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path := filepath.Join(cfg.Dir, file.Name)
+		if err := os.WriteFile(path, file.Content, 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// render executes tmpl with m and formats the result.
+func render(tmpl *template.Template, m *model) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, m); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// parseModels reads the models named names, or every model when names is empty, from
+// the Go package in dir.
+func parseModels(dir string, names []string) ([]*model, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	found := make(map[string]*model)
+	var order []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if ast.IsGenerated(file) {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+				m, isModel := parseModel(file, ts.Name.Name, st)
+				if len(names) == 0 && !isModel {
+					continue
+				}
+				found[m.Name] = m
+				order = append(order, m.Name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		names = order
+	}
+	models := make([]*model, 0, len(names))
+	for _, name := range names {
+		m, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("model %s not found in %s", name, dir)
+		}
+		models = append(models, m)
+	}
+	return models, nil
+}
+
+// parseModel describes the struct st named name, declared in file, and reports
+// whether it looks like a gorm model.
+func parseModel(file *ast.File, name string, st *ast.StructType) (*model, bool) {
+	m := &model{Name: name, Package: file.Name.Name}
+	isModel := false
+	imports := make(map[string]bool)
+	indexes := parseIndexes(st)
+
+	for _, field := range st.Fields.List {
+		tag := gormTag(field)
+		if tag != "" {
+			isModel = true
+		}
+		if len(field.Names) == 0 {
+			// Embedded structs, such as gorm.Model, are not looked up.
+			if types.ExprString(field.Type) == "gorm.Model" {
+				isModel = true
+				m.HasKey = true
+			}
+			continue
+		}
+
+		settings := schema.ParseTagSetting(tag, ";")
+		if _, ok := settings["-"]; ok {
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			if _, ok := settings["PRIMARYKEY"]; ok || ident.Name == "ID" {
+				m.HasKey = true
+				continue
+			}
+			unique, ok := lookupKind(settings, ident.Name, indexes)
+			if !ok {
+				continue
+			}
+
+			l := lookup{
+				Unique: unique,
+				Field:  ident.Name,
+				Column: settings["COLUMN"],
+				Param:  paramName(ident.Name),
+				Type:   types.ExprString(field.Type),
+				Sample: sample(field.Type, ident.Name),
+			}
+			if l.Column == "" {
+				l.Column = schema.NamingStrategy{}.ColumnName("", ident.Name)
+			}
+			m.Lookups = append(m.Lookups, l)
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if pkg, ok := sel.X.(*ast.Ident); ok {
+						imports[pkg.Name] = true
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if !imports[name] {
+			continue
+		}
+		if spec.Name != nil {
+			m.Imports = append(m.Imports, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			m.Imports = append(m.Imports, spec.Path.Value)
+		}
+	}
+	return m, isModel
+}
+
+// index describes a named index of a model.
+type index struct {
+	fields int
+	first  string
+}
+
+// parseIndexes returns the named indexes of the struct st by name.
+func parseIndexes(st *ast.StructType) map[string]*index {
+	indexes := make(map[string]*index)
+	for _, field := range st.Fields.List {
+		settings := schema.ParseTagSetting(gormTag(field), ";")
+		for _, key := range []string{"INDEX", "UNIQUEINDEX"} {
+			name, ok := indexName(settings, key)
+			if !ok || name == "" {
+				continue
+			}
+			for _, ident := range field.Names {
+				idx, ok := indexes[name]
+				if !ok {
+					idx = &index{first: ident.Name}
+					indexes[name] = idx
+				}
+				idx.fields++
+			}
+		}
+	}
+	return indexes
+}
+
+// indexName returns the name of the index declared by the tag key in settings, empty
+// for unnamed indexes, and whether the tag is present.
+func indexName(settings map[string]string, key string) (string, bool) {
+	value, ok := settings[key]
+	if !ok {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.Split(value, ",")[0])
+	if strings.EqualFold(name, key) {
+		name = ""
+	}
+	return name, true
+}
+
+// lookupKind reports whether the field with the given tag settings is looked up, and
+// whether it identifies a single object. Fields of composite indexes are looked up
+// when they come first in the index, as the other ones cannot use it alone.
+func lookupKind(settings map[string]string, field string, indexes map[string]*index) (unique bool, ok bool) {
+	if _, ok := settings["UNIQUE"]; ok {
+		return true, true
+	}
+	for _, key := range []string{"UNIQUEINDEX", "INDEX"} {
+		name, ok := indexName(settings, key)
+		if !ok {
+			continue
+		}
+		if idx := indexes[name]; name != "" && idx.fields > 1 {
+			if idx.first == field {
+				return false, true
+			}
+			continue
+		}
+		return key == "UNIQUEINDEX", true
+	}
+	return false, false
+}
+
+// gormTag returns the gorm tag of field.
+func gormTag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag).Get("gorm")
+}
+
+// paramName returns the name of the parameter holding the value of field, such as
+// tenantID for TenantID.
+func paramName(field string) string {
+	runes := []rune(field)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	// Keep the last upper case letter of an initialism starting a word, as in URLPath.
+	if n > 1 && n < len(runes) {
+		n--
+	}
+	name := strings.ToLower(string(runes[:n])) + string(runes[n:])
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	return name
+}
+
+// sample returns the literal of a sample value of the type expr for the generated
+// tests, empty for the types having none.
+func sample(expr ast.Expr, field string) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	switch ident.Name {
+	case "string":
+		return strconv.Quote(strings.ToLower(field) + "-1")
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "1"
+	case "bool":
+		return "true"
+	default:
+		return ""
+	}
+}
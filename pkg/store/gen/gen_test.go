@@ -0,0 +1,96 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testModels = `package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID        uint64    ` + "`gorm:\"primaryKey\"`" + `
+	Email     string    ` + "`gorm:\"uniqueIndex;size:191\"`" + `
+	TenantID  uint64    ` + "`gorm:\"index\"`" + `
+	Type      string    ` + "`gorm:\"index:idx_type_status\"`" + `
+	Status    int       ` + "`gorm:\"index:idx_type_status\"`" + `
+	LastLogin time.Time ` + "`gorm:\"column:last_seen_at;index\"`" + `
+	Name      string
+}
+
+type Tag struct {
+	gorm.Model
+	Slug string ` + "`gorm:\"unique\"`" + `
+}
+
+type request struct {
+	Email string
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model.go"), []byte(testModels), 0o644); err != nil {
+		t.Fatalf("Failed to write models: %v", err)
+	}
+
+	files, err := Generate(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	names := make([]string, 0, len(files))
+	contents := make(map[string]string)
+	for _, file := range files {
+		names = append(names, file.Name)
+		contents[file.Name] = string(file.Content)
+	}
+	if strings.Join(names, ",") != "user_store.go,user_store_test.go,tag_store.go,tag_store_test.go" {
+		t.Fatalf("unexpected generated files %v", names)
+	}
+
+	user := contents["user_store.go"]
+	for _, want := range []string{
+		"func (s *UserStore) GetByEmail(ctx context.Context, email string) (*User, error) {",
+		`return s.Get(ctx, where.F("email", email))`,
+		"func (s *UserStore) ListByTenantID(ctx context.Context, tenantID uint64, opts *where.Options) (int64, []*User, error) {",
+		"func (s *UserStore) ListByType(ctx context.Context, typeValue string, opts *where.Options) (int64, []*User, error) {",
+		`return s.List(ctx, opts.F("last_seen_at", lastLogin))`,
+		`"time"`,
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("expected user_store.go to contain %q, got:\n%s", want, user)
+		}
+	}
+	// Status is not the first field of its composite index and Name is not indexed.
+	for _, unwanted := range []string{"ByStatus", "ByName", "ByID"} {
+		if strings.Contains(user, unwanted) {
+			t.Errorf("unexpected %s method in user_store.go", unwanted)
+		}
+	}
+	if !strings.Contains(contents["user_store_test.go"], `obj, err := s.GetByEmail(ctx, "email-1")`) {
+		t.Errorf("expected a test of GetByEmail, got:\n%s", contents["user_store_test.go"])
+	}
+	if !strings.Contains(contents["tag_store.go"], "func (s *TagStore) GetBySlug(") {
+		t.Errorf("expected GetBySlug, got:\n%s", contents["tag_store.go"])
+	}
+
+	// Generated files are ignored when generating again.
+	if _, err := Write(Config{Dir: dir, Models: []string{"Tag"}, SkipTests: true}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	files, err = Generate(Config{Dir: dir})
+	if err != nil || len(files) != 4 {
+		t.Errorf("expected generated files to be ignored, got %d files, %v", len(files), err)
+	}
+
+	if _, err := Generate(Config{Dir: dir, Models: []string{"Post"}}); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
@@ -0,0 +1,76 @@
+package gen
+
+import "text/template"
+
+// storeTemplate generates the typed store of a model.
+var storeTemplate = template.Must(template.New("store").Parse(`// Code generated by miladystack gen store. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// {{.Name}}Store is the typed store of {{.Name}} objects.
+type {{.Name}}Store struct {
+	store.IStore[{{.Name}}]
+}
+
+// New{{.Name}}Store creates a {{.Name}}Store delegating to s, such as a *store.Store[{{.Name}}]
+// or a chain of decorators wrapping it.
+func New{{.Name}}Store(s store.IStore[{{.Name}}]) *{{.Name}}Store {
+	return &{{.Name}}Store{IStore: s}
+}
+{{range .Lookups}}{{if .Unique}}
+// {{.Method}} retrieves the {{$.Name}} whose {{.Column}} is {{.Param}}.
+func (s *{{$.Name}}Store) {{.Method}}(ctx context.Context, {{.Param}} {{.Type}}) (*{{$.Name}}, error) {
+	return s.Get(ctx, where.F("{{.Column}}", {{.Param}}))
+}
+{{else}}
+// {{.Method}} retrieves the {{$.Name}} objects whose {{.Column}} is {{.Param}}, narrowed
+// down by opts, along with the total number of matching objects.
+func (s *{{$.Name}}Store) {{.Method}}(ctx context.Context, {{.Param}} {{.Type}}, opts *where.Options) (int64, []*{{$.Name}}, error) {
+	if opts == nil {
+		opts = where.NewWhere()
+	}
+	return s.List(ctx, opts.F("{{.Column}}", {{.Param}}))
+}
+{{end}}{{end}}`))
+
+// testTemplate generates the unit tests of the typed store of a model.
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by miladystack gen store. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miladystack/miladystack/pkg/store/fake"
+)
+{{range .Lookups}}{{if .Sample}}
+func Test{{$.Name}}Store{{.Method}}(t *testing.T) {
+	ctx := context.Background()
+	s := New{{$.Name}}Store(fake.NewStore[{{$.Name}}]())
+	if err := s.Create(ctx, &{{$.Name}}{ {{- .Field}}: {{.Sample -}} }); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+{{if .Unique}}
+	obj, err := s.{{.Method}}(ctx, {{.Sample}})
+	if err != nil || obj.{{.Field}} != {{.Sample}} {
+		t.Errorf("{{.Method}} returned %+v, %v", obj, err)
+	}
+{{- else}}
+	count, objs, err := s.{{.Method}}(ctx, {{.Sample}}, nil)
+	if err != nil || count != 1 || len(objs) != 1 || objs[0].{{.Field}} != {{.Sample}} {
+		t.Errorf("{{.Method}} returned %d, %+v, %v", count, objs, err)
+	}
+{{- end}}
+}
+{{end}}{{end}}`))
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// GetMany retrieves the objects whose primary key is in ids with a single query,
+// instead of calling Get in a loop. Objects are returned in the order of ids, ids
+// matching no object are skipped.
+func (s *Store[T]) GetMany(ctx context.Context, ids []any) ([]*T, error) {
+	pk, err := primaryField[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return s.GetManyBy(ctx, pk.DBName, ids)
+}
+
+// GetManyBy retrieves the objects whose column is in values with a single query.
+// Objects are returned in the order of values, values matching no object are skipped.
+// column should be unique, such as a primary key or an email.
+func (s *Store[T]) GetManyBy(ctx context.Context, column string, values []any) (ret []*T, err error) {
+	ctx, op := s.begin(ctx, "GetMany")
+	defer func() { op.end(err) }()
+
+	if len(values) == 0 {
+		return []*T{}, nil
+	}
+
+	var objs []*T
+	opts := where.F(column, values)
+	if err := s.retry(ctx, func() error { return s.reader(ctx, opts).Find(&objs).Error }); err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve objects from database", "conditions", opts)
+		return nil, wrapError(err)
+	}
+
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	field := sch.LookUpField(column)
+	if field == nil {
+		return objs, nil
+	}
+
+	// Index the objects by key, printed so that keys of different integer types match.
+	byKey := make(map[string]*T, len(objs))
+	for _, obj := range objs {
+		key, _ := field.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+		byKey[fmt.Sprint(key)] = obj
+	}
+	ret = make([]*T, 0, len(objs))
+	for _, value := range values {
+		if obj, ok := byKey[fmt.Sprint(value)]; ok {
+			ret = append(ret, obj)
+		}
+	}
+	return ret, nil
+}
+
+// GetMap retrieves the objects of s whose primary key is in ids with a single query,
+// and returns them keyed by primary key. ids matching no object are absent from the map.
+func GetMap[K comparable, T any](ctx context.Context, s *Store[T], ids []K) (map[K]*T, error) {
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	objs, err := s.GetMany(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := primaryField[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	keyType := reflect.TypeFor[K]()
+	ret := make(map[K]*T, len(objs))
+	for _, obj := range objs {
+		key := pk.ReflectValueOf(ctx, reflect.ValueOf(obj).Elem())
+		if !key.CanConvert(keyType) {
+			return nil, fmt.Errorf("primary key %s of type %s cannot be used as %s", pk.Name, key.Type(), keyType)
+		}
+		ret[key.Convert(keyType).Interface().(K)] = obj
+	}
+	return ret, nil
+}
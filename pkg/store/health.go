@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// HealthChecker is implemented by the DBProviders backed by several databases,
+// such as ReplicaProvider and ShardProvider, to check and report on all of them.
+// Other providers are checked through the database returned by DB.
+type HealthChecker interface {
+	// Ping verifies that every database of the provider is reachable.
+	Ping(ctx context.Context) error
+	// Stats returns the connection pool statistics summed over the databases of the provider.
+	Stats() sql.DBStats
+}
+
+// Ping verifies that the database of the store is reachable and answers queries,
+// e.g. for a readiness probe. It does not join the transaction carried by ctx.
+func (s *Store[T]) Ping(ctx context.Context) (err error) {
+	ctx, op := s.begin(ctx, "Ping")
+	defer func() { op.end(err) }()
+
+	if hc, ok := s.storage.(HealthChecker); ok {
+		err = hc.Ping(ctx)
+	} else {
+		err = ping(ctx, s.storage.DB(ctx))
+	}
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to ping database")
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Stats returns the connection pool statistics of the database of the store.
+func (s *Store[T]) Stats() sql.DBStats {
+	if hc, ok := s.storage.(HealthChecker); ok {
+		return hc.Stats()
+	}
+	return stats(s.storage.DB(context.Background()))
+}
+
+// Ping implements HealthChecker, checking the primary and every replica.
+func (p *ReplicaProvider) Ping(ctx context.Context) error {
+	var errs []error
+	if err := ping(ctx, p.primary); err != nil {
+		errs = append(errs, fmt.Errorf("primary: %w", err))
+	}
+	for i, replica := range p.replicas {
+		if err := ping(ctx, replica); err != nil {
+			errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats implements HealthChecker.
+func (p *ReplicaProvider) Stats() sql.DBStats {
+	ret := stats(p.primary)
+	for _, replica := range p.replicas {
+		ret = addStats(ret, stats(replica))
+	}
+	return ret
+}
+
+// Ping implements HealthChecker, checking every shard.
+func (p *ShardProvider) Ping(ctx context.Context) error {
+	var errs []error
+	for i, shard := range p.shards {
+		if err := ping(ctx, shard); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats implements HealthChecker.
+func (p *ShardProvider) Stats() sql.DBStats {
+	var ret sql.DBStats
+	for _, shard := range p.shards {
+		ret = addStats(ret, stats(shard))
+	}
+	return ret
+}
+
+// ping checks the connection to db, then runs a trivial query on it.
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+
+	var one int
+	return db.WithContext(ctx).Raw("SELECT 1").Scan(&one).Error
+}
+
+// stats returns the connection pool statistics of db, or zero statistics when db
+// is not backed by a connection pool.
+func stats(db *gorm.DB) sql.DBStats {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// addStats sums the connection pool statistics a and b.
+func addStats(a, b sql.DBStats) sql.DBStats {
+	return sql.DBStats{
+		MaxOpenConnections: a.MaxOpenConnections + b.MaxOpenConnections,
+		OpenConnections:    a.OpenConnections + b.OpenConnections,
+		InUse:              a.InUse + b.InUse,
+		Idle:               a.Idle + b.Idle,
+		WaitCount:          a.WaitCount + b.WaitCount,
+		WaitDuration:       a.WaitDuration + b.WaitDuration,
+		MaxIdleClosed:      a.MaxIdleClosed + b.MaxIdleClosed,
+		MaxIdleTimeClosed:  a.MaxIdleTimeClosed + b.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  a.MaxLifetimeClosed + b.MaxLifetimeClosed,
+	}
+}
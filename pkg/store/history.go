@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// HistoryRecord is a row of the history table of a store configured with WithHistory,
+// holding a previous version of an object.
+type HistoryRecord struct {
+	ID uint64 `gorm:"primaryKey" json:"id"`
+	// EntityID is the primary key of the object, its parts separated by commas for
+	// composite keys.
+	EntityID string `gorm:"size:191;index" json:"entityID"`
+	// Operation is the change that replaced the version.
+	Operation Operation `gorm:"size:16" json:"operation"`
+	// Actor is the operator carried by the context of the change (see WithOperator).
+	Actor string `gorm:"size:191" json:"actor"`
+	// ChangedAt is the time of the change.
+	ChangedAt time.Time `gorm:"index" json:"changedAt"`
+	// Data is the version of the object before the change, encoded in JSON.
+	Data string `json:"data"`
+}
+
+// HistoryEntry is a previous version of an object, returned by Store.History.
+type HistoryEntry[T any] struct {
+	// Operation is the change that replaced the version.
+	Operation Operation
+	// Actor is the operator who made the change, empty when unknown.
+	Actor string
+	// ChangedAt is the time of the change.
+	ChangedAt time.Time
+	// Before is the version of the object before the change.
+	Before *T
+}
+
+// WithHistory returns an Option recording the previous version of every object
+// modified by Update, UpdateNonZero, UpdateWhere, UpdateExpr, Delete, DeleteInChunks
+// or Purge in the <table>_history table, with the operation, the operator carried by
+// the context (see WithOperator) and the time of the change, for compliance audits.
+// The table is created by MigrateHistory, and the versions are read with History.
+//
+// The versions are recorded in the transaction of the change, which is opened when
+// the operation is not called within one, so these operations are not retried.
+// Upsert, UpsertBatch and the statements run with Exec are not recorded.
+func WithHistory[T any]() Option[T] {
+	return func(s *Store[T]) {
+		s.history = true
+	}
+}
+
+// MigrateHistory creates or updates the history table of the store.
+func (s *Store[T]) MigrateHistory(ctx context.Context) error {
+	table, err := s.historyTable(ctx)
+	if err != nil {
+		return err
+	}
+	return s.storage.DB(ctx).Table(table).AutoMigrate(&HistoryRecord{})
+}
+
+// History returns the previous versions of the object identified by the given primary
+// key parts, in the order of the primary key fields, from the oldest to the newest.
+func (s *Store[T]) History(ctx context.Context, key ...any) (ret []HistoryEntry[T], err error) {
+	ctx, op := s.begin(ctx, "History")
+	defer func() { op.end(err) }()
+
+	table, err := s.historyTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []HistoryRecord
+	err = s.retry(ctx, func() error {
+		return s.historyDB(ctx, table).Where("entity_id = ?", entityID(key)).Order("changed_at, id").Find(&records).Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object history from database", "key", key)
+		return nil, wrapError(err)
+	}
+
+	ret = make([]HistoryEntry[T], 0, len(records))
+	for _, record := range records {
+		var before T
+		if err := json.Unmarshal([]byte(record.Data), &before); err != nil {
+			return nil, fmt.Errorf("decode history record %d: %w", record.ID, err)
+		}
+		ret = append(ret, HistoryEntry[T]{
+			Operation: record.Operation,
+			Actor:     record.Actor,
+			ChangedAt: record.ChangedAt,
+			Before:    &before,
+		})
+	}
+	return ret, nil
+}
+
+// tracked runs fn, which makes the change op to the objects matching opts, within a
+// transaction recording their current version in the history table beforehand.
+// unscoped includes the soft-deleted objects.
+func (s *Store[T]) tracked(ctx context.Context, op Operation, opts *where.Options, unscoped bool, fn func(ctx context.Context) error) error {
+	if !s.history {
+		return fn(ctx)
+	}
+	return WithTx(ctx, s.storage, func(txCtx context.Context) error {
+		if err := s.recordHistory(txCtx, op, opts, unscoped); err != nil {
+			s.logger.Error(txCtx, err, "Failed to record object history", "conditions", opts)
+			return err
+		}
+		return fn(txCtx)
+	})
+}
+
+// trackedObject runs fn, which makes the change op to obj, like tracked. Objects
+// without primary key, which are being inserted, have no history.
+func (s *Store[T]) trackedObject(ctx context.Context, op Operation, obj *T, fn func(ctx context.Context) error) error {
+	if !s.history {
+		return fn(ctx)
+	}
+	pk, err := primaryField[T](s.db(ctx))
+	if err != nil {
+		return err
+	}
+	value, zero := pk.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+	if zero {
+		return fn(ctx)
+	}
+	return s.tracked(ctx, op, where.F(pk.DBName, value), false, fn)
+}
+
+// recordHistory copies the objects matching opts into the history table, in batches,
+// so that writes matching many rows neither load them all in memory nor exceed the
+// number of placeholders of a statement.
+func (s *Store[T]) recordHistory(ctx context.Context, op Operation, opts *where.Options, unscoped bool) error {
+	db := s.db(ctx, conditions(opts))
+	if unscoped {
+		db = db.Unscoped()
+	}
+	sch, err := schemaOf[T](db)
+	if err != nil {
+		return err
+	}
+	table, err := s.historyTable(ctx)
+	if err != nil {
+		return err
+	}
+	var actor string
+	if id, ok := OperatorFromContext(ctx); ok {
+		actor = fmt.Sprint(id)
+	}
+	now := time.Now()
+
+	var objs []*T
+	return db.FindInBatches(&objs, defaultBatchSize, func(_ *gorm.DB, _ int) error {
+		records := make([]HistoryRecord, 0, len(objs))
+		for _, obj := range objs {
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			key := make([]any, 0, len(sch.PrimaryFields))
+			for _, field := range sch.PrimaryFields {
+				value, _ := field.ValueOf(ctx, reflect.ValueOf(obj).Elem())
+				key = append(key, value)
+			}
+			records = append(records, HistoryRecord{
+				EntityID:  entityID(key),
+				Operation: op,
+				Actor:     actor,
+				ChangedAt: now,
+				Data:      string(data),
+			})
+		}
+		return s.historyDB(ctx, table).CreateInBatches(&records, defaultBatchSize).Error
+	}).Error
+}
+
+// historyTable returns the name of the history table of the store.
+func (s *Store[T]) historyTable(ctx context.Context) (string, error) {
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return "", err
+	}
+	return sch.Table + "_history", nil
+}
+
+// historyDB returns the database instance of the operations on the history table,
+// within the transaction carried by ctx, if any, and without the scopes of the store.
+func (s *Store[T]) historyDB(ctx context.Context, table string) *gorm.DB {
+	return s.db(ctx).Session(&gorm.Session{NewDB: true}).Table(table)
+}
+
+// entityID formats the parts of a primary key as the entity ID of history records.
+func entityID(key []any) string {
+	parts := make([]string, len(key))
+	for i, part := range key {
+		parts[i] = fmt.Sprint(part)
+	}
+	return strings.Join(parts, ",")
+}
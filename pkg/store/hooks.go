@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// Hook is a function called around a write operation on obj.
+// An error returned by a before hook aborts the operation, an error returned by
+// an after hook is returned to the caller once the operation has been executed.
+type Hook[T any] func(ctx context.Context, obj *T) error
+
+// DeleteHook is a function called around a delete operation with its where options.
+type DeleteHook func(ctx context.Context, opts *where.Options) error
+
+// hooks holds the lifecycle hooks registered on a Store.
+type hooks[T any] struct {
+	beforeCreate []Hook[T]
+	afterCreate  []Hook[T]
+	beforeUpdate []Hook[T]
+	afterUpdate  []Hook[T]
+	beforeDelete []DeleteHook
+	afterDelete  []DeleteHook
+}
+
+// OnBeforeCreate registers hooks called before an object is inserted by Create,
+// CreateBatch, Upsert or GetOrCreate.
+// Hooks are not safe to register concurrently with store operations, register them
+// when the store is set up.
+func (s *Store[T]) OnBeforeCreate(fns ...Hook[T]) *Store[T] {
+	s.hooks.beforeCreate = append(s.hooks.beforeCreate, fns...)
+	return s
+}
+
+// OnAfterCreate registers hooks called after an object has been inserted.
+func (s *Store[T]) OnAfterCreate(fns ...Hook[T]) *Store[T] {
+	s.hooks.afterCreate = append(s.hooks.afterCreate, fns...)
+	return s
+}
+
+// OnBeforeUpdate registers hooks called before an object is saved by Update.
+func (s *Store[T]) OnBeforeUpdate(fns ...Hook[T]) *Store[T] {
+	s.hooks.beforeUpdate = append(s.hooks.beforeUpdate, fns...)
+	return s
+}
+
+// OnAfterUpdate registers hooks called after an object has been saved by Update.
+func (s *Store[T]) OnAfterUpdate(fns ...Hook[T]) *Store[T] {
+	s.hooks.afterUpdate = append(s.hooks.afterUpdate, fns...)
+	return s
+}
+
+// OnBeforeDelete registers hooks called before objects are removed by Delete or Purge.
+func (s *Store[T]) OnBeforeDelete(fns ...DeleteHook) *Store[T] {
+	s.hooks.beforeDelete = append(s.hooks.beforeDelete, fns...)
+	return s
+}
+
+// OnAfterDelete registers hooks called after objects have been removed by Delete or Purge.
+func (s *Store[T]) OnAfterDelete(fns ...DeleteHook) *Store[T] {
+	s.hooks.afterDelete = append(s.hooks.afterDelete, fns...)
+	return s
+}
+
+// runHooks calls fns in registration order and stops at the first error.
+func runHooks[T any](ctx context.Context, fns []Hook[T], objs ...*T) error {
+	for _, fn := range fns {
+		for _, obj := range objs {
+			if err := fn(ctx, obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runDeleteHooks calls fns in registration order and stops at the first error.
+func runDeleteHooks(ctx context.Context, fns []DeleteHook, opts *where.Options) error {
+	for _, fn := range fns {
+		if err := fn(ctx, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
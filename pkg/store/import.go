@@ -0,0 +1,241 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// ImportOption defines a function type for configuring Import.
+type ImportOption[T any] func(*importOptions[T])
+
+// importOptions holds the configuration of an Import.
+type importOptions[T any] struct {
+	batchSize int
+	prepare   func(ctx context.Context, obj *T) error
+}
+
+// WithImportBatchSize sets the number of objects inserted per statement. Defaults to 100.
+func WithImportBatchSize[T any](size int) ImportOption[T] {
+	return func(o *importOptions[T]) {
+		o.batchSize = size
+	}
+}
+
+// WithImportHook sets a function called with every decoded object before it is
+// inserted, to validate it or fill the fields that are not imported. Objects for
+// which fn returns an error are skipped and reported in the ImportResult.
+func WithImportHook[T any](fn func(ctx context.Context, obj *T) error) ImportOption[T] {
+	return func(o *importOptions[T]) {
+		o.prepare = fn
+	}
+}
+
+// ImportResult reports the outcome of an Import.
+type ImportResult struct {
+	// Imported is the number of objects inserted.
+	Imported int64
+	// Errors holds the records that could not be imported, in order.
+	Errors []ImportError
+}
+
+// ImportError describes a record that could not be imported.
+type ImportError struct {
+	// Line is the line of the record in the input, starting at 1.
+	Line int
+	// Err is the reason why the record was not imported.
+	Err error
+}
+
+// Error implements the error interface.
+func (e ImportError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the reason why the record was not imported.
+func (e ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Import reads records from r in the given format, as written by Export, and inserts
+// them in batches. CSV input starts with a header row naming the columns of the
+// records, empty fields leave the objects fields zero. NDJSON input holds an object
+// per line, decoded like encoding/json does.
+//
+// Records that cannot be decoded, are rejected by the hook set with WithImportHook or
+// fail to be inserted are skipped and reported in the returned ImportResult, the other
+// records are imported: a batch failing to be inserted is inserted again object by
+// object to find the failing ones. The returned error reports the failures preventing
+// the import from going on, such as a read error or the cancellation of ctx.
+// Import should not be called within a transaction, which a failing statement aborts
+// on PostgreSQL.
+func (s *Store[T]) Import(ctx context.Context, r io.Reader, format ExportFormat, opts ...ImportOption[T]) (*ImportResult, error) {
+	o := importOptions[T]{batchSize: defaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.batchSize <= 0 {
+		o.batchSize = defaultBatchSize
+	}
+
+	var next func() (obj *T, line int, err error)
+	switch format {
+	case ExportCSV:
+		decoder, err := s.csvDecoder(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		next = decoder
+	case ExportNDJSON:
+		next = ndjsonDecoder[T](r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+
+	result := &ImportResult{}
+	batch := make([]*T, 0, o.batchSize)
+	lines := make([]int, 0, o.batchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		obj, line, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var decodeErr *importDecodeError
+		if errors.As(err, &decodeErr) {
+			result.Errors = append(result.Errors, ImportError{Line: line, Err: decodeErr.err})
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if o.prepare != nil {
+			if err := o.prepare(ctx, obj); err != nil {
+				result.Errors = append(result.Errors, ImportError{Line: line, Err: err})
+				continue
+			}
+		}
+
+		batch, lines = append(batch, obj), append(lines, line)
+		if len(batch) == o.batchSize {
+			s.importBatch(ctx, result, batch, lines)
+			batch, lines = batch[:0], lines[:0]
+		}
+	}
+	s.importBatch(ctx, result, batch, lines)
+	return result, nil
+}
+
+// importBatch inserts batch, whose objects were read at lines, falling back to
+// inserting its objects one by one when the batch fails to find the failing ones.
+func (s *Store[T]) importBatch(ctx context.Context, result *ImportResult, batch []*T, lines []int) {
+	if len(batch) == 0 {
+		return
+	}
+	inserted, err := s.CreateBatch(ctx, batch, len(batch))
+	if err == nil {
+		result.Imported += inserted
+		return
+	}
+	for i, obj := range batch {
+		if err := s.Create(ctx, obj); err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: lines[i], Err: err})
+			continue
+		}
+		result.Imported++
+	}
+}
+
+// importDecodeError reports a record that cannot be decoded, which is skipped.
+type importDecodeError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *importDecodeError) Error() string {
+	return e.err.Error()
+}
+
+// csvDecoder reads the header row of a CSV import from r and returns the function
+// decoding the next record.
+func (s *Store[T]) csvDecoder(ctx context.Context, r io.Reader) (func() (*T, int, error), error) {
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	fields := make([]*schema.Field, len(header))
+	for i, column := range header {
+		if fields[i] = sch.LookUpField(column); fields[i] == nil {
+			return nil, fmt.Errorf("unknown column %s of model %s", column, sch.Name)
+		}
+	}
+
+	return func() (*T, int, error) {
+		record, err := cr.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.Line, &importDecodeError{err: err}
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(fields) {
+			return nil, line, &importDecodeError{err: fmt.Errorf("expected %d fields, got %d", len(fields), len(record))}
+		}
+
+		obj := new(T)
+		row := reflect.ValueOf(obj).Elem()
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			if err := fields[i].Set(ctx, row, value); err != nil {
+				return nil, line, &importDecodeError{err: fmt.Errorf("column %s: %w", fields[i].DBName, err)}
+			}
+		}
+		return obj, line, nil
+	}, nil
+}
+
+// ndjsonDecoder returns the function decoding the next record of an NDJSON import
+// from r. Blank lines are skipped.
+func ndjsonDecoder[T any](r io.Reader) func() (*T, int, error) {
+	br := bufio.NewReader(r)
+	line := 0
+	return func() (*T, int, error) {
+		for {
+			data, err := br.ReadBytes('\n')
+			if len(data) > 0 || err == nil {
+				line++
+			}
+			if len(bytes.TrimSpace(data)) > 0 {
+				obj := new(T)
+				if err := json.Unmarshal(data, obj); err != nil {
+					return nil, line, &importDecodeError{err: err}
+				}
+				return obj, line, nil
+			}
+			if err != nil {
+				return nil, line, err
+			}
+		}
+	}
+}
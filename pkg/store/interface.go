@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// IReadStore defines the read operations of a store of T objects. It is implemented
+// by IStore and by ReadOnlyStore, so that code only reading objects can depend on it.
+type IReadStore[T any] interface {
+	// Get retrieves a single object matching opts.
+	Get(ctx context.Context, opts *where.Options) (*T, error)
+	// GetOrNil retrieves a single object matching opts, or nil when none matches.
+	GetOrNil(ctx context.Context, opts *where.Options) (*T, error)
+	// GetMany retrieves the objects whose primary key is in ids, in the order of ids.
+	GetMany(ctx context.Context, ids []any) ([]*T, error)
+	// GetByKey retrieves the object identified by key, which maps every primary key column to its value.
+	GetByKey(ctx context.Context, key map[string]any) (*T, error)
+	// List retrieves the objects matching opts, along with the total number of matching objects.
+	List(ctx context.Context, opts *where.Options) (int64, []*T, error)
+	// ListByCursor retrieves up to limit objects matching opts after cursor, and the cursor of the next page.
+	ListByCursor(ctx context.Context, opts *where.Options, cursor string, limit int) ([]*T, string, error)
+	// Count returns the number of objects matching opts.
+	Count(ctx context.Context, opts *where.Options) (int64, error)
+	// Exists reports whether at least one object matches opts.
+	Exists(ctx context.Context, opts *where.Options) (bool, error)
+	// Each calls fn for every batch of batchSize objects matching opts.
+	Each(ctx context.Context, opts *where.Options, batchSize int, fn func([]*T) error) error
+	// Pluck scans a single column of the objects matching opts into dest.
+	Pluck(ctx context.Context, column string, dest any, opts *where.Options) error
+	// Aggregate applies agg to column over the objects matching opts.
+	Aggregate(ctx context.Context, opts *where.Options, agg Agg, column string) (float64, error)
+}
+
+// IStore defines the operations of a store of T objects. It is implemented by Store
+// and by the decorators wrapping it, such as CachedStore and BreakerStore, so that
+// services can depend on IStore and be handed any chain of decorators, or an
+// in-memory fake in unit tests.
+type IStore[T any] interface {
+	IReadStore[T]
+
+	// Create inserts a new object.
+	Create(ctx context.Context, obj *T) error
+	// CreateBatch inserts objs in batches of batchSize objects and returns the number of objects inserted.
+	CreateBatch(ctx context.Context, objs []*T, batchSize int) (int64, error)
+	// Upsert inserts obj or updates the updateColumns of the object conflicting on conflictColumns.
+	Upsert(ctx context.Context, obj *T, conflictColumns []string, updateColumns []string) error
+	// UpsertBatch upserts objs like Upsert, with a single statement per batch, and returns the number of rows affected.
+	UpsertBatch(ctx context.Context, objs []*T, conflictColumns []string, updateColumns []string) (int64, error)
+	// Update modifies an existing object.
+	Update(ctx context.Context, obj *T) error
+	// UpdateNonZero updates the non-zero fields of obj, or exactly the given columns.
+	UpdateNonZero(ctx context.Context, obj *T, columns ...string) error
+	// UpdateWhere sets the given columns of every object matching opts and returns the number of objects updated.
+	UpdateWhere(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error)
+	// UpdateMany sets the given columns of every object matching opts, like UpdateWhere.
+	UpdateMany(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error)
+	// UpdateExpr sets column to the SQL expression expr on every object matching opts and returns the number of objects updated.
+	UpdateExpr(ctx context.Context, opts *where.Options, column string, expr string, args ...any) (int64, error)
+	// Increment atomically adds delta to column on every object matching opts and returns the number of objects updated.
+	Increment(ctx context.Context, opts *where.Options, column string, delta int64) (int64, error)
+	// Delete removes the objects matching opts, softly when T supports it.
+	Delete(ctx context.Context, opts *where.Options) error
+	// DeleteByKey removes the object identified by key, like GetByKey.
+	DeleteByKey(ctx context.Context, key map[string]any) error
+	// DeleteInChunks removes the objects matching opts by chunks of chunkSize rows and returns the number of objects deleted.
+	DeleteInChunks(ctx context.Context, opts *where.Options, chunkSize int, progress func(deleted int64)) (int64, error)
+	// Purge permanently removes the objects matching opts.
+	Purge(ctx context.Context, opts *where.Options) error
+	// Restore brings back the soft-deleted objects matching opts and returns the number of objects restored.
+	Restore(ctx context.Context, opts *where.Options) (int64, error)
+	// GetOrCreate retrieves the object matching opts, creating obj when none matches.
+	GetOrCreate(ctx context.Context, opts *where.Options, obj *T) (*T, bool, error)
+	// RawQuery runs a raw SQL query and scans its result into dest.
+	RawQuery(ctx context.Context, dest any, sql string, args ...any) error
+	// Exec runs a raw SQL statement and returns the number of rows affected.
+	Exec(ctx context.Context, sql string, args ...any) (int64, error)
+	// Tx runs fn inside a transaction.
+	Tx(ctx context.Context, fn func(txCtx context.Context) error) error
+}
+
+var (
+	_ IStore[any] = (*Store[any])(nil)
+	_ IStore[any] = (*CachedStore[any])(nil)
+	_ IStore[any] = (*BreakerStore[any])(nil)
+
+	_ IReadStore[any] = (*ReadOnlyStore[any])(nil)
+)
+
+// Decorator wraps an IStore with additional behavior.
+type Decorator[T any] func(IStore[T]) IStore[T]
+
+// Decorate wraps s with decorators. The first decorator is the outermost one,
+// so Decorate(s, a, b) returns a(b(s)).
+func Decorate[T any](s IStore[T], decorators ...Decorator[T]) IStore[T] {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		s = decorators[i](s)
+	}
+	return s
+}
+
+// Cached returns a Decorator adding a read-through cache, see NewCachedStore.
+func Cached[T any](cache CacheBackend, opts ...CacheOption) Decorator[T] {
+	return func(s IStore[T]) IStore[T] {
+		return NewCachedStore(s, cache, opts...)
+	}
+}
+
+// Breaking returns a Decorator adding a circuit breaker, see NewBreakerStore.
+func Breaking[T any](breaker *CircuitBreaker) Decorator[T] {
+	return func(s IStore[T]) IStore[T] {
+		return NewBreakerStore(s, breaker)
+	}
+}
+
+// unwrapper is implemented by decorators to expose the store they wrap.
+type unwrapper[T any] interface {
+	Unwrap() IStore[T]
+}
+
+// baseStore returns the Store at the bottom of a chain of decorators.
+func baseStore[T any](s IStore[T]) (*Store[T], bool) {
+	for {
+		switch v := s.(type) {
+		case *Store[T]:
+			return v, true
+		case unwrapper[T]:
+			s = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// GetByKey retrieves the object identified by key, which maps every column of the
+// primary key to its value, such as map[string]any{"tenant_id": 1, "code": "FR"} for
+// a composite key. It returns an error matching ErrIncompleteKey when a part of the
+// key is missing or zero, and an error matching ErrNotFound when no object matches.
+func (s *Store[T]) GetByKey(ctx context.Context, key map[string]any) (*T, error) {
+	opts, err := s.keyWhere(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, opts)
+}
+
+// DeleteByKey removes the object identified by key, like GetByKey. A key missing a
+// part is rejected with an error matching ErrIncompleteKey instead of deleting every
+// object matching the other parts.
+func (s *Store[T]) DeleteByKey(ctx context.Context, key map[string]any) error {
+	opts, err := s.keyWhere(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, opts)
+}
+
+// keyWhere returns the where options matching the object identified by key, after
+// checking that key holds a non-zero value for every primary key column and nothing else.
+func (s *Store[T]) keyWhere(ctx context.Context, key map[string]any) (*where.Options, error) {
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(sch.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("model %s has no primary key", sch.Name)
+	}
+
+	for _, field := range sch.PrimaryFields {
+		value, ok := key[field.DBName]
+		if !ok || value == nil || reflect.ValueOf(value).IsZero() {
+			return nil, fmt.Errorf("%w: missing %s of model %s", ErrIncompleteKey, field.DBName, sch.Name)
+		}
+	}
+	for column := range key {
+		if !slices.ContainsFunc(sch.PrimaryFields, func(f *schema.Field) bool { return f.DBName == column }) {
+			return nil, fmt.Errorf("column %s is not part of the primary key of model %s", column, sch.Name)
+		}
+	}
+	return where.Key(key), nil
+}
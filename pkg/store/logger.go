@@ -2,10 +2,90 @@ package store
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger defines an interface for logging errors with contextual information.
+// Loggers may also implement WarnLogger to log warnings, such as slow queries, which
+// are otherwise logged as errors.
 type Logger interface {
 	// Error logs an error message with the associated context.
 	Error(ctx context.Context, err error, message string, kvs ...any)
 }
+
+// WarnLogger is implemented by the loggers logging warnings.
+type WarnLogger interface {
+	// Warn logs a warning message with the associated context.
+	Warn(ctx context.Context, message string, kvs ...any)
+}
+
+// warn logs a warning message to logger, as an error when it does not implement WarnLogger.
+func warn(ctx context.Context, logger Logger, message string, kvs ...any) {
+	if w, ok := logger.(WarnLogger); ok {
+		w.Warn(ctx, message, kvs...)
+		return
+	}
+	logger.Error(ctx, errors.New(message), message, kvs...)
+}
+
+// ContextExtractors maps the keys added to the entries logged by a store to the
+// functions extracting their values from the context of the operation.
+type ContextExtractors map[string]func(ctx context.Context) string
+
+// WithContextExtractors returns an Option adding the values extracted from the context
+// of the operations, such as the ID of the request being served, to every entry logged
+// by the store, so that a failing statement can be correlated with the request that
+// caused it. Empty values are omitted. The IDs of the OpenTelemetry span carried by
+// the context, if any, are always added as traceID and spanID.
+func WithContextExtractors[T any](extractors ContextExtractors) Option[T] {
+	return func(s *Store[T]) {
+		s.extractors = extractors
+	}
+}
+
+// correlatingLogger adds the values extracted from the context to the logged entries.
+type correlatingLogger struct {
+	Logger
+	keys       []string
+	extractors ContextExtractors
+}
+
+// correlateLogger wraps logger with the extraction of the correlation values.
+func correlateLogger(logger Logger, extractors ContextExtractors) Logger {
+	return &correlatingLogger{Logger: logger, keys: slices.Sorted(maps.Keys(extractors)), extractors: extractors}
+}
+
+// Error implements Logger.
+func (l *correlatingLogger) Error(ctx context.Context, err error, message string, kvs ...any) {
+	l.Logger.Error(ctx, err, message, l.correlate(ctx, kvs)...)
+}
+
+// Warn implements WarnLogger.
+func (l *correlatingLogger) Warn(ctx context.Context, message string, kvs ...any) {
+	warn(ctx, l.Logger, message, l.correlate(ctx, kvs)...)
+}
+
+// correlate returns kvs followed by the correlation values carried by ctx.
+func (l *correlatingLogger) correlate(ctx context.Context, kvs []any) []any {
+	if ctx == nil {
+		return kvs
+	}
+	var extra []any
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		extra = append(extra, "traceID", span.TraceID().String(), "spanID", span.SpanID().String())
+	}
+	// Keys are sorted so that entries list them in a stable order.
+	for _, key := range l.keys {
+		if value := l.extractors[key](ctx); value != "" {
+			extra = append(extra, key, value)
+		}
+	}
+	if len(extra) == 0 {
+		return kvs
+	}
+	return append(slices.Clip(kvs), extra...)
+}
@@ -16,3 +16,9 @@ func NewLogger() *emptyLogger {
 func (l *emptyLogger) Error(ctx context.Context, err error, msg string, kvs ...any) {
 	// No operation performed for logging errors
 }
+
+// Warn is a no-op method that satisfies the WarnLogger interface.
+// It does not log any warning messages or context.
+func (l *emptyLogger) Warn(ctx context.Context, msg string, kvs ...any) {
+	// No operation performed for logging warnings
+}
@@ -15,3 +15,7 @@ func NewLogger() *miladyLogger {
 func (l *miladyLogger) Error(ctx context.Context, err error, msg string, kvs ...any) {
 	log.Errorw(err, msg, kvs...)
 }
+
+func (l *miladyLogger) Warn(ctx context.Context, msg string, kvs ...any) {
+	log.Warnw(msg, kvs...)
+}
@@ -0,0 +1,5 @@
+// Package migrate runs versioned schema migrations on top of the store package.
+// Migrations are registered in order, applied migrations are tracked in a
+// schema_migrations table, and a lock keeps concurrent deployments from applying
+// the same migrations twice.
+package migrate // import "github.com/miladystack/miladystack/pkg/store/migrate"
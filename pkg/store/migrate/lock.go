@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miladystack/miladystack/pkg/store"
+)
+
+// ErrLocked is returned when the migration lock is still held by another run
+// once the lock timeout has elapsed.
+var ErrLocked = errors.New("migrations are locked by another run")
+
+// Locker serializes the migration runs of concurrent deployments. The lockers of
+// the distlock package satisfy it. Lock should wait for the lock until ctx is done.
+type Locker interface {
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// lockRecord is the row of the lock table held by the running migrator.
+type lockRecord struct {
+	ID        int    `gorm:"primaryKey;autoIncrement:false"`
+	Owner     string `gorm:"size:64"`
+	ExpiresAt time.Time
+}
+
+// tableLocker is a Locker holding a row of a lock table in the migrated database.
+// The lock expires after its lease so that a crashed run does not block the
+// following deployments forever.
+type tableLocker struct {
+	provider store.DBProvider
+	table    string
+	lease    time.Duration
+	interval time.Duration
+	owner    string
+}
+
+// Lock implements Locker, polling the lock table until the lock is acquired or ctx is done.
+func (l *tableLocker) Lock(ctx context.Context) error {
+	if err := l.provider.DB(ctx).Table(l.table).AutoMigrate(&lockRecord{}); err != nil {
+		return err
+	}
+
+	owner := make([]byte, 8)
+	_, _ = rand.Read(owner)
+	l.owner = hex.EncodeToString(owner)
+
+	for {
+		acquired, err := l.tryLock(ctx)
+		if err != nil || acquired {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrLocked, ctx.Err())
+		case <-time.After(l.interval):
+		}
+	}
+}
+
+// tryLock inserts the lock row, or takes it over once expired.
+func (l *tableLocker) tryLock(ctx context.Context) (bool, error) {
+	now := time.Now()
+	db := l.provider.DB(ctx).Table(l.table)
+
+	err := db.Create(&lockRecord{ID: 1, Owner: l.owner, ExpiresAt: now.Add(l.lease)}).Error
+	if err == nil || !store.IsDuplicateKey(err) {
+		return err == nil, err
+	}
+
+	res := l.provider.DB(ctx).Table(l.table).Where("id = ? AND expires_at < ?", 1, now).
+		Updates(map[string]any{"owner": l.owner, "expires_at": now.Add(l.lease)})
+	return res.RowsAffected == 1, res.Error
+}
+
+// Unlock implements Locker.
+func (l *tableLocker) Unlock(ctx context.Context) error {
+	return l.provider.DB(ctx).Table(l.table).Where("id = ? AND owner = ?", 1, l.owner).Delete(&lockRecord{}).Error
+}
@@ -0,0 +1,372 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+)
+
+const (
+	defaultTable        = "schema_migrations"
+	defaultLockTimeout  = time.Minute
+	defaultLockLease    = 10 * time.Minute
+	defaultLockInterval = time.Second
+)
+
+var (
+	// ErrDuplicateVersion is returned when two migrations share the same version.
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+
+	// ErrIrreversible is returned when rolling back a migration without Down.
+	ErrIrreversible = errors.New("irreversible migration")
+
+	// ErrUnknownVersion is returned when rolling back an applied migration that is not registered.
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// Func applies or reverts a migration on tx.
+type Func func(ctx context.Context, tx *gorm.DB) error
+
+// SQL returns a Func executing statements in order.
+func SQL(statements ...string) Func {
+	return func(ctx context.Context, tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Migration is a versioned schema change.
+type Migration struct {
+	// Version orders the migrations, such as a timestamp like 20240131120000.
+	// It must be unique and is recorded in the schema_migrations table once applied.
+	Version int64
+	// Name describes the migration.
+	Name string
+	// Up applies the migration.
+	Up Func
+	// Down reverts the migration. A migration without Down cannot be rolled back.
+	Down Func
+}
+
+// Record is a row of the schema_migrations table.
+type Record struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"size:255" json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	// Applied reports whether the migration has been applied.
+	Applied bool `json:"applied"`
+	// AppliedAt is the time the migration was applied.
+	AppliedAt time.Time `json:"applied_at"`
+	// Registered reports whether the migration is known to the Migrator. Applied
+	// migrations that are not registered were usually applied by a newer release.
+	Registered bool `json:"registered"`
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   []Migration
+)
+
+// Register registers migrations to be run by every Migrator created afterwards.
+// Migrations are usually registered during initialization, it panics when a
+// migration is invalid or its version is already registered.
+func Register(migrations ...Migration) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	ms, err := add(registered, migrations)
+	if err != nil {
+		panic(err)
+	}
+	registered = ms
+}
+
+// Option defines a function type for configuring the Migrator.
+type Option func(*Migrator)
+
+// WithTable sets the name of the table tracking the applied migrations.
+// Defaults to schema_migrations.
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLocker sets the Locker serializing migration runs, such as a distlock.Locker.
+// Defaults to a lock row stored in the <table>_lock table of the database.
+func WithLocker(locker Locker) Option {
+	return func(m *Migrator) {
+		m.locker = locker
+	}
+}
+
+// WithLockTimeout sets how long to wait for the lock held by another run. Defaults to 1 minute.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithLogger sets the logger used to report failed migrations.
+func WithLogger(logger store.Logger) Option {
+	return func(m *Migrator) {
+		m.logger = logger
+	}
+}
+
+// Migrator applies and reverts migrations on the database of a DBProvider.
+type Migrator struct {
+	provider    store.DBProvider
+	logger      store.Logger
+	table       string
+	locker      Locker
+	lockTimeout time.Duration
+
+	mu         sync.Mutex
+	migrations []Migration
+}
+
+// New creates a Migrator running the registered migrations on the database of provider.
+func New(provider store.DBProvider, opts ...Option) *Migrator {
+	registeredMu.Lock()
+	migrations := slices.Clone(registered)
+	registeredMu.Unlock()
+
+	m := &Migrator{
+		provider:    provider,
+		logger:      empty.NewLogger(),
+		table:       defaultTable,
+		lockTimeout: defaultLockTimeout,
+		migrations:  migrations,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.locker == nil {
+		m.locker = &tableLocker{
+			provider: provider,
+			table:    m.table + "_lock",
+			lease:    defaultLockLease,
+			interval: defaultLockInterval,
+		}
+	}
+	return m
+}
+
+// Register adds migrations to those run by m.
+func (m *Migrator) Register(migrations ...Migration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ms, err := add(m.migrations, migrations)
+	if err != nil {
+		return err
+	}
+	m.migrations = ms
+	return nil
+}
+
+// Up applies the pending migrations in version order and returns them. Each
+// migration runs in its own transaction together with its record in the
+// schema_migrations table, so a failed migration leaves the previous ones applied.
+// Note that MySQL commits DDL statements implicitly, they are not rolled back.
+//
+// With a context returned by store.DryRun, the pending migrations build their
+// statements into the DryRunResult without executing them, and nothing is recorded.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var pending []Migration
+	err := m.run(ctx, func(applied map[int64]Record) error {
+		for _, migration := range m.registered() {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, migration, migration.Up, true); err != nil {
+				return err
+			}
+			pending = append(pending, migration)
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// Down reverts the last steps applied migrations, most recent first, and returns them.
+// Like Up, it honors store.DryRun.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.run(ctx, func(applied map[int64]Record) error {
+		versions := slices.Sorted(maps.Keys(applied))
+		slices.Reverse(versions)
+
+		registered := m.registered()
+		for _, version := range versions[:min(max(steps, 0), len(versions))] {
+			i := slices.IndexFunc(registered, func(migration Migration) bool { return migration.Version == version })
+			if i < 0 {
+				return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+			}
+			migration := registered[i]
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d %s", ErrIrreversible, migration.Version, migration.Name)
+			}
+			if err := m.apply(ctx, migration, migration.Down, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns the registered and applied migrations in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int64]Status)
+	for _, migration := range m.registered() {
+		statuses[migration.Version] = Status{Version: migration.Version, Name: migration.Name, Registered: true}
+	}
+	for version, record := range applied {
+		status, ok := statuses[version]
+		if !ok {
+			status = Status{Version: version, Name: record.Name}
+		}
+		status.Applied, status.AppliedAt = true, record.AppliedAt
+		statuses[version] = status
+	}
+
+	ret := make([]Status, 0, len(statuses))
+	for _, version := range slices.Sorted(maps.Keys(statuses)) {
+		ret = append(ret, statuses[version])
+	}
+	return ret, nil
+}
+
+// run takes the lock, makes sure the schema_migrations table exists and calls fn
+// with the applied migrations. Dry runs neither lock nor create the table.
+func (m *Migrator) run(ctx context.Context, fn func(applied map[int64]Record) error) (err error) {
+	if !store.IsDryRun(ctx) {
+		if err := m.lock(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := m.locker.Unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+				m.logger.Error(ctx, unlockErr, "Failed to release migration lock")
+				err = errors.Join(err, unlockErr)
+			}
+		}()
+
+		if err := m.provider.DB(ctx).Table(m.table).AutoMigrate(&Record{}); err != nil {
+			m.logger.Error(ctx, err, "Failed to create migrations table", "table", m.table)
+			return err
+		}
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+// lock acquires the lock, waiting up to the lock timeout.
+func (m *Migrator) lock(ctx context.Context) error {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	if err := m.locker.Lock(lockCtx); err != nil {
+		m.logger.Error(ctx, err, "Failed to acquire migration lock")
+		return err
+	}
+	return nil
+}
+
+// apply runs fn, the Up or Down of migration, and records the outcome.
+func (m *Migrator) apply(ctx context.Context, migration Migration, fn Func, up bool) error {
+	var err error
+	if store.IsDryRun(ctx) {
+		err = fn(ctx, store.DryRunDB(ctx, m.provider.DB(ctx)))
+	} else {
+		err = m.provider.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := fn(ctx, tx); err != nil {
+				return err
+			}
+			if up {
+				return tx.Table(m.table).Create(&Record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			}
+			return tx.Table(m.table).Where("version = ?", migration.Version).Delete(&Record{}).Error
+		})
+	}
+	if err != nil {
+		m.logger.Error(ctx, err, "Failed to run migration", "version", migration.Version, "name", migration.Name, "up", up)
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// applied returns the applied migrations by version. No migration has been applied
+// while the schema_migrations table does not exist.
+func (m *Migrator) applied(ctx context.Context) (map[int64]Record, error) {
+	db := m.provider.DB(ctx)
+	applied := make(map[int64]Record)
+	if !db.Migrator().HasTable(m.table) {
+		return applied, nil
+	}
+
+	var records []Record
+	if err := db.Table(m.table).Find(&records).Error; err != nil {
+		m.logger.Error(ctx, err, "Failed to list applied migrations", "table", m.table)
+		return nil, err
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// registered returns a snapshot of the registered migrations.
+func (m *Migrator) registered() []Migration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.migrations
+}
+
+// add returns migrations sorted by version with more added, after validating them.
+func add(migrations []Migration, more []Migration) ([]Migration, error) {
+	ret := slices.Clone(migrations)
+	for _, migration := range more {
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d %s has no Up", migration.Version, migration.Name)
+		}
+		if slices.ContainsFunc(ret, func(m Migration) bool { return m.Version == migration.Version }) {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, migration.Version)
+		}
+		ret = append(ret, migration)
+	}
+	slices.SortFunc(ret, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return ret, nil
+}
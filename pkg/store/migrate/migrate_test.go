@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testProvider struct {
+	db *gorm.DB
+}
+
+func (p *testProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return p.db.WithContext(ctx)
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return &testProvider{db: db}
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{
+			Version: 2,
+			Name:    "add_users_email",
+			Up:      SQL("ALTER TABLE users ADD COLUMN email TEXT"),
+			Down:    SQL("ALTER TABLE users DROP COLUMN email"),
+		},
+		{
+			Version: 1,
+			Name:    "create_users",
+			Up:      SQL("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)"),
+			Down:    SQL("DROP TABLE users"),
+		},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+
+	m := New(provider)
+	if err := m.Register(testMigrations()...); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := m.Register(Migration{Version: 1, Up: SQL("SELECT 1")}); !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("Expected ErrDuplicateVersion, got %v", err)
+	}
+
+	dryCtx, result := store.DryRun(ctx)
+	if pending, err := m.Up(dryCtx); err != nil || len(pending) != 2 {
+		t.Fatalf("Expected 2 pending migrations in dry run, got %d, %v", len(pending), err)
+	}
+	if statements := result.Statements(); len(statements) != 2 || !strings.HasPrefix(statements[0].SQL, "CREATE TABLE users") {
+		t.Errorf("Unexpected dry-run statements %+v", statements)
+	}
+	if provider.db.Migrator().HasTable("users") || provider.db.Migrator().HasTable(defaultTable) {
+		t.Errorf("Expected dry run to leave the database untouched")
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil || len(applied) != 2 || applied[0].Version != 1 {
+		t.Fatalf("Expected migrations 1 and 2 to be applied in order, got %+v, %v", applied, err)
+	}
+	if !provider.db.Migrator().HasColumn("users", "email") {
+		t.Errorf("Expected users.email to exist")
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("Expected no pending migration, got %d, %v", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Expected migration 2 to be reverted, got %+v, %v", reverted, err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil || len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Expected only migration 1 to be applied, got %+v, %v", statuses, err)
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+
+	m := New(provider)
+	_ = m.Register(
+		Migration{Version: 1, Name: "create_items", Up: SQL("CREATE TABLE items (id INTEGER PRIMARY KEY)")},
+		Migration{Version: 2, Name: "broken", Up: SQL("ALTER TABLE missing ADD COLUMN x TEXT")},
+	)
+
+	if _, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "migration 2 broken") {
+		t.Fatalf("Expected migration 2 to fail, got %v", err)
+	}
+	statuses, _ := m.Status(ctx)
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Expected migration 1 to stay applied, got %+v", statuses)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("Expected ErrIrreversible, got %v", err)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+
+	holder := &tableLocker{provider: provider, table: "schema_migrations_lock", lease: time.Minute, interval: time.Millisecond}
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	m := New(provider, WithLockTimeout(20*time.Millisecond))
+	if _, err := m.Up(ctx); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked while another run holds the lock, got %v", err)
+	}
+
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Errorf("Expected Up to succeed once the lock is released, got %v", err)
+	}
+}
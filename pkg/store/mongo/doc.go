@@ -0,0 +1,4 @@
+// Package mongo implements the generic store operations over a MongoDB collection,
+// accepting the same where options as the SQL store, so that services can swap
+// backends without rewriting their data access code.
+package mongo // import "github.com/miladystack/miladystack/pkg/store/mongo"
@@ -0,0 +1,253 @@
+package mongo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ErrUnsupported is returned when where options cannot be translated to a MongoDB query,
+// such as raw SQL conditions, joins, preloads or row locks.
+var ErrUnsupported = errors.New("unsupported by the mongo store")
+
+// Filter translates the conditions of opts into a MongoDB filter document.
+// Filter keys are document field names. Slice values match any of their elements,
+// like IN conditions. Clauses support the comparison expressions of gorm/clause
+// (Eq, Neq, Gt, Gte, Lt, Lte, IN, Like) combined with clause.And, clause.Or and clause.Not.
+// Queries are only supported with map conditions.
+func Filter(opts *where.Options) (bson.D, error) {
+	filter := bson.D{}
+	if opts == nil {
+		return filter, nil
+	}
+	if len(opts.Joins) > 0 || len(opts.Preloads) > 0 || opts.Locking != "" || len(opts.Scopes) > 0 {
+		return nil, fmt.Errorf("%w: joins, preloads, locks and scopes", ErrUnsupported)
+	}
+	if err := opts.CheckAllowed(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]any, 0, len(opts.Filters))
+	for key := range opts.Filters {
+		keys = append(keys, key)
+	}
+	// Sort the keys so that the same options always produce the same filter.
+	slices.SortFunc(keys, func(a, b any) int { return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)) })
+	for _, key := range keys {
+		filter = append(filter, bson.E{Key: fmt.Sprint(key), Value: match(opts.Filters[key])})
+	}
+
+	for _, query := range opts.Queries {
+		conds, ok := toMap(query.Query)
+		if !ok || len(query.Args) > 0 {
+			return nil, fmt.Errorf("%w: query %v", ErrUnsupported, query.Query)
+		}
+		for _, key := range slices.Sorted(maps.Keys(conds)) {
+			filter = append(filter, bson.E{Key: key, Value: match(conds[key])})
+		}
+	}
+
+	for _, expr := range opts.Clauses {
+		cond, err := expression(expr)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, cond...)
+	}
+
+	searches := bson.A{}
+	for _, search := range opts.Searches {
+		if search.Keyword == "" || len(search.Columns) == 0 {
+			continue
+		}
+		conds := make(bson.A, 0, len(search.Columns))
+		for _, column := range search.Columns {
+			conds = append(conds, bson.D{{Key: column, Value: bson.D{{Key: "$regex", Value: regexp.QuoteMeta(search.Keyword)}, {Key: "$options", Value: "i"}}}})
+		}
+		searches = append(searches, bson.D{{Key: "$or", Value: conds}})
+	}
+	if len(searches) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: searches})
+	}
+	return filter, nil
+}
+
+// Sort translates an SQL ORDER BY list, such as "name desc, age", into a MongoDB sort document.
+func Sort(order string) (bson.D, error) {
+	sort := bson.D{}
+	for _, part := range strings.Split(order, ",") {
+		fields := strings.Fields(part)
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) == 1 || (len(fields) == 2 && strings.EqualFold(fields[1], "asc")):
+			sort = append(sort, bson.E{Key: fields[0], Value: 1})
+		case len(fields) == 2 && strings.EqualFold(fields[1], "desc"):
+			sort = append(sort, bson.E{Key: fields[0], Value: -1})
+		default:
+			return nil, fmt.Errorf("%w: order %q", ErrUnsupported, part)
+		}
+	}
+	return sort, nil
+}
+
+// match returns the condition matching value, using $in for slices.
+func match(value any) any {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		return bson.M{"$in": value}
+	}
+	return value
+}
+
+// toMap converts map conditions to a map keyed by field name.
+func toMap(query any) (map[string]any, bool) {
+	switch conds := query.(type) {
+	case map[string]any:
+		return conds, true
+	case map[any]any:
+		m := make(map[string]any, len(conds))
+		for key, value := range conds {
+			m[fmt.Sprint(key)] = value
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// expression translates a gorm clause expression into filter elements.
+func expression(expr clause.Expression) (bson.D, error) {
+	switch e := expr.(type) {
+	case clause.Eq:
+		return comparison(e.Column, "$eq", e.Value)
+	case clause.Neq:
+		return comparison(e.Column, "$ne", e.Value)
+	case clause.Gt:
+		return comparison(e.Column, "$gt", e.Value)
+	case clause.Gte:
+		return comparison(e.Column, "$gte", e.Value)
+	case clause.Lt:
+		return comparison(e.Column, "$lt", e.Value)
+	case clause.Lte:
+		return comparison(e.Column, "$lte", e.Value)
+	case clause.IN:
+		return comparison(e.Column, "$in", e.Values)
+	case clause.Like:
+		pattern, ok := e.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: like %v", ErrUnsupported, e.Value)
+		}
+		return comparison(e.Column, "$regex", likeToRegex(pattern))
+	case where.LikeExpr:
+		return comparison(e.Column, "$regex", e.Regexp())
+	case where.BetweenExpr:
+		return bson.D{{Key: e.Column, Value: bson.D{{Key: "$gte", Value: e.From}, {Key: "$lte", Value: e.To}}}}, nil
+	case where.JSONEqExpr:
+		keys, err := where.JSONPathKeys(e.Path)
+		if err != nil {
+			return nil, err
+		}
+		return comparison(strings.Join(append([]string{e.Column}, keys...), "."), "$eq", e.Value)
+	case where.JSONContainsExpr:
+		data, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return contains(e.Column, value), nil
+	case where.NotExpr:
+		cond, err := expression(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: "$nor", Value: bson.A{cond}}}, nil
+	case clause.AndConditions:
+		return combine("$and", e.Exprs)
+	case clause.OrConditions:
+		return combine("$or", e.Exprs)
+	case clause.NotConditions:
+		conds, err := combine("$or", e.Exprs)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: "$nor", Value: conds[0].Value}}, nil
+	case clause.Where:
+		return combine("$and", e.Exprs)
+	}
+	return nil, fmt.Errorf("%w: clause %T", ErrUnsupported, expr)
+}
+
+// comparison builds the filter element applying operator to column.
+func comparison(column any, operator string, value any) (bson.D, error) {
+	var name string
+	switch c := column.(type) {
+	case string:
+		name = c
+	case clause.Column:
+		name = c.Name
+	default:
+		return nil, fmt.Errorf("%w: column %v", ErrUnsupported, column)
+	}
+	return bson.D{{Key: name, Value: bson.D{{Key: operator, Value: value}}}}, nil
+}
+
+// contains builds the filter elements matching the documents whose field contains the
+// decoded JSON value: the members of objects are matched by their dotted path, the
+// elements of arrays with $all.
+func contains(field string, value any) bson.D {
+	switch v := value.(type) {
+	case map[string]any:
+		var conds bson.D
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			conds = append(conds, contains(field+"."+key, v[key])...)
+		}
+		return conds
+	case []any:
+		return bson.D{{Key: field, Value: bson.D{{Key: "$all", Value: bson.A(v)}}}}
+	}
+	return bson.D{{Key: field, Value: bson.D{{Key: "$eq", Value: value}}}}
+}
+
+// combine builds the filter element joining exprs with a logical operator.
+func combine(operator string, exprs []clause.Expression) (bson.D, error) {
+	conds := make(bson.A, 0, len(exprs))
+	for _, expr := range exprs {
+		cond, err := expression(expr)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	return bson.D{{Key: operator, Value: conds}}, nil
+}
+
+// likeToRegex converts an SQL LIKE pattern into an anchored regular expression.
+func likeToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
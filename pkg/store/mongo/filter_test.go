@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *where.Options
+		want string
+	}{
+		{
+			name: "filters",
+			opts: where.F("status", "active", "role", []string{"admin", "owner"}),
+			want: `{"role":{"$in":["admin","owner"]},"status":"active"}`,
+		},
+		{
+			name: "clauses",
+			opts: where.C(clause.Gte{Column: "age", Value: 18}, clause.Or(
+				clause.Like{Column: clause.Column{Name: "name"}, Value: "jo%"},
+				clause.Eq{Column: "vip", Value: true},
+			)),
+			want: `{"age":{"$gte":18},"$or":[{"name":{"$regex":"^jo.*$"}},{"vip":{"$eq":true}}]}`,
+		},
+		{
+			name: "like",
+			opts: where.ILike("name", where.Prefix("jo_")),
+			want: `{"name":{"$regex":"(?si)^jo_.*$"}}`,
+		},
+		{
+			name: "between",
+			opts: where.Between("age", 18, 30),
+			want: `{"age":{"$gte":18,"$lte":30}}`,
+		},
+		{
+			name: "not",
+			opts: where.Not(where.F("status", "archived", "locked", true)),
+			want: `{"$nor":[{"$and":[{"locked":{"$eq":true}},{"status":{"$eq":"archived"}}]}]}`,
+		},
+		{
+			name: "json",
+			opts: where.JSONEq("meta", "$.items[0].id", 7).JSONContains("meta", map[string]any{"plan": "pro", "tags": []string{"go"}}),
+			want: `{"meta.items.0.id":{"$eq":7},"meta.plan":{"$eq":"pro"},"meta.tags":{"$all":["go"]}}`,
+		},
+		{
+			name: "search",
+			opts: where.Search("j.doe", "name", "email"),
+			want: `{"$and":[{"$or":[{"name":{"$regex":"j\\.doe","$options":"i"}},{"email":{"$regex":"j\\.doe","$options":"i"}}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Filter(tt.opts)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+			data, err := bson.MarshalExtJSON(filter, false, false)
+			if err != nil {
+				t.Fatalf("Failed to marshal filter: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Filter mismatch\n got: %s\nwant: %s", data, tt.want)
+			}
+		})
+	}
+
+	if _, err := Filter(where.NewWhere().Q("name = ?", "x")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for raw SQL query, got %v", err)
+	}
+}
+
+func TestSort(t *testing.T) {
+	sort, err := Sort("name desc, age")
+	if err != nil {
+		t.Fatalf("Sort failed: %v", err)
+	}
+	want := bson.D{{Key: "name", Value: -1}, {Key: "age", Value: 1}}
+	data, _ := bson.Marshal(sort)
+	wantData, _ := bson.Marshal(want)
+	if string(data) != string(wantData) {
+		t.Errorf("Expected %v, got %v", want, sort)
+	}
+}
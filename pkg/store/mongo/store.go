@@ -0,0 +1,261 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// idField is the name of the primary key of MongoDB documents.
+const idField = "_id"
+
+// Option defines a function type for configuring the Store.
+type Option[T any] func(*Store[T])
+
+// WithLogger returns an Option function that sets the provided Logger to the Store for logging purposes.
+func WithLogger[T any](logger store.Logger) Option[T] {
+	return func(s *Store[T]) {
+		s.logger = logger
+	}
+}
+
+// WithSoftDelete returns an Option making Delete set the given time field instead of
+// removing documents. Documents with the field set are then excluded from queries
+// unless the where options are unscoped, like gorm.DeletedAt with the SQL store.
+func WithSoftDelete[T any](field string) Option[T] {
+	return func(s *Store[T]) {
+		s.softDelete = field
+	}
+}
+
+// Store represents a generic data store backed by a MongoDB collection.
+// T is decoded from and encoded to documents with the bson struct tags of its fields,
+// its primary key must be mapped to the _id field.
+type Store[T any] struct {
+	logger     store.Logger
+	collection *mongo.Collection
+	softDelete string
+}
+
+// NewStore creates a new instance of Store operating on collection.
+func NewStore[T any](collection *mongo.Collection, opts ...Option[T]) *Store[T] {
+	s := &Store[T]{
+		logger:     empty.NewLogger(),
+		collection: collection,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create inserts a new object into the collection. When obj has a zero _id, the
+// identifier generated by the driver is set on it.
+func (s *Store[T]) Create(ctx context.Context, obj *T) error {
+	result, err := s.collection.InsertOne(ctx, obj)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to insert object into database", "object", obj)
+		return wrapError(err)
+	}
+	setID(obj, result.InsertedID)
+	return nil
+}
+
+// Update replaces an existing object in the collection, matched by its _id.
+func (s *Store[T]) Update(ctx context.Context, obj *T) error {
+	id, err := idOf(obj)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.collection.ReplaceOne(ctx, bson.D{{Key: idField, Value: id}}, obj); err != nil {
+		s.logger.Error(ctx, err, "Failed to update object in database", "object", obj)
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Delete removes the objects matching the provided where options, or marks them as
+// deleted when the store is configured with WithSoftDelete.
+func (s *Store[T]) Delete(ctx context.Context, opts *where.Options) error {
+	filter, err := s.filter(opts)
+	if err != nil {
+		return err
+	}
+
+	if s.softDelete != "" && (opts == nil || !opts.Unscoped) {
+		_, err = s.collection.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: s.softDelete, Value: time.Now()}}}})
+	} else {
+		_, err = s.collection.DeleteMany(ctx, filter)
+	}
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to delete object from database", "conditions", opts)
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Get retrieves a single object from the collection based on the provided where options.
+// It returns an error matching store.ErrNotFound when no object matches the conditions.
+func (s *Store[T]) Get(ctx context.Context, opts *where.Options) (*T, error) {
+	filter, err := s.filter(opts)
+	if err != nil {
+		return nil, err
+	}
+	findOpts := options.FindOne()
+	if opts != nil {
+		sort, err := Sort(opts.Ordering())
+		if err != nil {
+			return nil, err
+		}
+		findOpts.SetSort(sort).SetSkip(int64(opts.Offset))
+		if len(opts.Columns) > 0 {
+			findOpts.SetProjection(projection(opts.Columns))
+		}
+	}
+
+	var obj T
+	if err := s.collection.FindOne(ctx, filter, findOpts).Decode(&obj); err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object from database", "conditions", opts)
+		return nil, wrapError(err)
+	}
+	return &obj, nil
+}
+
+// List retrieves a list of objects from the collection based on the provided where options,
+// together with the number of objects matching the conditions regardless of pagination,
+// or -1 when opts skips counting with where.NoCount.
+// Objects are sorted by _id in descending order when no order is specified.
+func (s *Store[T]) List(ctx context.Context, opts *where.Options) (count int64, ret []*T, err error) {
+	filter, err := s.filter(opts)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: idField, Value: -1}})
+	if opts != nil {
+		if order := opts.Ordering(); order != "" {
+			sort, err := Sort(order)
+			if err != nil {
+				return 0, nil, err
+			}
+			findOpts.SetSort(sort)
+		}
+		if opts.Offset > 0 {
+			findOpts.SetSkip(int64(opts.Offset))
+		}
+		if opts.Limit > 0 {
+			findOpts.SetLimit(int64(opts.Limit))
+		}
+		if len(opts.Columns) > 0 {
+			findOpts.SetProjection(projection(opts.Columns))
+		}
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, findOpts)
+	if err == nil {
+		err = cursor.All(ctx, &ret)
+	}
+	if err == nil {
+		if opts != nil && opts.SkipCount {
+			count = -1
+		} else {
+			count, err = s.collection.CountDocuments(ctx, filter)
+		}
+	}
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to list objects from database", "conditions", opts)
+		return 0, nil, wrapError(err)
+	}
+	return count, ret, nil
+}
+
+// Count returns the number of objects matching the provided where options.
+func (s *Store[T]) Count(ctx context.Context, opts *where.Options) (int64, error) {
+	filter, err := s.filter(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to count objects in database", "conditions", opts)
+		return 0, wrapError(err)
+	}
+	return count, nil
+}
+
+// projection returns the projection document fetching only the given fields.
+func projection(fields []string) bson.D {
+	doc := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		doc = append(doc, bson.E{Key: field, Value: 1})
+	}
+	return doc
+}
+
+// filter translates opts into a filter document, excluding soft-deleted documents.
+func (s *Store[T]) filter(opts *where.Options) (bson.D, error) {
+	filter, err := Filter(opts)
+	if err != nil {
+		return nil, err
+	}
+	if s.softDelete != "" && (opts == nil || !opts.Unscoped) {
+		filter = append(filter, bson.E{Key: s.softDelete, Value: nil})
+	}
+	return filter, nil
+}
+
+// wrapError attaches the matching store sentinel error to err.
+func wrapError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return fmt.Errorf("%w: %w", store.ErrNotFound, err)
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", store.ErrDuplicateKey, err)
+	}
+	return err
+}
+
+// idOf returns the _id of obj.
+func idOf[T any](obj *T) (any, error) {
+	data, err := bson.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	value, err := bson.Raw(data).LookupErr(idField)
+	if err != nil {
+		return nil, fmt.Errorf("object has no %s field: %w", idField, err)
+	}
+	return value, nil
+}
+
+// setID sets id on the _id field of obj when it is zero and id is assignable to it.
+func setID[T any](obj *T, id any) {
+	rv := reflect.ValueOf(obj).Elem()
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		name, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("bson"), ",")
+		if name != idField {
+			continue
+		}
+		field, value := rv.Field(i), reflect.ValueOf(id)
+		if field.CanSet() && field.IsZero() && value.IsValid() && value.Type().AssignableTo(field.Type()) {
+			field.Set(value)
+		}
+		return
+	}
+}
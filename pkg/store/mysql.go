@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+const (
+	// defaultMaxOpenConns defines the maximum number of open connections of the pool.
+	defaultMaxOpenConns = 100
+	// defaultMaxIdleConns defines the maximum number of idle connections of the pool.
+	defaultMaxIdleConns = 100
+	// defaultConnMaxLifetime defines the maximum amount of time a connection may be reused.
+	defaultConnMaxLifetime = 10 * time.Second
+	// defaultStartupMinBackoff defines the delay before the first connection retry on startup.
+	defaultStartupMinBackoff = 500 * time.Millisecond
+	// defaultStartupMaxBackoff defines the maximum delay between two connection attempts on startup.
+	defaultStartupMaxBackoff = 10 * time.Second
+)
+
+// MySQLOption defines a function type for configuring the MySQLProvider.
+type MySQLOption func(*mysqlOptions)
+
+// mysqlOptions holds the configuration of a MySQLProvider.
+type mysqlOptions struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	tls             *tls.Config
+	startupAttempts int
+	startupBackoff  func(attempt int) time.Duration
+	logger          logger.Interface
+}
+
+// WithMaxOpenConns sets the maximum number of open connections to the database. Defaults to 100.
+func WithMaxOpenConns(n int) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.maxOpenConns = n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of connections in the idle connection pool. Defaults to 100.
+func WithMaxIdleConns(n int) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.maxIdleConns = n
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused. Defaults to 10 seconds.
+func WithConnMaxLifetime(d time.Duration) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.connMaxLifetime = d
+	}
+}
+
+// WithTLS secures the connections with config. It takes precedence over the tls
+// parameter of the DSN.
+func WithTLS(config *tls.Config) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.tls = config
+	}
+}
+
+// WithStartupRetry makes NewMySQLProvider try to connect up to attempts times,
+// so services starting alongside their database wait for it to accept connections.
+// backoff returns the delay before the given retry attempt (starting at 1) and
+// defaults to an exponential backoff from 500ms to 10s.
+func WithStartupRetry(attempts int, backoff func(attempt int) time.Duration) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.startupAttempts = attempts
+		o.startupBackoff = backoff
+	}
+}
+
+// WithGormLogger sets the logger of the gorm database. Defaults to logger.Default.
+func WithGormLogger(l logger.Interface) MySQLOption {
+	return func(o *mysqlOptions) {
+		o.logger = l
+	}
+}
+
+// MySQLProvider is a DBProvider backed by a MySQL database.
+type MySQLProvider struct {
+	db *gorm.DB
+}
+
+var _ DBProvider = (*MySQLProvider)(nil)
+
+// NewMySQLProvider connects to the MySQL database of dsn, such as
+// "user:password@tcp(127.0.0.1:3306)/app?parseTime=true", and configures its
+// connection pool. It fails when the database cannot be reached, after the
+// attempts configured with WithStartupRetry.
+func NewMySQLProvider(dsn string, opts ...MySQLOption) (*MySQLProvider, error) {
+	o := &mysqlOptions{
+		maxOpenConns:    defaultMaxOpenConns,
+		maxIdleConns:    defaultMaxIdleConns,
+		connMaxLifetime: defaultConnMaxLifetime,
+		startupAttempts: 1,
+		logger:          logger.Default,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.startupBackoff == nil {
+		o.startupBackoff = ExponentialBackoff(defaultStartupMinBackoff, defaultStartupMaxBackoff)
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql dsn: %w", err)
+	}
+	if o.tls != nil {
+		cfg.TLS = o.tls
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB := sql.OpenDB(connector)
+	sqlDB.SetMaxOpenConns(o.maxOpenConns)
+	sqlDB.SetMaxIdleConns(o.maxIdleConns)
+	sqlDB.SetConnMaxLifetime(o.connMaxLifetime)
+
+	if err := connect(sqlDB, o.startupAttempts, o.startupBackoff); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sqlDB, DSNConfig: cfg}), &gorm.Config{
+		// PrepareStmt executes the given query in cached statement.
+		// This can improve performance.
+		PrepareStmt: true,
+		Logger:      o.logger,
+	})
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return &MySQLProvider{db: db}, nil
+}
+
+// connect pings sqlDB until it succeeds or attempts are exhausted.
+func connect(sqlDB *sql.DB, attempts int, backoff func(attempt int) time.Duration) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = sqlDB.PingContext(context.Background()); err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("failed to connect to mysql after %d attempts: %w", attempt, err)
+		}
+		time.Sleep(backoff(attempt))
+	}
+}
+
+// DB implements DBProvider.
+func (p *MySQLProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	db := p.db.WithContext(ctx)
+	for _, whr := range wheres {
+		if whr != nil {
+			db = whr.Where(db)
+		}
+	}
+	return db
+}
+
+// Close closes the connection pool.
+func (p *MySQLProvider) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
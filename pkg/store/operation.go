@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithStatementTimeout returns an Option bounding the duration of every store
+// operation to timeout, so that a runaway query cannot hold a connection
+// indefinitely. The deadline is set on the operation context, which makes the
+// driver cancel the query once it expires; the operation then fails with the
+// cancellation error of the driver, usually context.DeadlineExceeded.
+// A deadline already carried by the caller context is kept when it is earlier.
+//
+// Each, Export, DeleteInChunks and Tx are not bounded as a whole, as they legitimately
+// run for long: each operation called within a transaction is bounded on its own.
+func WithStatementTimeout[T any](timeout time.Duration) Option[T] {
+	return func(s *Store[T]) {
+		s.timeout = timeout
+	}
+}
+
+// unboundedOperations lists the operations not subject to the statement timeout.
+var unboundedOperations = map[string]bool{"Each": true, "Export": true, "Tx": true, "DeleteInChunks": true}
+
+// operation holds the span and the deadline of a store operation in progress.
+// A nil operation is a no-op, so operations do not need to check whether tracing,
+// timeouts or the query cache are enabled.
+type operation struct {
+	span   trace.Span
+	cancel context.CancelFunc
+	done   func()
+}
+
+// begin starts the given operation, returning the context it must run with.
+func (s *Store[T]) begin(ctx context.Context, name string) (context.Context, *operation) {
+	bounded := s.timeout > 0 && !unboundedOperations[name]
+	invalidates := s.queryCache != nil && !readOperations[name]
+	if s.tracing == nil && !bounded && !invalidates {
+		return ctx, nil
+	}
+
+	op := &operation{}
+	if invalidates {
+		op.done = func() { s.invalidateQueries(ctx) }
+	}
+	if bounded {
+		ctx, op.cancel = context.WithTimeout(ctx, s.timeout)
+	}
+	if s.tracing != nil {
+		ctx, op.span = s.tracing.startSpan(ctx, reflect.TypeFor[T]().Name(), name)
+	}
+	return ctx, op
+}
+
+// end records the outcome of the operation and releases its resources.
+func (o *operation) end(err error) {
+	if o == nil {
+		return
+	}
+	if o.done != nil {
+		o.done()
+	}
+	if o.span != nil {
+		endSpan(o.span, err)
+	}
+	if o.cancel != nil {
+		o.cancel()
+	}
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithDefaultOrder returns an Option setting the order of the objects returned by List
+// when its where options specify none, such as "created_at desc". It defaults to the
+// primary key in descending order, and to the database order for models without
+// primary key.
+func WithDefaultOrder[T any](order string) Option[T] {
+	return func(s *Store[T]) {
+		s.defaultOrder = order
+	}
+}
+
+// orderByDefault applies the default order of the store to db.
+func (s *Store[T]) orderByDefault(db *gorm.DB) *gorm.DB {
+	if s.defaultOrder != "" {
+		return db.Order(s.defaultOrder)
+	}
+	pk, err := primaryField[T](db)
+	if err != nil {
+		return db
+	}
+	// Qualify the column so that the default order stays unambiguous with joins.
+	return db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Desc: true})
+}
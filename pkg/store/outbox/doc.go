@@ -0,0 +1,4 @@
+// Package outbox implements the transactional outbox pattern on top of the store package.
+// Messages are written to an outbox table in the same transaction as the entity changes,
+// and a relay worker dispatches them to a publisher with at-least-once semantics.
+package outbox // import "github.com/miladystack/miladystack/pkg/store/outbox"
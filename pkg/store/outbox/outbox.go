@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store"
+)
+
+// Message is a domain event stored in the outbox table.
+type Message struct {
+	ID            uint64     `gorm:"primaryKey" json:"id"`
+	Topic         string     `gorm:"size:255;index" json:"topic"`
+	Key           string     `gorm:"size:255" json:"key"`
+	Payload       []byte     `json:"payload"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `gorm:"size:1024" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	PublishedAt   *time.Time `gorm:"index" json:"published_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName returns the name of the outbox table.
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// Migrate creates or updates the outbox table.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Message{})
+}
+
+// Outbox writes messages to the outbox table.
+type Outbox struct {
+	store *store.Store[Message]
+}
+
+// New creates an Outbox writing through the given DBProvider. Use the same provider
+// as the entity stores so that messages join their transactions.
+func New(provider store.DBProvider, logger store.Logger) *Outbox {
+	return &Outbox{store: store.NewStore(provider, store.WithLogger[Message](logger))}
+}
+
+// Add writes a message with a raw payload to the outbox. Call it with the context
+// passed to store.WithTx so that the message is committed or rolled back together
+// with the entity changes.
+func (o *Outbox) Add(ctx context.Context, topic string, key string, payload []byte) error {
+	return o.store.Create(ctx, &Message{
+		Topic:         topic,
+		Key:           key,
+		Payload:       payload,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// AddJSON writes a message with the JSON encoding of payload to the outbox.
+func (o *Outbox) AddJSON(ctx context.Context, topic string, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return o.Add(ctx, topic, key, data)
+}
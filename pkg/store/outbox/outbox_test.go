@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testProvider struct {
+	db *gorm.DB
+}
+
+func (p *testProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return p.db.WithContext(ctx)
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return &testProvider{db: db}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+	box := New(provider, nil)
+
+	// Messages added in a rolled back transaction are never dispatched.
+	_ = store.WithTx(ctx, provider, func(txCtx context.Context) error {
+		if err := box.Add(txCtx, "users", "1", []byte("discarded")); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		return errors.New("rollback")
+	})
+	err := store.WithTx(ctx, provider, func(txCtx context.Context) error {
+		return box.AddJSON(txCtx, "users", "2", map[string]string{"name": "john"})
+	})
+	if err != nil {
+		t.Fatalf("AddJSON failed: %v", err)
+	}
+
+	var published []string
+	fail := true
+	relay := NewRelay(provider, PublisherFunc(func(ctx context.Context, msg *Message) error {
+		if fail {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, string(msg.Payload))
+		return nil
+	}), WithBackoff(func(int) time.Duration { return 0 }))
+
+	if _, err := relay.Process(ctx); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	fail = false
+	if _, err := relay.Process(ctx); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if n, _ := relay.Process(ctx); n != 0 {
+		t.Errorf("Expected no pending message left, got %d", n)
+	}
+
+	if len(published) != 1 || published[0] != `{"name":"john"}` {
+		t.Errorf("Expected the committed message to be published once, got %v", published)
+	}
+}
+
+func TestRelayInvalidOptions(t *testing.T) {
+	provider := newTestProvider(t)
+	box := New(provider, nil)
+	for i := range 3 {
+		if err := box.Add(context.Background(), "users", fmt.Sprint(i), []byte("{}")); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	var published int
+	relay := NewRelay(provider, PublisherFunc(func(ctx context.Context, msg *Message) error {
+		published++
+		return nil
+	}), WithInterval(0), WithBatchSize(0), WithMaxAttempts(-1), WithBackoff(nil))
+
+	// Invalid options fall back to the defaults instead of panicking or spinning.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := relay.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Run to stop with the context, got %v", err)
+	}
+	if published != 3 {
+		t.Errorf("Expected 3 messages published, got %d", published)
+	}
+}
@@ -0,0 +1,213 @@
+package outbox
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 10
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+)
+
+// Publisher dispatches outbox messages to a message broker or any other consumer.
+// Messages may be published more than once, so consumers should be idempotent.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc adapts an ordinary function to the Publisher interface.
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish calls f(ctx, msg).
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// BackoffFunc returns the delay before the given delivery attempt is retried.
+type BackoffFunc func(attempt int) time.Duration
+
+// RelayOption defines a function type for configuring the Relay.
+type RelayOption func(*Relay)
+
+// Relay polls the outbox table and dispatches pending messages to a Publisher.
+type Relay struct {
+	provider    store.DBProvider
+	publisher   Publisher
+	logger      store.Logger
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	backoff     BackoffFunc
+}
+
+// WithInterval sets the delay between two polls of the outbox table. Defaults to one
+// second, which is also used for non-positive intervals.
+func WithInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of messages dispatched per poll. Defaults to
+// 100, which is also used for non-positive sizes.
+func WithBatchSize(batchSize int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = batchSize
+	}
+}
+
+// WithMaxAttempts sets the number of delivery attempts after which a message is given
+// up. Defaults to 10, which is also used for non-positive numbers.
+func WithMaxAttempts(maxAttempts int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = maxAttempts
+	}
+}
+
+// WithBackoff sets the function computing the delay before a failed message is retried.
+func WithBackoff(backoff BackoffFunc) RelayOption {
+	return func(r *Relay) {
+		r.backoff = backoff
+	}
+}
+
+// WithLogger sets the logger used to report delivery failures.
+func WithLogger(logger store.Logger) RelayOption {
+	return func(r *Relay) {
+		r.logger = logger
+	}
+}
+
+// NewRelay creates a Relay reading the outbox table through provider and dispatching
+// messages to publisher.
+func NewRelay(provider store.DBProvider, publisher Publisher, opts ...RelayOption) *Relay {
+	r := &Relay{
+		provider:    provider,
+		publisher:   publisher,
+		logger:      empty.NewLogger(),
+		interval:    defaultInterval,
+		batchSize:   defaultBatchSize,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     ExponentialBackoff(defaultMinBackoff, defaultMaxBackoff),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// Non-positive values would make the ticker panic, the drain loop spin forever or
+	// no message be dispatched at all.
+	if r.interval <= 0 {
+		r.interval = defaultInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultMaxAttempts
+	}
+	if r.backoff == nil {
+		r.backoff = ExponentialBackoff(defaultMinBackoff, defaultMaxBackoff)
+	}
+	return r
+}
+
+// Run polls the outbox table until ctx is canceled.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches are returned.
+		for ctx.Err() == nil {
+			n, err := r.Process(ctx)
+			if err != nil {
+				r.logger.Error(ctx, err, "Failed to process outbox messages")
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process dispatches one batch of pending messages and returns the number of messages handled.
+// Messages are locked while being dispatched (FOR UPDATE SKIP LOCKED where supported),
+// so several relays can run concurrently. A message is marked as published only after
+// the publisher succeeded, which gives at-least-once delivery. Failed messages are
+// retried after a backoff until the maximum number of attempts is reached.
+func (r *Relay) Process(ctx context.Context) (int, error) {
+	var handled int
+	err := r.provider.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var msgs []*Message
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("published_at IS NULL AND attempts < ? AND next_attempt_at <= ?", r.maxAttempts, time.Now()).
+			Order("id").Limit(r.batchSize).Find(&msgs).Error
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if err := r.dispatch(ctx, tx, msg); err != nil {
+				return err
+			}
+			handled++
+		}
+		return nil
+	})
+	return handled, err
+}
+
+// dispatch publishes msg and records the outcome of the delivery attempt.
+func (r *Relay) dispatch(ctx context.Context, tx *gorm.DB, msg *Message) error {
+	now := time.Now()
+	fields := map[string]any{"attempts": msg.Attempts + 1}
+
+	if err := r.publisher.Publish(ctx, msg); err != nil {
+		r.logger.Error(ctx, err, "Failed to publish outbox message", "id", msg.ID, "topic", msg.Topic, "attempt", msg.Attempts+1)
+		fields["last_error"] = truncate(err.Error(), 1024)
+		fields["next_attempt_at"] = now.Add(r.backoff(msg.Attempts + 1))
+	} else {
+		fields["published_at"] = now
+		fields["last_error"] = ""
+	}
+
+	return tx.Model(&Message{}).Where("id = ?", msg.ID).Updates(fields).Error
+}
+
+// ExponentialBackoff returns a BackoffFunc doubling the delay at every attempt,
+// starting from minDelay and capped at maxDelay, with up to 20% random jitter.
+func ExponentialBackoff(minDelay, maxDelay time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		delay := minDelay
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, maxDelay)
+		return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+	}
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
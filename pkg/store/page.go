@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ListResult holds a page of objects along with the pagination metadata, ready
+// to be serialized as the pagination envelope of an API response.
+type ListResult[T any] struct {
+	// Items holds the objects of the page.
+	Items []*T `json:"items"`
+	// TotalCount is the number of objects matching the conditions, across all pages,
+	// or -1 when the listing skipped counting with where.NoCount.
+	TotalCount int64 `json:"totalCount"`
+	// Page is the 1-based number of the page.
+	Page int `json:"page"`
+	// PageSize is the maximum number of objects per page.
+	PageSize int `json:"pageSize"`
+	// TotalPages is the number of pages needed to list all the matching objects,
+	// or -1 when the total count is unknown.
+	TotalPages int `json:"totalPages"`
+	// HasNext reports whether there are objects after this page. Without a total
+	// count, it is assumed whenever the page is full.
+	HasNext bool `json:"hasNext"`
+}
+
+// NewListResult builds the ListResult of the items returned by a List call with opts,
+// out of count matching objects. Pages are derived from the offset and limit of opts,
+// an unlimited listing is a single page holding every object.
+func NewListResult[T any](opts *where.Options, count int64, items []*T) *ListResult[T] {
+	var offset, limit int
+	if opts != nil {
+		offset, limit = opts.Offset, opts.Limit
+	}
+	if items == nil {
+		items = []*T{}
+	}
+
+	ret := &ListResult[T]{Items: items, TotalCount: count, Page: 1, PageSize: limit}
+	if limit > 0 {
+		ret.Page = offset/limit + 1
+	} else {
+		ret.PageSize = len(items)
+	}
+
+	switch {
+	case count < 0:
+		ret.TotalPages = -1
+		ret.HasNext = limit > 0 && len(items) == limit
+	case limit <= 0:
+		ret.TotalPages = min(int(count), 1)
+		ret.HasNext = int64(offset+len(items)) < count
+	default:
+		ret.TotalPages = int((count + int64(limit) - 1) / int64(limit))
+		ret.HasNext = int64(offset+len(items)) < count
+	}
+	return ret
+}
+
+// ListPage lists the objects matching opts from s and returns them as a ListResult.
+// Set the page with where.P, e.g. ListPage(ctx, s, where.P(2, 20)).
+func ListPage[T any](ctx context.Context, s IStore[T], opts *where.Options) (*ListResult[T], error) {
+	count, items, err := s.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewListResult(opts, count, items), nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ListInto retrieves the objects of s matching opts like List, but hydrates them into
+// the lighter struct D rather than the model of the store, such as the response type
+// of an API handler:
+//
+//	type UserSummary struct {
+//		ID   uint64
+//		Name string
+//	}
+//
+//	count, users, err := store.ListInto[UserSummary](ctx, userStore, where.F("active", true))
+//
+// Only the columns of the fields of D are selected, matched to the columns of the
+// model by name, unless opts selects columns with where.Select. Fields of D without
+// matching column are left zero.
+func ListInto[D, T any](ctx context.Context, s *Store[T], opts *where.Options) (count int64, ret []*D, err error) {
+	ctx, op := s.begin(ctx, "ListInto")
+	defer func() { op.end(err) }()
+
+	name := "ListInto:" + reflect.TypeFor[D]().String()
+	page, err := cachedQuery(ctx, s, name, opts, func() (listPage[D], error) {
+		return listRows[T, D](ctx, s, opts)
+	}, func(page listPage[D]) listPage[D] {
+		return listPage[D]{count: page.count, objs: cloneObjects(page.objs)}
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to list objects from database", "conditions", opts)
+		return 0, nil, wrapError(err)
+	}
+	return page.count, page.objs, nil
+}
+
+// projectedColumns returns the columns of the model T matching the fields of D.
+func projectedColumns[T, D any](db *gorm.DB) ([]string, error) {
+	model, err := schemaOf[T](db)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := schemaOf[D](db)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, name := range projection.DBNames {
+		if _, ok := model.FieldsByDBName[name]; ok {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no field matching a column of model %s", projection.Name, model.Name)
+	}
+	return columns, nil
+}
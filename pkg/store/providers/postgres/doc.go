@@ -0,0 +1,2 @@
+// Package postgres provides a ready-made store.DBProvider backed by PostgreSQL.
+package postgres // import "github.com/miladystack/miladystack/pkg/store/providers/postgres"
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// Options defines options for the PostgreSQL provider.
+type Options struct {
+	// Addr is the server address as host[:port]. Defaults to 127.0.0.1:5432.
+	Addr     string
+	Username string
+	Password string
+	Database string
+	// SSLMode is the libpq sslmode, such as disable, require or verify-full. Defaults to disable.
+	SSLMode string
+	// TimeZone is the session time zone. The server default is used when empty.
+	TimeZone string
+	// SearchPath lists the schemas searched for unqualified table names.
+	SearchPath []string
+	// StatementTimeout aborts statements running longer than the timeout. Disabled when zero.
+	StatementTimeout time.Duration
+	// ConnectTimeout bounds the time spent establishing a connection. Defaults to 10 seconds.
+	ConnectTimeout time.Duration
+	// ApplicationName is reported in pg_stat_activity.
+	ApplicationName       string
+	MaxIdleConnections    int
+	MaxOpenConnections    int
+	MaxConnectionLifeTime time.Duration
+	// +optional
+	Logger logger.Interface
+}
+
+// DSN returns the keyword/value connection string built from the options.
+// Settings such as statement_timeout and search_path are sent as runtime parameters
+// when the connection is established, so they apply to every pooled connection.
+func (o *Options) DSN() string {
+	host, port, err := net.SplitHostPort(o.Addr)
+	if err != nil {
+		host, port = o.Addr, "5432"
+	}
+
+	params := [][2]string{
+		{"host", host},
+		{"port", port},
+		{"user", o.Username},
+		{"password", o.Password},
+		{"dbname", o.Database},
+		{"sslmode", o.SSLMode},
+		{"connect_timeout", fmt.Sprint(int(o.ConnectTimeout.Seconds()))},
+	}
+	if o.TimeZone != "" {
+		params = append(params, [2]string{"TimeZone", o.TimeZone})
+	}
+	if len(o.SearchPath) > 0 {
+		params = append(params, [2]string{"search_path", strings.Join(o.SearchPath, ",")})
+	}
+	if o.StatementTimeout > 0 {
+		params = append(params, [2]string{"statement_timeout", fmt.Sprint(o.StatementTimeout.Milliseconds())})
+	}
+	if o.ApplicationName != "" {
+		params = append(params, [2]string{"application_name", o.ApplicationName})
+	}
+
+	pairs := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] != "" {
+			pairs = append(pairs, param[0]+"="+quote(param[1]))
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// quote quotes a connection string value when it contains spaces or quotes.
+func quote(value string) string {
+	if !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
+
+// Provider is a store.DBProvider backed by a PostgreSQL database.
+type Provider struct {
+	db *gorm.DB
+}
+
+var _ store.DBProvider = (*Provider)(nil)
+
+// New connects to the PostgreSQL database described by opts.
+func New(opts *Options) (*Provider, error) {
+	// Set default values to ensure all fields in opts are available.
+	setDefaults(opts)
+
+	db, err := gorm.Open(postgres.Open(opts.DSN()), &gorm.Config{
+		// PrepareStmt executes the given query in cached statement.
+		// This can improve performance.
+		PrepareStmt: true,
+		Logger:      opts.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// SetMaxOpenConns sets the maximum number of open connections to the database.
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConnections)
+
+	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
+	sqlDB.SetConnMaxLifetime(opts.MaxConnectionLifeTime)
+
+	// SetMaxIdleConns sets the maximum number of connections in the idle connection pool.
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConnections)
+
+	return &Provider{db: db}, nil
+}
+
+// DB returns the database instance for the given context with the where conditions applied.
+func (p *Provider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	db := p.db.WithContext(ctx)
+	for _, whr := range wheres {
+		if whr != nil {
+			db = whr.Where(db)
+		}
+	}
+	return db
+}
+
+// Close closes the connection pool.
+func (p *Provider) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// setDefaults set available default values for some fields.
+func setDefaults(opts *Options) {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:5432"
+	}
+	if opts.SSLMode == "" {
+		opts.SSLMode = "disable"
+	}
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	if opts.MaxIdleConnections == 0 {
+		opts.MaxIdleConnections = 100
+	}
+	if opts.MaxOpenConnections == 0 {
+		opts.MaxOpenConnections = 100
+	}
+	if opts.MaxConnectionLifeTime == 0 {
+		opts.MaxConnectionLifeTime = 10 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = logger.Default
+	}
+}
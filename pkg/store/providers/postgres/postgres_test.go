@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	opts := &Options{
+		Addr:             "db.local",
+		Username:         "app",
+		Password:         "it's secret",
+		Database:         "orders",
+		SearchPath:       []string{"tenant_a", "public"},
+		StatementTimeout: 3 * time.Second,
+	}
+	setDefaults(opts)
+
+	want := `host=db.local port=5432 user=app password='it\'s secret' dbname=orders sslmode=disable ` +
+		`connect_timeout=10 search_path=tenant_a,public statement_timeout=3000`
+	if got := opts.DSN(); got != want {
+		t.Errorf("DSN mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
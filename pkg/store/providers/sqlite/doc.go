@@ -0,0 +1,3 @@
+// Package sqlite provides a ready-made store.DBProvider backed by SQLite, for unit
+// tests and small tools that do not need a database server.
+package sqlite // import "github.com/miladystack/miladystack/pkg/store/providers/sqlite"
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// Memory is the path of a private in-memory database.
+const Memory = ":memory:"
+
+// Options defines options for the SQLite provider.
+type Options struct {
+	// Path is the database file path, or Memory for an in-memory database.
+	Path string
+	// BusyTimeout is how long a connection waits for a lock held by another connection.
+	BusyTimeout time.Duration
+	// JournalMode is the journal mode of file databases. Defaults to WAL, which lets
+	// readers run concurrently with a writer.
+	JournalMode string
+	// DisableForeignKeys disables the enforcement of foreign key constraints.
+	DisableForeignKeys bool
+	// MaxOpenConnections is the maximum number of open connections. In-memory
+	// databases always use a single connection, as each connection has its own database.
+	MaxOpenConnections int
+	// +optional
+	Logger logger.Interface
+}
+
+// DSN returns the data source name, carrying the pragmas of the options.
+func (o *Options) DSN() string {
+	params := url.Values{}
+	params.Set("_busy_timeout", fmt.Sprint(o.BusyTimeout.Milliseconds()))
+	params.Set("_foreign_keys", fmt.Sprint(!o.DisableForeignKeys))
+	if o.Path != Memory {
+		params.Set("_journal_mode", o.JournalMode)
+		params.Set("_synchronous", "NORMAL")
+	}
+
+	separator := "?"
+	if strings.Contains(o.Path, "?") {
+		separator = "&"
+	}
+	return o.Path + separator + params.Encode()
+}
+
+// Provider is a store.DBProvider backed by a SQLite database.
+type Provider struct {
+	db *gorm.DB
+}
+
+var _ store.DBProvider = (*Provider)(nil)
+
+// New opens the SQLite database described by opts.
+func New(opts *Options) (*Provider, error) {
+	// Set default values to ensure all fields in opts are available.
+	setDefaults(opts)
+
+	db, err := gorm.Open(sqlite.Open(opts.DSN()), &gorm.Config{Logger: opts.Logger})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConnections)
+	if opts.Path == Memory {
+		// The in-memory database is dropped with its connection, keep it open.
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+
+	return &Provider{db: db}, nil
+}
+
+// NewInMemory opens a private in-memory database with the default options.
+func NewInMemory() (*Provider, error) {
+	return New(&Options{Path: Memory, Logger: logger.Discard})
+}
+
+// DB returns the database instance for the given context with the where conditions applied.
+func (p *Provider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	db := p.db.WithContext(ctx)
+	for _, whr := range wheres {
+		if whr != nil {
+			db = whr.Where(db)
+		}
+	}
+	return db
+}
+
+// Close closes the database.
+func (p *Provider) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// setDefaults set available default values for some fields.
+func setDefaults(opts *Options) {
+	if opts.Path == "" {
+		opts.Path = Memory
+	}
+	if opts.BusyTimeout == 0 {
+		opts.BusyTimeout = 5 * time.Second
+	}
+	if opts.JournalMode == "" {
+		opts.JournalMode = "WAL"
+	}
+	if opts.MaxOpenConnections == 0 || opts.Path == Memory {
+		// SQLite serializes writes, a single connection avoids lock contention.
+		opts.MaxOpenConnections = 1
+	}
+	if opts.Logger == nil {
+		opts.Logger = logger.Default
+	}
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type item struct {
+	ID   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+func TestProvider(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"memory": {Path: Memory},
+		"file":   {Path: filepath.Join(t.TempDir(), "test.db")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider, err := New(opts)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			defer provider.Close()
+
+			ctx := context.Background()
+			if err := provider.DB(ctx).AutoMigrate(&item{}); err != nil {
+				t.Fatalf("Failed to migrate: %v", err)
+			}
+
+			s := store.NewStore[item](provider)
+			if err := s.Create(ctx, &item{Name: "a"}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			got, err := s.Get(ctx, where.F("name", "a"))
+			if err != nil || got.ID == 0 {
+				t.Errorf("Expected stored item, got %+v, %v", got, err)
+			}
+
+			var mode string
+			provider.DB(ctx).Raw("PRAGMA foreign_keys").Scan(&mode)
+			if mode != "1" {
+				t.Errorf("Expected foreign keys to be enforced, got %q", mode)
+			}
+		})
+	}
+}
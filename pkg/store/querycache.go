@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// readOperations lists the operations that do not invalidate the query cache.
+// RawQuery is not one of them, as it may run statements modifying data, such as
+// UPDATE ... RETURNING. Tx does not invalidate the cache itself: the writes made
+// within the transaction, including Exec, invalidate it once it is committed.
+var readOperations = map[string]bool{
+	"Get": true, "GetOrNil": true, "GetMany": true, "List": true, "ListInto": true, "ListByCursor": true, "Count": true,
+	"Exists": true, "Each": true, "Pluck": true, "Aggregate": true, "GroupBy": true, "Ping": true, "Tx": true,
+	"History": true, "Export": true,
+}
+
+// WithQueryCache returns an Option caching the results of Get, List and Count in
+// memory for ttl, keyed by the generated SQL statement and its arguments, so that
+// bursts of identical reads are served without querying the database. Concurrent
+// identical reads missing the cache are collapsed into a single query, whose result
+// is shared with every caller.
+//
+// Every write made through the store, including the raw statements run with Exec or
+// RawQuery, clears the cache, once the enclosing transaction is committed. Writes made
+// by other stores or processes are only picked up once the entries expire, so ttl
+// should be short, such as a second. Reads within transactions and locking reads are
+// never cached. Cached objects are shallow copies: associations loaded with
+// where.Preload are shared between callers and must not be modified.
+func WithQueryCache[T any](ttl time.Duration) Option[T] {
+	return func(s *Store[T]) {
+		s.queryCache = &queryCache{ttl: ttl, entries: make(map[string]queryEntry)}
+	}
+}
+
+// queryCache caches the results of the read operations of a store.
+type queryCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]queryEntry
+	generation uint64
+	swept      time.Time
+}
+
+// queryEntry is a cached result with its expiration time.
+type queryEntry struct {
+	value   any
+	expires time.Time
+}
+
+// do returns the result cached under key, or calls query once for all the concurrent
+// callers and caches its result unless the cache was cleared in the meantime.
+func (c *queryCache) do(key string, query func() (any, error)) (any, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	// Queries started before the cache was cleared are not joined by later callers.
+	value, err, _ := c.group.Do(strconv.FormatUint(generation, 10)+":"+key, func() (any, error) {
+		value, err := query()
+		if err == nil {
+			c.set(key, value, generation)
+		}
+		return value, err
+	})
+	return value, err
+}
+
+// set caches value under key, unless the cache was cleared since generation.
+func (c *queryCache) set(key string, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	now := time.Now()
+	if now.Sub(c.swept) > c.ttl {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.swept = now
+	}
+	c.entries[key] = queryEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// clear removes all the cached results.
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.generation++
+}
+
+// invalidateQueries clears the query cache after a write operation, now and once the
+// enclosing transaction, if any, has been committed.
+func (s *Store[T]) invalidateQueries(ctx context.Context) {
+	s.queryCache.clear()
+	if _, ok := txFromContext(ctx, s.storage); ok {
+		afterCommit(ctx, s.storage, s.queryCache.clear)
+	}
+}
+
+// queryKey returns the key of the read operation name for opts in the query cache,
+// built from the statement it generates, or false when the operation is not cacheable.
+func (s *Store[T]) queryKey(ctx context.Context, name string, opts *where.Options) (string, bool) {
+	if s.queryCache == nil || IsDryRun(ctx) || (opts != nil && opts.Locking != "") {
+		return "", false
+	}
+	// Transactions read their own uncommitted writes, which must not be cached.
+	if _, ok := txFromContext(ctx, s.storage); ok {
+		return "", false
+	}
+
+	db := s.reader(ctx, opts).Session(&gorm.Session{DryRun: true, Logger: gormlogger.Discard}).Find(new([]*T))
+	if db.Error != nil {
+		return "", false
+	}
+	key := name + ":" + db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
+
+	// Associations are loaded by separate statements, missing from the one above.
+	// Functions, such as the scopes of a preload, cannot be told apart.
+	if opts != nil {
+		for _, preload := range opts.Preloads {
+			for _, arg := range preload.Args {
+				if arg != nil && reflect.TypeOf(arg).Kind() == reflect.Func {
+					return "", false
+				}
+			}
+			key += fmt.Sprintf(" PRELOAD %s %v", preload.Name, preload.Args)
+		}
+	}
+	return key, true
+}
+
+// cachedQuery runs query, the read operation name of s for opts, through the query
+// cache of s, if any. Results are cloned, so that callers cannot modify cached ones.
+func cachedQuery[T, V any](ctx context.Context, s *Store[T], name string, opts *where.Options, query func() (V, error), clone func(V) V) (V, error) {
+	key, ok := s.queryKey(ctx, name, opts)
+	if !ok {
+		return query()
+	}
+
+	value, err := s.queryCache.do(key, func() (any, error) { return query() })
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return clone(value.(V)), nil
+}
+
+// cloneObjects returns shallow copies of objs.
+func cloneObjects[T any](objs []*T) []*T {
+	ret := make([]*T, len(objs))
+	for i, obj := range objs {
+		copied := *obj
+		ret[i] = &copied
+	}
+	return ret
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+)
+
+// RawQuery runs a raw SQL query and scans its result into dest, which can be a
+// pointer to a struct, a slice of structs or a scalar, for the queries the where
+// options cannot express. Placeholders are written as ? and replaced by args,
+// named arguments are supported with sql.Named.
+//
+// The query is logged and traced like any other operation and joins the transaction
+// carried by ctx. As a read, it may be served by a replica: use WithPrimary for
+// queries that modify data, such as UPDATE ... RETURNING. Tenancy is not applied,
+// the query must filter on the tenant column itself.
+func (s *Store[T]) RawQuery(ctx context.Context, dest any, sql string, args ...any) (err error) {
+	ctx, op := s.begin(ctx, "RawQuery")
+	defer func() { op.end(err) }()
+
+	if err := s.retry(ctx, func() error { return s.reader(ctx).Raw(sql, args...).Scan(dest).Error }); err != nil {
+		s.logger.Error(ctx, err, "Failed to run raw query", "sql", sql)
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Exec runs a raw SQL statement and returns the number of rows affected, for the
+// statements the store operations cannot express.
+//
+// The statement is logged and traced like any other operation and joins the
+// transaction carried by ctx. Unlike RawQuery it is never retried, as statements may
+// not be idempotent. Tenancy is not applied, hooks are not run and no change event
+// is published.
+func (s *Store[T]) Exec(ctx context.Context, sql string, args ...any) (affected int64, err error) {
+	ctx, op := s.begin(ctx, "Exec")
+	defer func() { op.end(err) }()
+
+	result := s.db(ctx).Exec(sql, args...)
+	if result.Error != nil {
+		s.logger.Error(ctx, result.Error, "Failed to execute raw statement", "sql", sql)
+		return 0, wrapError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// ReadOnlyStore is a store of T objects exposing the read operations only, for models
+// mapped to database views or reporting tables, such as denormalized read models.
+// Writes do not compile, rather than failing at runtime:
+//
+//	type OrderSummary struct { ... }
+//
+//	func (OrderSummary) TableName() string { return "order_summaries_view" }
+//
+//	summaries := store.NewReadOnlyStore[OrderSummary](provider)
+//	_, rows, err := summaries.List(ctx, where.F("customer_id", id))
+type ReadOnlyStore[T any] struct {
+	store *Store[T]
+}
+
+// NewReadOnlyStore creates a ReadOnlyStore with the provided DBProvider, configured
+// by opts like a Store.
+func NewReadOnlyStore[T any](storage DBProvider, opts ...Option[T]) *ReadOnlyStore[T] {
+	return &ReadOnlyStore[T]{store: NewStore(storage, opts...)}
+}
+
+// Get retrieves a single object matching opts, see Store.Get.
+func (r *ReadOnlyStore[T]) Get(ctx context.Context, opts *where.Options) (*T, error) {
+	return r.store.Get(ctx, opts)
+}
+
+// GetOrNil retrieves a single object matching opts, or nil when none matches, see Store.GetOrNil.
+func (r *ReadOnlyStore[T]) GetOrNil(ctx context.Context, opts *where.Options) (*T, error) {
+	return r.store.GetOrNil(ctx, opts)
+}
+
+// GetMany retrieves the objects whose primary key is in ids, see Store.GetMany.
+func (r *ReadOnlyStore[T]) GetMany(ctx context.Context, ids []any) ([]*T, error) {
+	return r.store.GetMany(ctx, ids)
+}
+
+// GetByKey retrieves the object identified by key, see Store.GetByKey.
+func (r *ReadOnlyStore[T]) GetByKey(ctx context.Context, key map[string]any) (*T, error) {
+	return r.store.GetByKey(ctx, key)
+}
+
+// List retrieves the objects matching opts with their count, see Store.List.
+func (r *ReadOnlyStore[T]) List(ctx context.Context, opts *where.Options) (int64, []*T, error) {
+	return r.store.List(ctx, opts)
+}
+
+// ListByCursor retrieves a page of the objects matching opts after cursor, see Store.ListByCursor.
+func (r *ReadOnlyStore[T]) ListByCursor(ctx context.Context, opts *where.Options, cursor string, limit int) ([]*T, string, error) {
+	return r.store.ListByCursor(ctx, opts, cursor, limit)
+}
+
+// Count returns the number of objects matching opts, see Store.Count.
+func (r *ReadOnlyStore[T]) Count(ctx context.Context, opts *where.Options) (int64, error) {
+	return r.store.Count(ctx, opts)
+}
+
+// Exists reports whether at least one object matches opts, see Store.Exists.
+func (r *ReadOnlyStore[T]) Exists(ctx context.Context, opts *where.Options) (bool, error) {
+	return r.store.Exists(ctx, opts)
+}
+
+// Each calls fn for every batch of the objects matching opts, see Store.Each.
+func (r *ReadOnlyStore[T]) Each(ctx context.Context, opts *where.Options, batchSize int, fn func([]*T) error) error {
+	return r.store.Each(ctx, opts, batchSize, fn)
+}
+
+// Pluck scans a single column of the objects matching opts into dest, see Store.Pluck.
+func (r *ReadOnlyStore[T]) Pluck(ctx context.Context, column string, dest any, opts *where.Options) error {
+	return r.store.Pluck(ctx, column, dest, opts)
+}
+
+// Aggregate applies agg to column over the objects matching opts, see Store.Aggregate.
+func (r *ReadOnlyStore[T]) Aggregate(ctx context.Context, opts *where.Options, agg Agg, column string) (float64, error) {
+	return r.store.Aggregate(ctx, opts, agg, column)
+}
+
+// GroupBy scans the groups of the objects matching opts into dest, see Store.GroupBy.
+func (r *ReadOnlyStore[T]) GroupBy(ctx context.Context, opts *where.Options, groupColumns []string, selects []string, dest any) error {
+	return r.store.GroupBy(ctx, opts, groupColumns, selects, dest)
+}
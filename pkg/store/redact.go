@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// redactedValue replaces the masked values in the logs.
+const redactedValue = "[REDACTED]"
+
+// redactedField is a field of the model tagged for redaction.
+type redactedField struct {
+	index []int
+	mask  bool
+}
+
+// redactor removes the sensitive fields of T from the objects and conditions passed
+// to the store Logger. Fields are tagged for redaction with the log tag:
+//
+//	Password string `log:"-"`    // omitted, logged as its zero value
+//	Email    string `log:"mask"` // masked, logged as [REDACTED]
+//
+// Conditions on the columns of these fields have their values masked.
+type redactor[T any] struct {
+	fields  []redactedField
+	columns map[string]bool
+}
+
+// newRedactor returns the redactor of T, or nil when T has no field to redact.
+func newRedactor[T any]() *redactor[T] {
+	r := &redactor[T]{columns: make(map[string]bool)}
+	r.collect(reflect.TypeFor[T](), nil)
+	if len(r.fields) == 0 {
+		return nil
+	}
+
+	// Resolve the columns of the redacted fields with the default naming strategy,
+	// which covers the columns named after the fields and the explicit column tags.
+	if sch, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{}); err == nil {
+		for _, field := range sch.Fields {
+			if slices.ContainsFunc(r.fields, func(f redactedField) bool { return slices.Equal(f.index, field.StructField.Index) }) {
+				r.columns[field.DBName] = true
+			}
+		}
+	}
+	return r
+}
+
+// collect records the tagged fields of typ, descending into embedded structs.
+// Fields reached through pointers are skipped, as redacting them would modify the
+// object shared with the caller.
+func (r *redactor[T]) collect(typ reflect.Type, index []int) {
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		path := append(slices.Clip(index), i)
+		switch tag := field.Tag.Get("log"); {
+		case tag == "-" || tag == "mask":
+			r.fields = append(r.fields, redactedField{index: path, mask: tag == "mask"})
+		case field.Anonymous:
+			r.collect(field.Type, path)
+		}
+	}
+}
+
+// value returns v with its sensitive data redacted, when v is an object or a condition of the store.
+func (r *redactor[T]) value(v any) any {
+	switch v := v.(type) {
+	case *T:
+		if v == nil {
+			return v
+		}
+		return r.object(*v)
+	case T:
+		return *r.object(v)
+	case []*T:
+		objs := make([]*T, len(v))
+		for i, obj := range v {
+			objs[i] = r.value(obj).(*T)
+		}
+		return objs
+	case *where.Options:
+		return r.conditions(v)
+	case []where.Where:
+		wheres := slices.Clone(v)
+		for i, whr := range wheres {
+			if opts, ok := whr.(*where.Options); ok {
+				wheres[i] = r.conditions(opts)
+			}
+		}
+		return wheres
+	}
+	return v
+}
+
+// object returns a copy of obj with its tagged fields redacted.
+func (r *redactor[T]) object(obj T) *T {
+	rv := reflect.ValueOf(&obj).Elem()
+	for _, f := range r.fields {
+		field := rv.FieldByIndex(f.index)
+		if !field.CanSet() {
+			continue
+		}
+		if f.mask && field.Kind() == reflect.String && field.Len() > 0 {
+			field.SetString(redactedValue)
+		} else {
+			field.SetZero()
+		}
+	}
+	return &obj
+}
+
+// conditions returns a copy of opts with the values compared to redacted columns masked.
+func (r *redactor[T]) conditions(opts *where.Options) *where.Options {
+	if opts == nil {
+		return nil
+	}
+
+	redacted := *opts
+	redacted.Filters = maps.Clone(opts.Filters)
+	for key := range redacted.Filters {
+		if r.redacted(key) {
+			redacted.Filters[key] = redactedValue
+		}
+	}
+
+	redacted.Clauses = slices.Clone(opts.Clauses)
+	for i, expr := range redacted.Clauses {
+		switch e := expr.(type) {
+		case clause.Eq:
+			if r.redacted(e.Column) {
+				e.Value = redactedValue
+			}
+			redacted.Clauses[i] = e
+		case clause.Neq:
+			if r.redacted(e.Column) {
+				e.Value = redactedValue
+			}
+			redacted.Clauses[i] = e
+		case clause.Like:
+			if r.redacted(e.Column) {
+				e.Value = redactedValue
+			}
+			redacted.Clauses[i] = e
+		case clause.IN:
+			if r.redacted(e.Column) {
+				e.Values = []any{redactedValue}
+			}
+			redacted.Clauses[i] = e
+		}
+	}
+	return &redacted
+}
+
+// redacted reports whether column, given as a name or a clause.Column, is redacted.
+func (r *redactor[T]) redacted(column any) bool {
+	var name string
+	switch c := column.(type) {
+	case string:
+		name = c
+	case clause.Column:
+		name = c.Name
+	default:
+		return false
+	}
+	// Accept table qualified names, such as users.email.
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return r.columns[name]
+}
+
+// redactingLogger is a Logger redacting the objects and conditions of T logged by the store.
+type redactingLogger[T any] struct {
+	Logger
+	redactor *redactor[T]
+}
+
+// redactLogger wraps logger with the redaction of T, when T has fields to redact.
+func redactLogger[T any](logger Logger) Logger {
+	r := newRedactor[T]()
+	if r == nil {
+		return logger
+	}
+	return &redactingLogger[T]{Logger: logger, redactor: r}
+}
+
+// Error implements Logger.
+func (l *redactingLogger[T]) Error(ctx context.Context, err error, message string, kvs ...any) {
+	l.Logger.Error(ctx, err, message, l.redact(kvs)...)
+}
+
+// Warn implements WarnLogger.
+func (l *redactingLogger[T]) Warn(ctx context.Context, message string, kvs ...any) {
+	warn(ctx, l.Logger, message, l.redact(kvs)...)
+}
+
+// redact returns a copy of kvs with its values redacted.
+func (l *redactingLogger[T]) redact(kvs []any) []any {
+	redacted := slices.Clone(kvs)
+	for i := 1; i < len(redacted); i += 2 {
+		redacted[i] = l.redactor.value(redacted[i])
+	}
+	return redacted
+}
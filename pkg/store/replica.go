@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type (
+	// readKey marks a context used by a read-only store operation.
+	readKey struct{}
+	// primaryKey marks a context whose reads must be served by the primary.
+	primaryKey struct{}
+	// stickyKey holds the flag set once a write went through a context.
+	stickyKey struct{}
+)
+
+// IsReadOnly reports whether ctx is used by a read-only store operation (Get, List,
+// Count, Exists, ...) that may be served by a replica.
+// DBProvider implementations can use it to route queries.
+func IsReadOnly(ctx context.Context) bool {
+	if ctx.Value(readKey{}) == nil || ctx.Value(primaryKey{}) != nil {
+		return false
+	}
+	if written, ok := ctx.Value(stickyKey{}).(*atomic.Bool); ok && written.Load() {
+		return false
+	}
+	return true
+}
+
+// WithPrimary returns a context whose reads are always served by the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, struct{}{})
+}
+
+// WithReadYourWrites returns a context whose reads are served by the primary once
+// a write went through it, so that a request always sees its own changes despite replication lag.
+// It is typically installed by a middleware at the beginning of each request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, new(atomic.Bool))
+}
+
+// withRead marks ctx as used by a read-only operation.
+func withRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readKey{}, struct{}{})
+}
+
+// markWritten records that a write went through ctx.
+func markWritten(ctx context.Context) {
+	if written, ok := ctx.Value(stickyKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// ReplicaPolicy selects the replica serving a read.
+type ReplicaPolicy interface {
+	// Pick returns the index of the replica to use among n replicas.
+	Pick(n int) int
+}
+
+// LatencyObserver is implemented by policies relying on the measured latency of replicas.
+type LatencyObserver interface {
+	// Observe records the latency measured for the replica at the given index.
+	Observe(replica int, latency time.Duration)
+}
+
+// roundRobin distributes reads evenly across replicas.
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+// RoundRobin returns a ReplicaPolicy distributing reads evenly across replicas.
+func RoundRobin() ReplicaPolicy {
+	return &roundRobin{}
+}
+
+// Pick implements ReplicaPolicy.
+func (p *roundRobin) Pick(n int) int {
+	return int((p.next.Add(1) - 1) % uint64(n))
+}
+
+// leastLatency sends reads to the replica with the lowest average latency.
+type leastLatency struct {
+	mu        sync.RWMutex
+	latencies []float64
+}
+
+// LeastLatency returns a ReplicaPolicy sending reads to the replica with the lowest
+// exponentially weighted moving average latency, as measured by ReplicaProvider.Probe.
+// Replicas that have not been measured yet are preferred so that all get probed.
+func LeastLatency() ReplicaPolicy {
+	return &leastLatency{}
+}
+
+// Pick implements ReplicaPolicy.
+func (p *leastLatency) Pick(n int) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	best, bestLatency := 0, math.MaxFloat64
+	for i := 0; i < n; i++ {
+		latency := 0.0
+		if i < len(p.latencies) {
+			latency = p.latencies[i]
+		}
+		if latency < bestLatency {
+			best, bestLatency = i, latency
+		}
+	}
+	return best
+}
+
+// Observe implements LatencyObserver.
+func (p *leastLatency) Observe(replica int, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.latencies) <= replica {
+		p.latencies = append(p.latencies, 0)
+	}
+	if p.latencies[replica] == 0 {
+		p.latencies[replica] = float64(latency)
+		return
+	}
+	// EWMA with a smoothing factor of 0.2.
+	p.latencies[replica] = 0.8*p.latencies[replica] + 0.2*float64(latency)
+}
+
+// ReplicaOption defines a function type for configuring the ReplicaProvider.
+type ReplicaOption func(*ReplicaProvider)
+
+// WithReplicaPolicy sets the policy selecting the replica serving a read.
+func WithReplicaPolicy(policy ReplicaPolicy) ReplicaOption {
+	return func(p *ReplicaProvider) {
+		p.policy = policy
+	}
+}
+
+// ReplicaProvider is a DBProvider splitting reads and writes: read-only store
+// operations are served by the replicas, everything else, including transactions,
+// by the primary. Use WithPrimary or WithReadYourWrites to read from the primary.
+type ReplicaProvider struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	policy   ReplicaPolicy
+}
+
+var _ DBProvider = (*ReplicaProvider)(nil)
+
+// NewReplicaProvider creates a ReplicaProvider. Reads are distributed round-robin
+// unless another policy is configured. Without replicas all queries go to the primary.
+func NewReplicaProvider(primary *gorm.DB, replicas []*gorm.DB, opts ...ReplicaOption) *ReplicaProvider {
+	p := &ReplicaProvider{
+		primary:  primary,
+		replicas: replicas,
+		policy:   RoundRobin(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// DB implements DBProvider.
+func (p *ReplicaProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	db := p.primary
+	if IsReadOnly(ctx) && len(p.replicas) > 0 {
+		db = p.replicas[p.policy.Pick(len(p.replicas))]
+	} else if ctx.Value(readKey{}) == nil {
+		markWritten(ctx)
+	}
+
+	db = db.WithContext(ctx)
+	for _, whr := range wheres {
+		if whr != nil {
+			db = whr.Where(db)
+		}
+	}
+	return db
+}
+
+// Probe pings every replica and reports the measured latencies to the policy when
+// it implements LatencyObserver. Call it periodically when using LeastLatency.
+// Replicas failing the ping are reported with a very high latency.
+func (p *ReplicaProvider) Probe(ctx context.Context) {
+	observer, ok := p.policy.(LatencyObserver)
+	if !ok {
+		return
+	}
+
+	for i, replica := range p.replicas {
+		start := time.Now()
+		latency := time.Duration(math.MaxInt32) * time.Millisecond
+		if sqlDB, err := replica.DB(); err == nil && sqlDB.PingContext(ctx) == nil {
+			latency = time.Since(start)
+		}
+		observer.Observe(i, latency)
+	}
+}
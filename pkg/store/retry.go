@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+)
+
+const (
+	// defaultRetryAttempts defines the number of attempts when the policy does not set it.
+	defaultRetryAttempts = 3
+	// defaultRetryMinBackoff defines the delay before the first retry.
+	defaultRetryMinBackoff = 50 * time.Millisecond
+	// defaultRetryMaxBackoff defines the maximum delay between two attempts.
+	defaultRetryMaxBackoff = 2 * time.Second
+)
+
+// RetryPolicy defines how store operations failing with a transient error are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. Defaults to 3.
+	MaxAttempts int
+	// Backoff returns the delay before the given retry attempt (starting at 1).
+	// Defaults to an exponential backoff from 50ms to 2s with jitter.
+	Backoff func(attempt int) time.Duration
+	// Retryable reports whether an error is transient. Defaults to IsTransient.
+	Retryable func(err error) bool
+}
+
+// WithRetry returns an Option retrying the database statements of the store
+// operations that fail with a transient error, such as deadlocks or dropped
+// connections. Hooks and change events are not repeated. GetOrCreate, Each,
+// ListByCursor and Exec are not retried.
+//
+// Statements running within a transaction are not retried, as the failure aborts
+// the whole transaction. Tx retries the entire transaction instead, so fn may run
+// several times and must not have side effects outside the transaction.
+func WithRetry[T any](policy RetryPolicy) Option[T] {
+	return func(s *Store[T]) {
+		policy = policy.withDefaults(IsTransient)
+		s.retryPolicy = &policy
+	}
+}
+
+// WithTxRetry runs fn inside a transaction opened on provider like WithTx, and runs
+// the whole transaction again when it is aborted by a deadlock, as the database
+// rolled it back in favor of a concurrent transaction. Each retry is logged to logger,
+// which may be nil. policy defaults to 3 attempts with a jittered exponential backoff,
+// and to retrying deadlocks only (see IsDeadlock).
+//
+// fn may run several times and must not have side effects outside the transaction.
+// When ctx already carries a transaction opened on provider, fn runs in a savepoint
+// and is not retried, as the deadlock aborted the enclosing transaction.
+func WithTxRetry(ctx context.Context, provider DBProvider, logger Logger, policy RetryPolicy, fn func(txCtx context.Context) error) error {
+	if _, nested := txFromContext(ctx, provider); nested {
+		return WithTx(ctx, provider, fn)
+	}
+	if logger == nil {
+		logger = empty.NewLogger()
+	}
+	policy = policy.withDefaults(IsDeadlock)
+	return policy.do(ctx, logger, func() error { return WithTx(ctx, provider, fn) })
+}
+
+// ExponentialBackoff returns a backoff doubling the delay from minDelay up to
+// maxDelay, with up to 20% of random jitter to spread concurrent retries.
+func ExponentialBackoff(minDelay, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := minDelay
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, maxDelay)
+		return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+	}
+}
+
+// IsDeadlock reports whether err indicates that the transaction was aborted by the
+// database to resolve a deadlock: MySQL error 1213 or PostgreSQL deadlock_detected (40P01).
+func IsDeadlock(err error) bool {
+	if code, ok := mysqlErrorNumber(err); ok {
+		return code == 1213
+	}
+	if state, ok := sqlState(err); ok {
+		return state == "40P01"
+	}
+	return false
+}
+
+// IsTransient reports whether err is a transient database error that may succeed
+// when retried: deadlocks, lock wait timeouts, serialization failures and lost connections.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// MySQL: 1205 lock wait timeout, 1213 deadlock
+	if code, ok := mysqlErrorNumber(err); ok {
+		return code == 1205 || code == 1213
+	}
+
+	// PostgreSQL: serialization_failure, deadlock_detected, connection exceptions (class 08)
+	if state, ok := sqlState(err); ok {
+		return state == "40001" || state == "40P01" || strings.HasPrefix(state, "08")
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "database is locked")
+}
+
+// retry runs fn, retrying it according to the retry policy of the store while it
+// fails with a transient error. fn is run once when no policy is configured or
+// when ctx carries a transaction.
+func (s *Store[T]) retry(ctx context.Context, fn func() error) error {
+	if s.retryPolicy == nil {
+		return fn()
+	}
+	if _, ok := txFromContext(ctx, s.storage); ok {
+		return fn()
+	}
+	return s.retryPolicy.do(ctx, s.logger, fn)
+}
+
+// withDefaults returns p with its unset fields set to their defaults, retrying the
+// errors matching retryable.
+func (p RetryPolicy) withDefaults(retryable func(err error) bool) RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryAttempts
+	}
+	if p.Backoff == nil {
+		p.Backoff = ExponentialBackoff(defaultRetryMinBackoff, defaultRetryMaxBackoff)
+	}
+	if p.Retryable == nil {
+		p.Retryable = retryable
+	}
+	return p
+}
+
+// do runs fn until it succeeds, fails with a permanent error or the attempts are exhausted.
+func (p *RetryPolicy) do(ctx context.Context, logger Logger, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.Retryable(err) {
+			return err
+		}
+
+		// The final failure is logged by the operation, the retried ones are warnings.
+		delay := p.Backoff(attempt)
+		warn(ctx, logger, "Retrying database operation after transient error",
+			"error", err, "attempt", attempt, "maxAttempts", p.MaxAttempts, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// returningKey is the context key holding the Returned of the write operations.
+type returningKey struct{}
+
+// Returned collects the objects written by the store operations called with a
+// context returned by Returning.
+type Returned[T any] struct {
+	columns []string
+
+	mu   sync.Mutex
+	objs []*T
+}
+
+// Objects returns the objects written so far, in order, holding the returned columns.
+func (r *Returned[T]) Objects() []*T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.objs)
+}
+
+// add records objs.
+func (r *Returned[T]) add(objs ...*T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.objs = append(r.objs, objs...)
+}
+
+// Returning returns a context in which the write operations of the stores of T fetch
+// the given columns of the rows they write with a RETURNING clause, so that values
+// computed by the database, such as defaults, generated keys or columns set by
+// triggers, are available without another query. All columns are returned when none
+// is given:
+//
+//	ctx, returned := store.Returning[User](ctx, "id", "created_at")
+//	_, _ = users.UpdateWhere(ctx, where.F("status", "expired"), map[string]any{"status": "closed"})
+//	fmt.Println(returned.Objects())
+//
+// Create, CreateBatch, Upsert and GetOrCreate populate the created objects with the
+// returned columns. UpdateWhere, UpdateExpr and Delete collect the updated or deleted
+// rows, which would otherwise not be loaded. Every written object is collected in the
+// returned Returned. RETURNING is supported by PostgreSQL and SQLite, other databases
+// ignore it.
+func Returning[T any](ctx context.Context, columns ...string) (context.Context, *Returned[T]) {
+	returned := &Returned[T]{columns: columns}
+	return context.WithValue(ctx, returningKey{}, returned), returned
+}
+
+// returnedFrom returns the Returned of T carried by ctx, if any.
+func returnedFrom[T any](ctx context.Context) (*Returned[T], bool) {
+	returned, ok := ctx.Value(returningKey{}).(*Returned[T])
+	return returned, ok
+}
+
+// returning adds the RETURNING clause requested by ctx, if any, to db, which inserts
+// objects. Primary keys are always returned, as GORM relies on them to populate the
+// objects of inserts.
+func (s *Store[T]) returning(ctx context.Context, db *gorm.DB) *gorm.DB {
+	returned, ok := returnedFrom[T](ctx)
+	if !ok {
+		return db
+	}
+
+	columns := returningColumns(returned.columns)
+	if len(columns) > 0 {
+		if sch, err := schemaOf[T](db); err == nil {
+			for _, field := range sch.PrimaryFields {
+				if !slices.Contains(returned.columns, field.DBName) {
+					columns = append(columns, clause.Column{Name: field.DBName})
+				}
+			}
+		}
+	}
+	return db.Clauses(clause.Returning{Columns: columns})
+}
+
+// returningModel sets the model of db, which updates or deletes the objects matching
+// its conditions. When ctx requests a RETURNING clause, the model is a slice receiving
+// the returned rows, which the returned function collects once the statement succeeded.
+func (s *Store[T]) returningModel(ctx context.Context, db *gorm.DB) (*gorm.DB, func()) {
+	returned, ok := returnedFrom[T](ctx)
+	if !ok {
+		return db.Model(new(T)), func() {}
+	}
+
+	var objs []*T
+	db = db.Clauses(clause.Returning{Columns: returningColumns(returned.columns)}).Model(&objs)
+	return db, func() { returned.add(objs...) }
+}
+
+// collectReturned records objs in the Returned carried by ctx, if any.
+func (s *Store[T]) collectReturned(ctx context.Context, objs ...*T) {
+	if returned, ok := returnedFrom[T](ctx); ok {
+		returned.add(objs...)
+	}
+}
+
+// returningColumns converts column names to the columns of a RETURNING clause.
+func returningColumns(names []string) []clause.Column {
+	columns := make([]clause.Column, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, clause.Column{Name: name})
+	}
+	return columns
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// deletedAtType is the type of the standard GORM soft delete field.
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// schemaOf parses the schema of the model T using the configuration of db.
+// Parsed schemas are cached by GORM, so calling it on every operation is cheap.
+func schemaOf[T any](db *gorm.DB) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// primaryField returns the primary key field of the model T.
+// For composite primary keys the prioritized field is returned.
+func primaryField[T any](db *gorm.DB) (*schema.Field, error) {
+	sch, err := schemaOf[T](db)
+	if err != nil {
+		return nil, err
+	}
+
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("model %s has no primary key", sch.Name)
+	}
+	return sch.PrioritizedPrimaryField, nil
+}
+
+// softDeleteField returns the soft delete field of the model T, honoring custom column names.
+func softDeleteField[T any](db *gorm.DB) (*schema.Field, error) {
+	sch, err := schemaOf[T](db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("model %s has no soft delete field", sch.Name)
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// RegisterScope registers a named scope, applied to the queries of any store with
+// where.Scope, so that common predicates are not copy-pasted across call sites:
+//
+//	store.RegisterScope("published", func(db *gorm.DB) *gorm.DB {
+//		return db.Where("published_at IS NOT NULL")
+//	})
+//	posts, err := s.List(ctx, where.Scope("published").L(10))
+//
+// Queries using a scope that is not registered fail.
+func RegisterScope(name string, scope func(*gorm.DB) *gorm.DB) {
+	where.RegisterScope(name, scope)
+}
+
+// WithDefaultWhere returns an Option restricting every operation of the store to the
+// objects matching the conditions of opts, so that callers cannot forget them:
+//
+//	orders := store.NewStore[Order](provider, store.WithDefaultWhere[Order](where.NewWhere().Q("status <> ?", "draft")))
+//	drafts, err := orders.Count(ctx, where.F("status", "draft").NoDefaultWhere())
+//
+// Pagination and ordering carried by opts are ignored. The conditions are bypassed by
+// the operations called with where options marked with where.NoDefaultWhere.
+func WithDefaultWhere[T any](opts *where.Options) Option[T] {
+	return func(s *Store[T]) {
+		s.defaultWhere = conditions(opts)
+	}
+}
+
+// scopeDefault restricts db to the objects matching the default conditions of the
+// store, unless one of wheres bypasses them.
+func (s *Store[T]) scopeDefault(db *gorm.DB, wheres []where.Where) *gorm.DB {
+	if s.defaultWhere == nil {
+		return db
+	}
+	for _, whr := range wheres {
+		if opts, ok := whr.(*where.Options); ok && opts != nil && opts.SkipDefaultWhere {
+			return db
+		}
+	}
+
+	// Where appends the conditions of the queries to the clauses, which must not grow
+	// the slice shared by the concurrent operations.
+	whr := *s.defaultWhere
+	whr.Clauses = slices.Clip(whr.Clauses)
+	return whr.Where(db)
+}
@@ -0,0 +1,5 @@
+// Package seed loads YAML or JSON fixtures into the database of a store, so that
+// integration tests and demo environments can be provisioned reproducibly.
+// Fixtures may reference rows of other fixtures, and are inserted in a deterministic
+// order honoring those references.
+package seed // import "github.com/miladystack/miladystack/pkg/store/seed"
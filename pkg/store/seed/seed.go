@@ -0,0 +1,338 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/miladystack/miladystack/pkg/store"
+	"github.com/miladystack/miladystack/pkg/store/logger/empty"
+)
+
+const (
+	// refKey is the key naming a fixture row, so that other rows can reference it.
+	refKey = "_ref"
+	// refPrefix prefixes the values referencing a column of a named row.
+	refPrefix = "$ref:"
+)
+
+// Refs holds the columns of the named fixture rows, as inserted, by reference name.
+type Refs map[string]map[string]any
+
+// Option defines a function type for configuring the Seeder.
+type Option func(*Seeder)
+
+// WithModels registers the models of the fixture tables. Rows of these tables are
+// created through their model, so that defaults, hooks and generated primary keys
+// apply and can be referenced. Rows of other tables are inserted as is.
+func WithModels(models ...any) Option {
+	return func(s *Seeder) {
+		s.models = append(s.models, models...)
+	}
+}
+
+// WithLogger sets the logger used to report failures.
+func WithLogger(logger store.Logger) Option {
+	return func(s *Seeder) {
+		s.logger = logger
+	}
+}
+
+// Seeder loads fixtures into the database of a DBProvider.
+//
+// Fixtures map table names to lists of rows. A row named with the _ref key can be
+// referenced by the rows loaded after it with "$ref:<name>", which resolves to its
+// primary key, or "$ref:<name>.<column>":
+//
+//	users:
+//	  - _ref: alice
+//	    name: Alice
+//	posts:
+//	  - title: Hello
+//	    user_id: $ref:alice
+//
+// Tables are loaded so that referenced rows are inserted first, and in alphabetical
+// order otherwise. Rows are inserted in the order of the fixtures.
+type Seeder struct {
+	provider store.DBProvider
+	logger   store.Logger
+	models   []any
+}
+
+// New creates a Seeder loading fixtures through provider.
+func New(provider store.DBProvider, opts ...Option) *Seeder {
+	s := &Seeder{provider: provider, logger: empty.NewLogger()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// LoadFiles loads the YAML or JSON fixture files at paths. See Load.
+func (s *Seeder) LoadFiles(ctx context.Context, paths ...string) (Refs, error) {
+	docs := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, data)
+	}
+	return s.Load(ctx, docs...)
+}
+
+// Load loads the YAML or JSON fixture documents in a single transaction, and
+// returns the named rows. Rows of the same table found in several documents are
+// inserted in the order of the documents.
+func (s *Seeder) Load(ctx context.Context, docs ...[]byte) (Refs, error) {
+	fixtures := make(map[string][]map[string]any)
+	for i, data := range docs {
+		var doc map[string][]map[string]any
+		// YAML is a superset of JSON, so both are decoded alike.
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid fixtures in document %d: %w", i, err)
+		}
+		for table, rows := range doc {
+			fixtures[table] = append(fixtures[table], rows...)
+		}
+	}
+
+	tables, err := order(fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(Refs)
+	primaryKeys := make(map[string]string)
+	err = s.provider.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		models, err := s.schemas(tx)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			for i, row := range fixtures[table] {
+				columns, primaryKey, err := s.insert(ctx, tx, models[table], table, row, refs, primaryKeys)
+				if err != nil {
+					return fmt.Errorf("fixture %s[%d]: %w", table, i, err)
+				}
+				if name, ok := row[refKey].(string); ok {
+					refs[name], primaryKeys[name] = columns, primaryKey
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to load fixtures")
+		return nil, err
+	}
+	return refs, nil
+}
+
+// Truncate removes every row of tables, resetting their auto-increment counters
+// where the database supports it. Foreign key checks are disabled on MySQL and
+// dependent tables are truncated in cascade on PostgreSQL.
+func (s *Seeder) Truncate(ctx context.Context, tables ...string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	db := s.provider.DB(ctx)
+	err := db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case "postgres":
+			return conn.Exec("TRUNCATE TABLE " + quoteAll(conn, tables) + " RESTART IDENTITY CASCADE").Error
+		case "mysql":
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			for _, table := range tables {
+				if err := conn.Exec("TRUNCATE TABLE " + quoteAll(conn, []string{table})).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			// SQLite has no TRUNCATE: delete the rows in reverse order so that the
+			// dependent tables, listed last, are emptied first.
+			for _, table := range slices.Backward(tables) {
+				if err := conn.Exec("DELETE FROM " + quoteAll(conn, []string{table})).Error; err != nil {
+					return err
+				}
+			}
+			if conn.Migrator().HasTable("sqlite_sequence") {
+				return conn.Exec("DELETE FROM sqlite_sequence WHERE name IN ?", tables).Error
+			}
+			return nil
+		}
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to truncate tables", "tables", tables)
+		return err
+	}
+	return nil
+}
+
+// schemas parses the registered models by table name.
+func (s *Seeder) schemas(db *gorm.DB) (map[string]*schema.Schema, error) {
+	schemas := make(map[string]*schema.Schema, len(s.models))
+	for _, model := range s.models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		schemas[stmt.Schema.Table] = stmt.Schema
+	}
+	return schemas, nil
+}
+
+// insert inserts row into table, through the model sch when not nil, and returns
+// the columns of the inserted row along with its primary key column.
+func (s *Seeder) insert(
+	ctx context.Context, tx *gorm.DB, sch *schema.Schema, table string, row map[string]any, refs Refs, primaryKeys map[string]string,
+) (map[string]any, string, error) {
+	values := make(map[string]any, len(row))
+	for column, value := range row {
+		if column == refKey {
+			continue
+		}
+		resolved, err := resolve(value, refs, primaryKeys)
+		if err != nil {
+			return nil, "", err
+		}
+		values[column] = resolved
+	}
+
+	if sch == nil {
+		if err := tx.Table(table).Create(values).Error; err != nil {
+			return nil, "", err
+		}
+		return values, "id", nil
+	}
+
+	obj := reflect.New(sch.ModelType)
+	for column, value := range values {
+		field := sch.LookUpField(column)
+		if field == nil {
+			return nil, "", fmt.Errorf("unknown column %s", column)
+		}
+		if err := field.Set(ctx, obj.Elem(), value); err != nil {
+			return nil, "", fmt.Errorf("column %s: %w", column, err)
+		}
+	}
+	if err := tx.Create(obj.Interface()).Error; err != nil {
+		return nil, "", err
+	}
+
+	columns := make(map[string]any, len(sch.DBNames))
+	for _, field := range sch.Fields {
+		if field.DBName != "" {
+			columns[field.DBName], _ = field.ValueOf(ctx, obj.Elem())
+		}
+	}
+	primaryKey := "id"
+	if sch.PrioritizedPrimaryField != nil {
+		primaryKey = sch.PrioritizedPrimaryField.DBName
+	}
+	return columns, primaryKey, nil
+}
+
+// resolve returns value with its reference, if any, replaced by the referenced column.
+func resolve(value any, refs Refs, primaryKeys map[string]string) (any, error) {
+	name, column, ok := parseRef(value)
+	if !ok {
+		return value, nil
+	}
+	columns, ok := refs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference %s", name)
+	}
+	if column == "" {
+		column = primaryKeys[name]
+	}
+	resolved, ok := columns[column]
+	if !ok {
+		return nil, fmt.Errorf("reference %s has no column %s", name, column)
+	}
+	return resolved, nil
+}
+
+// parseRef parses a "$ref:<name>[.<column>]" value.
+func parseRef(value any) (name string, column string, ok bool) {
+	str, isString := value.(string)
+	if !isString || !strings.HasPrefix(str, refPrefix) {
+		return "", "", false
+	}
+	name, column, _ = strings.Cut(strings.TrimPrefix(str, refPrefix), ".")
+	return name, column, true
+}
+
+// order returns the tables of fixtures sorted so that the tables of referenced rows
+// come first, alphabetically among independent tables.
+func order(fixtures map[string][]map[string]any) ([]string, error) {
+	owners := make(map[string]string)
+	for table, rows := range fixtures {
+		for _, row := range rows {
+			if name, ok := row[refKey].(string); ok {
+				if owner, dup := owners[name]; dup {
+					return nil, fmt.Errorf("duplicate reference %s in %s and %s", name, owner, table)
+				}
+				owners[name] = table
+			}
+		}
+	}
+
+	deps := make(map[string]map[string]bool, len(fixtures))
+	for table, rows := range fixtures {
+		deps[table] = make(map[string]bool)
+		for _, row := range rows {
+			for _, value := range row {
+				if name, _, ok := parseRef(value); ok {
+					if owner, ok := owners[name]; ok && owner != table {
+						deps[table][owner] = true
+					}
+				}
+			}
+		}
+	}
+
+	var tables []string
+	for len(deps) > 0 {
+		var ready []string
+		for table, requires := range deps {
+			if len(requires) == 0 {
+				ready = append(ready, table)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("circular references between tables %v", slices.Sorted(maps.Keys(deps)))
+		}
+		slices.Sort(ready)
+
+		table := ready[0]
+		tables = append(tables, table)
+		delete(deps, table)
+		for _, requires := range deps {
+			delete(requires, table)
+		}
+	}
+	return tables, nil
+}
+
+// quoteAll quotes the table names for the dialect of db, separated by commas.
+func quoteAll(db *gorm.DB, tables []string) string {
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = db.Statement.Quote(table)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,133 @@
+package seed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testUser struct {
+	ID   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+type testPost struct {
+	ID     uint64 `gorm:"primaryKey"`
+	UserID uint64
+	Title  string
+}
+
+type testProvider struct {
+	db *gorm.DB
+}
+
+func (p *testProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return p.db.WithContext(ctx)
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&testUser{}, &testPost{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE tags (id INTEGER PRIMARY KEY, post_id INTEGER, name TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create tags: %v", err)
+	}
+	return &testProvider{db: db}
+}
+
+const testFixtures = `
+test_posts:
+  - _ref: hello
+    title: Hello
+    user_id: $ref:alice
+test_users:
+  - _ref: alice
+    name: Alice
+  - name: Bob
+`
+
+func TestLoad(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+
+	s := New(provider, WithModels(&testUser{}, &testPost{}))
+	tags := `{"tags": [{"id": 7, "post_id": "$ref:hello", "name": "$ref:hello.title"}]}`
+	refs, err := s.Load(ctx, []byte(testFixtures), []byte(tags))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var post testPost
+	provider.db.First(&post)
+	if post.UserID == 0 || post.UserID != refs["alice"]["id"] {
+		t.Errorf("Expected the post to reference alice %v, got %+v", refs["alice"], post)
+	}
+
+	var tag struct {
+		PostID uint64
+		Name   string
+	}
+	provider.db.Table("tags").Where("id = ?", 7).Scan(&tag)
+	if tag.PostID != post.ID || tag.Name != "Hello" {
+		t.Errorf("Expected the tag to reference the post, got %+v", tag)
+	}
+
+	if err := s.Truncate(ctx, "test_users", "test_posts", "tags"); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+	var count int64
+	provider.db.Model(&testUser{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected users to be truncated, got %d", count)
+	}
+
+	// Auto-increment counters are reset, so the fixtures load identically again.
+	refs, err = s.Load(ctx, []byte(testFixtures))
+	if err != nil || refs["alice"]["id"] != uint64(1) {
+		t.Errorf("Expected alice to get id 1 again, got %v, %v", refs["alice"], err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	provider := newTestProvider(t)
+	ctx := context.Background()
+	s := New(provider, WithModels(&testUser{}, &testPost{}))
+
+	tests := []struct {
+		name     string
+		fixtures string
+		want     string
+	}{
+		{"unknown reference", "test_posts:\n  - user_id: $ref:nobody\n", "unknown reference nobody"},
+		{"unknown column", "test_users:\n  - nickname: x\n", "unknown column nickname"},
+		{"circular references", "test_users:\n  - _ref: a\n    name: $ref:b.title\ntest_posts:\n  - _ref: b\n    title: $ref:a.name\n", "circular references"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Load(ctx, []byte(tt.fixtures)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	var count int64
+	provider.db.Model(&testUser{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected failed loads to be rolled back, got %d users", count)
+	}
+}
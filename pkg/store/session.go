@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// sessionKey is the context key holding the SessionOptions of the store operations.
+type sessionKey struct{}
+
+// SessionOptions holds the GORM session flags applied to the statements of the store
+// operations called with a context returned by WithSessionOptions.
+type SessionOptions struct {
+	// SkipHooks skips the hooks of the model, such as BeforeCreate. The hooks of the
+	// store registered with OnBeforeCreate and the like still run.
+	SkipHooks bool
+	// FullSaveAssociations updates the associations of the saved objects instead of
+	// only inserting the missing ones.
+	FullSaveAssociations bool
+	// PrepareStmt caches the prepared statements.
+	PrepareStmt bool
+	// QueryFields selects the columns of the model by name instead of with *.
+	QueryFields bool
+	// SkipDefaultTransaction runs the writes without the transaction GORM opens by default.
+	SkipDefaultTransaction bool
+}
+
+// WithSessionOptions returns a context in which the store operations run their
+// statements with the given GORM session flags, for example to skip the model hooks
+// during a bulk import:
+//
+//	ctx = store.WithSessionOptions(ctx, store.SessionOptions{SkipHooks: true})
+//	_, err := users.CreateBatch(ctx, imported, 500)
+func WithSessionOptions(ctx context.Context, opts SessionOptions) context.Context {
+	return context.WithValue(ctx, sessionKey{}, opts)
+}
+
+// session applies the GORM session flags carried by ctx, if any, to db.
+func session(ctx context.Context, db *gorm.DB) *gorm.DB {
+	opts, ok := ctx.Value(sessionKey{}).(SessionOptions)
+	if !ok {
+		return db
+	}
+	return db.Session(&gorm.Session{
+		SkipHooks:              opts.SkipHooks,
+		FullSaveAssociations:   opts.FullSaveAssociations,
+		PrepareStmt:            opts.PrepareStmt,
+		QueryFields:            opts.QueryFields,
+		SkipDefaultTransaction: opts.SkipDefaultTransaction,
+	})
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type (
+	// shardKey is the context key holding the shard key of the current request.
+	shardKey struct{}
+	// wheresKey is the context key holding the where conditions of a store operation.
+	wheresKey struct{}
+)
+
+// WithShardKey returns a context carrying the shard key used by ShardProvider to
+// select the shard serving the queries, such as a tenant or user ID.
+func WithShardKey(ctx context.Context, key any) context.Context {
+	return context.WithValue(ctx, shardKey{}, key)
+}
+
+// ShardFunc maps a shard key to the index of a shard among n shards.
+type ShardFunc func(key any, n int) int
+
+// ModuloShard is the default ShardFunc. Integer keys are mapped with key modulo n,
+// any other key with the FNV-1a hash of its string representation modulo n.
+func ModuloShard(key any, n int) int {
+	var k uint64
+	switch v := key.(type) {
+	case int:
+		k = uint64(v)
+	case int32:
+		k = uint64(v)
+	case int64:
+		k = uint64(v)
+	case uint:
+		k = uint64(v)
+	case uint32:
+		k = uint64(v)
+	case uint64:
+		k = v
+	default:
+		h := fnv.New64a()
+		_, _ = fmt.Fprint(h, key)
+		k = h.Sum64()
+	}
+	return int(k % uint64(n))
+}
+
+// ShardOption defines a function type for configuring the ShardProvider.
+type ShardOption func(*ShardProvider)
+
+// WithShardFunc sets the function mapping shard keys to shards.
+func WithShardFunc(fn ShardFunc) ShardOption {
+	return func(p *ShardProvider) {
+		p.shardFunc = fn
+	}
+}
+
+// ShardProvider is a DBProvider routing queries over horizontally partitioned
+// databases. The shard is selected from the shard key carried by the context
+// (see WithShardKey) or, when absent, from the filter on the shard column of the
+// where options (e.g. where.F("tenant_id", 42)).
+//
+// Queries whose shard cannot be determined fail with ErrNoShardKey rather than
+// silently hitting the wrong shard. Writes and transactions do not carry where
+// options, so they require the shard key in the context.
+type ShardProvider struct {
+	shards    []*gorm.DB
+	column    string
+	shardFunc ShardFunc
+}
+
+var _ DBProvider = (*ShardProvider)(nil)
+
+// NewShardProvider creates a ShardProvider over shards, partitioned by column.
+// The order of shards is significant and must remain stable, as keys are mapped to
+// shard indexes.
+func NewShardProvider(column string, shards []*gorm.DB, opts ...ShardOption) *ShardProvider {
+	p := &ShardProvider{
+		shards:    shards,
+		column:    column,
+		shardFunc: ModuloShard,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// DB implements DBProvider.
+func (p *ShardProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	key, ok := p.shardKey(ctx, wheres)
+	if !ok {
+		db := p.shards[0].WithContext(ctx)
+		_ = db.AddError(fmt.Errorf("%w: %s is required in context or where options", ErrNoShardKey, p.column))
+		return db
+	}
+
+	db := p.Shard(key).WithContext(ctx)
+	for _, whr := range wheres {
+		if whr != nil {
+			db = whr.Where(db)
+		}
+	}
+	return db
+}
+
+// Shard returns the database holding the rows of the given shard key.
+func (p *ShardProvider) Shard(key any) *gorm.DB {
+	return p.shards[p.shardFunc(key, len(p.shards))]
+}
+
+// Shards returns all the shards, e.g. to run migrations or fan-out queries.
+func (p *ShardProvider) Shards() []*gorm.DB {
+	return p.shards
+}
+
+// shardKey extracts the shard key from the context, then from the where options
+// passed by the caller or by the Store operation.
+func (p *ShardProvider) shardKey(ctx context.Context, wheres []where.Where) (any, bool) {
+	if key := ctx.Value(shardKey{}); key != nil {
+		return key, true
+	}
+	if conds, ok := ctx.Value(wheresKey{}).([]where.Where); ok {
+		wheres = slices.Concat(conds, wheres)
+	}
+	for _, whr := range wheres {
+		opts, ok := whr.(*where.Options)
+		if !ok || opts == nil {
+			continue
+		}
+		if key, ok := opts.Filters[p.column]; ok && key != nil {
+			return key, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// WithSlowQueryThreshold returns an Option logging a warning for every statement
+// of the store operations running for longer than threshold, with its duration,
+// the conditions of the operation and the generated SQL. The SQL includes the query
+// arguments, so the logs may contain sensitive data.
+func WithSlowQueryThreshold[T any](threshold time.Duration) Option[T] {
+	return func(s *Store[T]) {
+		s.slowQuery = threshold
+	}
+}
+
+// logSlow makes db report the statements exceeding the slow query threshold.
+func (s *Store[T]) logSlow(db *gorm.DB) *gorm.DB {
+	if s.slowQuery <= 0 {
+		return db
+	}
+	return db.Session(&gorm.Session{Logger: &slowQueryLogger{
+		Interface: db.Logger,
+		logger:    s.logger,
+		threshold: s.slowQuery,
+		entity:    reflect.TypeFor[T]().Name(),
+	}})
+}
+
+// slowQueryLogger is a GORM logger warning about the statements running for longer
+// than threshold through the store Logger, then delegating to the wrapped logger.
+type slowQueryLogger struct {
+	gormlogger.Interface
+	logger    Logger
+	threshold time.Duration
+	entity    string
+}
+
+// LogMode implements gorm logger.Interface.
+func (l *slowQueryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.Interface = l.Interface.LogMode(level)
+	return &clone
+}
+
+// Trace implements gorm logger.Interface.
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if elapsed := time.Since(begin); elapsed >= l.threshold {
+		sql, rows := fc()
+		kvs := []any{"entity", l.entity, "duration", elapsed, "sql", sql, "rows", rows}
+		if wheres, ok := ctx.Value(wheresKey{}).([]where.Where); ok && len(wheres) > 0 {
+			kvs = append(kvs, "conditions", wheres)
+		}
+		warn(ctx, l.logger, "Slow database query", kvs...)
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
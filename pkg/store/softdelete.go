@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+// SoftDelete defines how the objects of a store are soft-deleted, for schemas that
+// do not use the gorm.DeletedAt field, such as legacy is_deleted 0/1 flags.
+type SoftDelete struct {
+	// Column is the column marking the deleted objects.
+	Column string
+	// Deleted returns the value of Column for the objects being deleted.
+	Deleted func() any
+	// NotDeleted is the value of Column for the objects that are not deleted, nil for NULL.
+	NotDeleted any
+	// DeletedBy is the column recording the operator deleting the objects (see
+	// WithOperator). It is optional and defaults to the deleted by column of WithAudit.
+	DeletedBy string
+}
+
+// TimestampSoftDelete returns a SoftDelete setting column to the deletion time,
+// column being NULL for the objects that are not deleted.
+func TimestampSoftDelete(column string) SoftDelete {
+	return SoftDelete{
+		Column:  column,
+		Deleted: func() any { return time.Now() },
+	}
+}
+
+// FlagSoftDelete returns a SoftDelete setting column to 1 on deletion, column being
+// 0 for the objects that are not deleted.
+func FlagSoftDelete(column string) SoftDelete {
+	return SoftDelete{
+		Column:     column,
+		Deleted:    func() any { return 1 },
+		NotDeleted: 0,
+	}
+}
+
+// WithDeletedBy returns a copy of d recording the operator deleting the objects in column.
+func (d SoftDelete) WithDeletedBy(column string) SoftDelete {
+	d.DeletedBy = column
+	return d
+}
+
+// WithSoftDelete returns an Option soft-deleting the objects of the store with the
+// given strategy: Delete marks the objects as deleted instead of removing them, and
+// every other operation ignores the deleted objects unless its where options are
+// unscoped (see where.Options.U). Restore brings them back and Purge removes them.
+func WithSoftDelete[T any](strategy SoftDelete) Option[T] {
+	return func(s *Store[T]) {
+		s.softDeletion = &strategy
+	}
+}
+
+// scopeSoftDelete restricts db to the objects that are not soft-deleted, unless the
+// statement is unscoped. The scope runs when the statement is executed, so that
+// operations calling Unscoped after retrieving db are honored.
+func (s *Store[T]) scopeSoftDelete(db *gorm.DB) *gorm.DB {
+	if s.softDeletion == nil {
+		return db
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: s.softDeletion.Column}
+	return db.Scopes(func(db *gorm.DB) *gorm.DB {
+		if db.Statement.Unscoped {
+			return db
+		}
+		return db.Where(clause.Eq{Column: column, Value: s.softDeletion.NotDeleted})
+	})
+}
+
+// markDeleted soft-deletes the objects matching opts with the configured strategy
+// and returns the number of rows affected.
+func (s *Store[T]) markDeleted(ctx context.Context, opts *where.Options) (affected int64, err error) {
+	fields := map[string]any{s.softDeletion.Column: s.softDeletion.Deleted()}
+	if deletedBy := s.deletedBy(); s.hasColumn(ctx, deletedBy) {
+		if id, ok := OperatorFromContext(ctx); ok {
+			fields[deletedBy] = id
+		}
+	}
+
+	collect := func() {}
+	err = s.retry(ctx, func() error {
+		var db *gorm.DB
+		db, collect = s.returningModel(ctx, s.db(ctx, opts))
+		result := db.UpdateColumns(fields)
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err == nil {
+		collect()
+	}
+	return affected, err
+}
+
+// deletedBy returns the column recording the operator soft-deleting the objects, the
+// one of the strategy or else the one of WithAudit, if any.
+func (s *Store[T]) deletedBy() string {
+	if s.softDeletion.DeletedBy == "" && s.audit != nil {
+		return s.audit.DeletedBy
+	}
+	return s.softDeletion.DeletedBy
+}
+
+// unmarkDeleted restores the objects matching opts soft-deleted with the configured
+// strategy and returns the number of rows affected.
+func (s *Store[T]) unmarkDeleted(ctx context.Context, opts *where.Options) (restored int64, err error) {
+	fields := map[string]any{s.softDeletion.Column: s.softDeletion.NotDeleted}
+	if deletedBy := s.deletedBy(); s.hasColumn(ctx, deletedBy) {
+		fields[deletedBy] = nil
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: s.softDeletion.Column}
+	err = s.retry(ctx, func() error {
+		result := s.db(ctx, opts).Unscoped().Model(new(T)).
+			Where(clause.Neq{Column: column, Value: s.softDeletion.NotDeleted}).UpdateColumns(fields)
+		restored = result.RowsAffected
+		return result.Error
+	})
+	return restored, err
+}
@@ -3,13 +3,22 @@ package store
 import (
 	"context"
 	"errors"
+	"reflect"
+	"slices"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/miladystack/miladystack/pkg/store/logger/empty"
 	"github.com/miladystack/miladystack/pkg/store/where"
 )
 
+const (
+	// defaultBatchSize defines the batch size used when the caller does not provide a valid one.
+	defaultBatchSize = 100
+)
+
 // DBProvider defines an interface for providing a database connection.
 type DBProvider interface {
 	// DB returns the database instance for the given context.
@@ -23,6 +32,22 @@ type Option[T any] func(*Store[T])
 type Store[T any] struct {
 	logger  Logger
 	storage DBProvider
+	hooks   hooks[T]
+
+	publisher    ChangePublisher[T]
+	tenantColumn string
+	tracing      *tracing
+	retryPolicy  *RetryPolicy
+	audit        *AuditColumns
+	timeout      time.Duration
+	slowQuery    time.Duration
+	softDeletion *SoftDelete
+	tableFunc    func(ctx context.Context) string
+	history      bool
+	queryCache   *queryCache
+	defaultOrder string
+	defaultWhere *where.Options
+	extractors   ContextExtractors
 }
 
 // WithLogger returns an Option function that sets the provided Logger to the Store for logging purposes.
@@ -32,21 +57,43 @@ func WithLogger[T any](logger Logger) Option[T] {
 	}
 }
 
-// NewStore creates a new instance of Store with the provided DBProvider.
-func NewStore[T any](storage DBProvider, logger Logger) *Store[T] {
-	if logger == nil {
-		logger = empty.NewLogger()
-	}
-
-	return &Store[T]{
-		logger:  logger,
+// NewStore creates a new instance of Store with the provided DBProvider, configured
+// by opts, such as WithLogger. Without a logger, nothing is logged.
+// The concrete Store is returned so that it can be configured with hooks, services
+// should depend on the IStore interface it implements.
+// Fields of T tagged with `log:"-"` or `log:"mask"` are redacted from the objects and
+// conditions passed to the logger, so that sensitive data does not leak into the logs.
+func NewStore[T any](storage DBProvider, opts ...Option[T]) *Store[T] {
+	s := &Store[T]{
+		logger:  empty.NewLogger(),
 		storage: storage,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = empty.NewLogger()
+	}
+	s.logger = correlateLogger(redactLogger[T](s.logger), s.extractors)
+	return s
 }
 
 // db retrieves the database instance and applies the provided where conditions.
+// When ctx carries a transaction opened on the store's DBProvider, the transaction is used instead.
+// The query is scoped to the tenant carried by ctx when the store is configured with WithTenancy.
 func (s *Store[T]) db(ctx context.Context, wheres ...where.Where) *gorm.DB {
-	dbInstance := s.storage.DB(ctx)
+	// Expose the conditions to providers routing queries on them, such as ShardProvider,
+	// and to the slow query log.
+	ctx = context.WithValue(ctx, wheresKey{}, wheres)
+
+	var dbInstance *gorm.DB
+	if tx, ok := txFromContext(ctx, s.storage); ok {
+		dbInstance = tx.db.WithContext(ctx)
+	} else {
+		dbInstance = s.storage.DB(ctx)
+	}
+	dbInstance = s.scopeSoftDelete(s.scopeTenant(ctx, s.scopeTable(ctx, dryRun(ctx, s.logSlow(s.traced(session(ctx, dbInstance)))))))
+	dbInstance = s.scopeDefault(dbInstance, wheres)
 	for _, whr := range wheres {
 		if whr != nil {
 			dbInstance = whr.Where(dbInstance)
@@ -55,58 +102,626 @@ func (s *Store[T]) db(ctx context.Context, wheres ...where.Where) *gorm.DB {
 	return dbInstance
 }
 
+// reader retrieves the database instance for a read-only operation, which the
+// DBProvider may serve from a replica, and applies the provided where conditions.
+func (s *Store[T]) reader(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return s.db(withRead(ctx), wheres...)
+}
+
+// conditions returns a copy of opts that only carries its filtering conditions,
+// for operations where pagination and ordering are meaningless.
+func conditions(opts *where.Options) *where.Options {
+	var whr where.Options
+	if opts != nil {
+		whr = *opts
+	}
+	whr.Offset, whr.Limit, whr.Order, whr.Sorts = 0, -1, "", nil
+	return &whr
+}
+
 // Create inserts a new object into the database.
-func (s *Store[T]) Create(ctx context.Context, obj *T) error {
-	if err := s.db(ctx).Create(obj).Error; err != nil {
-		s.logger.Error(ctx, err, "Failed to insert object into database", "object", obj)
+func (s *Store[T]) Create(ctx context.Context, obj *T) (err error) {
+	ctx, op := s.begin(ctx, "Create")
+	defer func() { op.end(err) }()
+
+	if err := runHooks(ctx, s.hooks.beforeCreate, obj); err != nil {
 		return err
 	}
-	return nil
+	if err := s.fillTenant(ctx, obj); err != nil {
+		return err
+	}
+	if err := s.fillAudit(ctx, true, obj); err != nil {
+		return err
+	}
+
+	if err := s.retry(ctx, func() error { return s.returning(ctx, s.db(ctx)).Create(obj).Error }); err != nil {
+		s.logger.Error(ctx, err, "Failed to insert object into database", "object", obj)
+		return wrapError(err)
+	}
+	s.collectReturned(ctx, obj)
+	s.publish(ctx, OperationCreate, nil, []*T{obj})
+	return runHooks(ctx, s.hooks.afterCreate, obj)
+}
+
+// CreateBatch inserts objs into the database in batches of batchSize rows and
+// returns the number of rows inserted. Each batch is committed on its own, so when
+// a batch fails the rows of the previous batches stay inserted. Run it inside Tx
+// for all-or-nothing semantics.
+func (s *Store[T]) CreateBatch(ctx context.Context, objs []*T, batchSize int) (inserted int64, err error) {
+	ctx, op := s.begin(ctx, "CreateBatch")
+	defer func() { op.end(err) }()
+
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	for start := 0; start < len(objs); start += batchSize {
+		end := min(start+batchSize, len(objs))
+		if err := runHooks(ctx, s.hooks.beforeCreate, objs[start:end]...); err != nil {
+			return inserted, err
+		}
+		if err := s.fillTenant(ctx, objs[start:end]...); err != nil {
+			return inserted, err
+		}
+		if err := s.fillAudit(ctx, true, objs[start:end]...); err != nil {
+			return inserted, err
+		}
+
+		var rows int64
+		err := s.retry(ctx, func() error {
+			result := s.returning(ctx, s.db(ctx)).CreateInBatches(objs[start:end], batchSize)
+			rows = result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to insert batch into database",
+				"batch", start/batchSize, "offset", start, "size", end-start)
+			return inserted, wrapError(err)
+		}
+		inserted += rows
+		s.collectReturned(ctx, objs[start:end]...)
+		s.publish(ctx, OperationCreate, nil, objs[start:end])
+
+		if err := runHooks(ctx, s.hooks.afterCreate, objs[start:end]...); err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
 }
 
 // Update modifies an existing object in the database.
-func (s *Store[T]) Update(ctx context.Context, obj *T) error {
-	if err := s.db(ctx).Save(obj).Error; err != nil {
+func (s *Store[T]) Update(ctx context.Context, obj *T) (err error) {
+	ctx, op := s.begin(ctx, "Update")
+	defer func() { op.end(err) }()
+
+	if err := runHooks(ctx, s.hooks.beforeUpdate, obj); err != nil {
+		return err
+	}
+
+	before, err := s.snapshot(ctx, obj)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object state before update", "object", obj)
+		return wrapError(err)
+	}
+
+	if err := s.fillAudit(ctx, false, obj); err != nil {
+		return err
+	}
+
+	_, scoped := s.tenant(ctx)
+	if scoped {
+		if err := s.fillTenant(ctx, obj); err != nil {
+			return err
+		}
+	}
+	err = s.trackedObject(ctx, OperationUpdate, obj, func(ctx context.Context) error {
+		return s.retry(ctx, func() error {
+			db := s.db(ctx)
+			if scoped {
+				// Selecting the columns prevents Save from falling back to an upsert, which
+				// could overwrite a row of another tenant when no row of the tenant matches.
+				db = db.Select("*")
+			}
+			return db.Save(obj).Error
+		})
+	})
+	if err != nil {
 		s.logger.Error(ctx, err, "Failed to update object in database", "object", obj)
+		return wrapError(err)
+	}
+	s.publish(ctx, OperationUpdate, []*T{before}, []*T{obj})
+	return runHooks(ctx, s.hooks.afterUpdate, obj)
+}
+
+// UpdateNonZero updates the object identified by the primary key of obj with the
+// non-zero fields of obj. Unlike Update, which overwrites every column, the columns
+// of zero fields are left untouched, so partially filled objects are safe to pass.
+// When columns are given, exactly these columns are updated, zero values included,
+// e.g. to clear a field on purpose. The update time is always refreshed.
+func (s *Store[T]) UpdateNonZero(ctx context.Context, obj *T, columns ...string) (err error) {
+	ctx, op := s.begin(ctx, "UpdateNonZero")
+	defer func() { op.end(err) }()
+
+	if err := runHooks(ctx, s.hooks.beforeUpdate, obj); err != nil {
 		return err
 	}
-	return nil
+
+	before, err := s.snapshot(ctx, obj)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object state before update", "object", obj)
+		return wrapError(err)
+	}
+
+	if err := s.fillAudit(ctx, false, obj); err != nil {
+		return err
+	}
+	if _, ok := s.operator(ctx); ok && len(columns) > 0 && s.hasColumn(ctx, s.audit.UpdatedBy) {
+		columns = append(slices.Clip(columns), s.audit.UpdatedBy)
+	}
+
+	err = s.trackedObject(ctx, OperationUpdate, obj, func(ctx context.Context) error {
+		return s.retry(ctx, func() error {
+			db := s.db(ctx).Model(obj)
+			if len(columns) > 0 {
+				db = db.Select(columns)
+			}
+			return db.Updates(obj).Error
+		})
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to update object in database", "object", obj, "columns", columns)
+		return wrapError(err)
+	}
+	s.publish(ctx, OperationUpdate, []*T{before}, []*T{obj})
+	return runHooks(ctx, s.hooks.afterUpdate, obj)
+}
+
+// UpdateWhere updates the given columns of every object matching the provided where
+// options without loading them first, and returns the number of rows affected.
+// Keys of fields are column names. Unlike Update, columns not present in fields are left untouched.
+func (s *Store[T]) UpdateWhere(ctx context.Context, opts *where.Options, fields map[string]any) (affected int64, err error) {
+	ctx, op := s.begin(ctx, "UpdateWhere")
+	defer func() { op.end(err) }()
+
+	before, err := s.snapshotWhere(ctx, opts, false)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve objects state before update", "conditions", opts)
+		return 0, wrapError(err)
+	}
+
+	fields = s.auditFields(ctx, fields)
+	collect := func() {}
+	err = s.tracked(ctx, OperationUpdate, opts, false, func(ctx context.Context) error {
+		return s.retry(ctx, func() error {
+			var db *gorm.DB
+			db, collect = s.returningModel(ctx, s.db(ctx, opts))
+			result := db.Updates(fields)
+			affected = result.RowsAffected
+			return result.Error
+		})
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to update objects in database", "conditions", opts, "fields", fields)
+		return 0, wrapError(err)
+	}
+	collect()
+	s.publishChanges(ctx, before)
+	return affected, nil
+}
+
+// UpdateMany sets the given columns of every object matching the provided where options
+// in a single statement and returns the number of rows affected, for batch state
+// transitions such as closing all expired orders. It is equivalent to UpdateWhere.
+// Conditions are required: GORM refuses to update a whole table.
+func (s *Store[T]) UpdateMany(ctx context.Context, opts *where.Options, fields map[string]any) (int64, error) {
+	return s.UpdateWhere(ctx, opts, fields)
+}
+
+// UpdateExpr sets column to the SQL expression expr, evaluated by the database, on
+// every object matching the provided where options and returns the number of rows
+// affected, e.g. UpdateExpr(ctx, opts, "view_count", "view_count + ?", 1). Counters
+// are thus updated atomically, without read-modify-write races.
+// The statement is not retried, as it may not be idempotent.
+func (s *Store[T]) UpdateExpr(ctx context.Context, opts *where.Options, column string, expr string, args ...any) (affected int64, err error) {
+	ctx, op := s.begin(ctx, "UpdateExpr")
+	defer func() { op.end(err) }()
+
+	before, err := s.snapshotWhere(ctx, opts, false)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve objects state before update", "conditions", opts)
+		return 0, wrapError(err)
+	}
+
+	fields := s.auditFields(ctx, map[string]any{column: gorm.Expr(expr, args...)})
+	collect := func() {}
+	err = s.tracked(ctx, OperationUpdate, opts, false, func(ctx context.Context) error {
+		var db *gorm.DB
+		db, collect = s.returningModel(ctx, s.db(ctx, opts))
+		result := db.Updates(fields)
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to update objects in database",
+			"conditions", opts, "column", column, "expr", expr)
+		return 0, wrapError(err)
+	}
+	collect()
+	s.publishChanges(ctx, before)
+	return affected, nil
+}
+
+// Increment atomically adds delta, which may be negative, to column on every object
+// matching the provided where options and returns the number of rows affected.
+func (s *Store[T]) Increment(ctx context.Context, opts *where.Options, column string, delta int64) (int64, error) {
+	return s.UpdateExpr(ctx, opts, column, "? + ?", clause.Column{Name: column}, delta)
 }
 
 // Delete removes an object from the database based on the provided where options.
-func (s *Store[T]) Delete(ctx context.Context, opts *where.Options) error {
-	err := s.db(ctx, opts).Delete(new(T)).Error
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+func (s *Store[T]) Delete(ctx context.Context, opts *where.Options) (err error) {
+	ctx, op := s.begin(ctx, "Delete")
+	defer func() { op.end(err) }()
+
+	if err := runDeleteHooks(ctx, s.hooks.beforeDelete, opts); err != nil {
+		return err
+	}
+
+	before, err := s.snapshotWhere(ctx, opts, false)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve objects state before delete", "conditions", opts)
+		return wrapError(err)
+	}
+
+	if _, err := s.remove(ctx, opts); err != nil {
 		s.logger.Error(ctx, err, "Failed to delete object from database", "conditions", opts)
+		return wrapError(err)
+	}
+	s.publish(ctx, OperationDelete, before, nil)
+	return runDeleteHooks(ctx, s.hooks.afterDelete, opts)
+}
+
+// remove deletes the objects matching opts, softly when T supports it or the store
+// is configured with WithSoftDelete, and returns the number of rows affected.
+func (s *Store[T]) remove(ctx context.Context, opts *where.Options) (affected int64, err error) {
+	err = s.tracked(ctx, OperationDelete, opts, opts != nil && opts.Unscoped, func(ctx context.Context) (err error) {
+		affected, err = s.deleteRows(ctx, opts)
 		return err
+	})
+	return affected, err
+}
+
+// deleteRows deletes the objects matching opts like remove, without recording their history.
+func (s *Store[T]) deleteRows(ctx context.Context, opts *where.Options) (affected int64, err error) {
+	if s.softDeletion != nil && (opts == nil || !opts.Unscoped) {
+		return s.markDeleted(ctx, opts)
 	}
-	return nil
+
+	audited, affected, err := s.softDelete(ctx, opts)
+	if !audited {
+		collect := func() {}
+		err = s.retry(ctx, func() error {
+			var db *gorm.DB
+			db, collect = s.returningModel(ctx, s.db(ctx, opts))
+			result := db.Delete(db.Statement.Model)
+			affected = result.RowsAffected
+			return result.Error
+		})
+		if err == nil {
+			collect()
+		}
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// Purge permanently removes the objects matching the provided where options.
+// Unlike Delete, it bypasses soft delete and issues a real DELETE statement.
+func (s *Store[T]) Purge(ctx context.Context, opts *where.Options) (err error) {
+	ctx, op := s.begin(ctx, "Purge")
+	defer func() { op.end(err) }()
+
+	if err := runDeleteHooks(ctx, s.hooks.beforeDelete, opts); err != nil {
+		return err
+	}
+
+	before, err := s.snapshotWhere(ctx, opts, true)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve objects state before purge", "conditions", opts)
+		return wrapError(err)
+	}
+
+	err = s.tracked(ctx, OperationDelete, opts, true, func(ctx context.Context) error {
+		return s.retry(ctx, func() error { return s.db(ctx, opts).Unscoped().Delete(new(T)).Error })
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error(ctx, err, "Failed to purge object from database", "conditions", opts)
+		return wrapError(err)
+	}
+	s.publish(ctx, OperationDelete, before, nil)
+	return runDeleteHooks(ctx, s.hooks.afterDelete, opts)
+}
+
+// Restore brings back the soft-deleted objects matching the provided where options by
+// clearing their soft delete column, and returns the number of rows restored.
+// The soft delete column is resolved from the model or from WithSoftDelete, so custom
+// column names and strategies are supported.
+func (s *Store[T]) Restore(ctx context.Context, opts *where.Options) (restored int64, err error) {
+	ctx, op := s.begin(ctx, "Restore")
+	defer func() { op.end(err) }()
+
+	if s.softDeletion != nil {
+		restored, err = s.unmarkDeleted(ctx, opts)
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to restore objects in database", "conditions", opts)
+			return 0, wrapError(err)
+		}
+		return restored, nil
+	}
+
+	field, err := softDeleteField[T](s.db(ctx))
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to restore objects in database", "conditions", opts)
+		return 0, err
+	}
+
+	column := clause.Column{Name: field.DBName}
+	err = s.retry(ctx, func() error {
+		result := s.db(ctx, opts).Unscoped().Model(new(T)).
+			Where(clause.Neq{Column: column, Value: nil}).Update(field.DBName, nil)
+		restored = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to restore objects in database", "conditions", opts)
+		return 0, wrapError(err)
+	}
+	return restored, nil
 }
 
 // Get retrieves a single object from the database based on the provided where options.
-func (s *Store[T]) Get(ctx context.Context, opts *where.Options) (*T, error) {
-	var obj T
-	if err := s.db(ctx, opts).First(&obj).Error; err != nil {
+// It returns an error matching ErrNotFound when no object matches the conditions.
+func (s *Store[T]) Get(ctx context.Context, opts *where.Options) (ret *T, err error) {
+	ctx, op := s.begin(ctx, "Get")
+	defer func() { op.end(err) }()
+
+	if ret, err = s.get(ctx, opts); err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object from database", "conditions", opts)
+		return nil, wrapError(err)
+	}
+	return ret, nil
+}
+
+// GetOrNil retrieves a single object like Get, but returns nil without error when no
+// object matches, for callers considering an absent object as a normal state.
+func (s *Store[T]) GetOrNil(ctx context.Context, opts *where.Options) (ret *T, err error) {
+	ctx, op := s.begin(ctx, "GetOrNil")
+	defer func() { op.end(err) }()
+
+	ret, err = s.get(ctx, opts)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
 		s.logger.Error(ctx, err, "Failed to retrieve object from database", "conditions", opts)
-		return nil, err
+		return nil, wrapError(err)
 	}
-	return &obj, nil
+	return ret, nil
+}
+
+// get retrieves the first object matching opts.
+func (s *Store[T]) get(ctx context.Context, opts *where.Options) (*T, error) {
+	return cachedQuery(ctx, s, "Get", opts, func() (*T, error) {
+		var obj T
+		return &obj, s.retry(ctx, func() error { return s.reader(ctx, opts).First(&obj).Error })
+	}, func(obj *T) *T { return cloneObjects([]*T{obj})[0] })
 }
 
 // List retrieves a list of objects from the database based on the provided where options.
+// The returned count is the number of objects matching opts regardless of pagination,
+// or -1 when opts skips counting with where.NoCount. Objects are sorted in the default
+// order of the store (see WithDefaultOrder) when opts specifies none.
 func (s *Store[T]) List(ctx context.Context, opts *where.Options) (count int64, ret []*T, err error) {
-	db := s.db(ctx, opts)
+	ctx, op := s.begin(ctx, "List")
+	defer func() { op.end(err) }()
+
+	page, err := cachedQuery(ctx, s, "List", opts, func() (listPage[T], error) {
+		return listRows[T, T](ctx, s, opts)
+	}, func(page listPage[T]) listPage[T] {
+		return listPage[T]{count: page.count, objs: cloneObjects(page.objs)}
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to list objects from database", "conditions", opts)
+		return 0, nil, wrapError(err)
+	}
+	return page.count, page.objs, nil
+}
+
+// listPage holds the objects retrieved by List with their count.
+type listPage[T any] struct {
+	count int64
+	objs  []*T
+}
+
+// listRows retrieves the objects of s matching opts into rows of type D, which is
+// either T or a projection of T, and their count.
+func listRows[T, D any](ctx context.Context, s *Store[T], opts *where.Options) (page listPage[D], err error) {
+	err = s.retry(ctx, func() error {
+		db := s.reader(ctx, opts).Model(new(T))
+		if reflect.TypeFor[D]() != reflect.TypeFor[T]() && len(db.Statement.Selects) == 0 {
+			columns, err := projectedColumns[T, D](db)
+			if err != nil {
+				return err
+			}
+			db = db.Select(columns)
+		}
+
+		// Apply default sorting if no order is specified in options
+		// Check if opts is nil or order is not set
+		orderIsEmpty := opts == nil || (opts.Order == "" && len(opts.Sorts) == 0)
+		if orderIsEmpty {
+			db = s.orderByDefault(db)
+		}
+
+		result := db.Find(&page.objs)
+		if opts != nil && opts.SkipCount {
+			page.count = -1
+			return result.Error
+		}
+		if estimate, ok := s.estimateCount(ctx, opts); ok && result.Error == nil {
+			page.count = estimate
+			return nil
+		}
+		if result.DryRun {
+			// Dry runs keep the statement built by Find, which Count would reuse.
+			result.Statement.SQL.Reset()
+			result.Statement.Vars = nil
+		}
+		if !result.Statement.Distinct {
+			// Count the rows rather than the non NULL values of a selected column.
+			result.Statement.Selects = nil
+		}
+		return result.Offset(-1).Limit(-1).Count(&page.count).Error
+	})
+	return page, err
+}
 
-	// Apply default sorting if no order is specified in options
-	// Check if opts is nil or order is not set
-	orderIsEmpty := opts == nil || opts.Order == ""
-	if orderIsEmpty {
-		db = db.Order("id desc")
+// Count returns the number of objects matching the provided where options.
+// Pagination in opts is ignored and no rows are fetched.
+func (s *Store[T]) Count(ctx context.Context, opts *where.Options) (count int64, err error) {
+	ctx, op := s.begin(ctx, "Count")
+	defer func() { op.end(err) }()
+
+	count, err = cachedQuery(ctx, s, "Count", opts, func() (count int64, err error) {
+		if estimate, ok := s.estimateCount(ctx, opts); ok {
+			return estimate, nil
+		}
+		err = s.retry(ctx, func() error {
+			return s.reader(ctx, opts).Model(new(T)).Offset(-1).Limit(-1).Count(&count).Error
+		})
+		return count, err
+	}, func(count int64) int64 { return count })
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to count objects in database", "conditions", opts)
+		return 0, wrapError(err)
 	}
+	return count, nil
+}
+
+// Exists reports whether at least one object matches the provided where options.
+// It issues a SELECT 1 ... LIMIT 1 query and does not hydrate any object.
+func (s *Store[T]) Exists(ctx context.Context, opts *where.Options) (exists bool, err error) {
+	ctx, op := s.begin(ctx, "Exists")
+	defer func() { op.end(err) }()
 
-	err = db.Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	err = s.retry(ctx, func() error {
+		var found int
+		result := s.reader(ctx, opts).Model(new(T)).Select("1").Offset(-1).Limit(1).Find(&found)
+		exists = result.RowsAffected > 0
+		return result.Error
+	})
 	if err != nil {
-		s.logger.Error(ctx, err, "Failed to list objects from database", "conditions", opts)
+		s.logger.Error(ctx, err, "Failed to check object existence in database", "conditions", opts)
+		return false, wrapError(err)
+	}
+	return exists, nil
+}
+
+// GetOrCreate retrieves the object matching the provided where options, creating obj
+// when no object matches. The returned bool reports whether obj was created.
+// When a concurrent caller inserts the same row first, the unique constraint violation
+// is detected and the row created by the other caller is returned instead, so the
+// conditions should be covered by a unique index for the operation to be race free.
+func (s *Store[T]) GetOrCreate(ctx context.Context, opts *where.Options, obj *T) (ret *T, created bool, err error) {
+	ctx, op := s.begin(ctx, "GetOrCreate")
+	defer func() { op.end(err) }()
+
+	var existing T
+	err = s.db(ctx, opts).First(&existing).Error
+	if err == nil {
+		return &existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error(ctx, err, "Failed to retrieve object from database", "conditions", opts)
+		return nil, false, wrapError(err)
+	}
+
+	if err := runHooks(ctx, s.hooks.beforeCreate, obj); err != nil {
+		return nil, false, err
+	}
+	if err := s.fillTenant(ctx, obj); err != nil {
+		return nil, false, err
 	}
-	return
+	if err := s.fillAudit(ctx, true, obj); err != nil {
+		return nil, false, err
+	}
+
+	// The insert runs in its own (nested) transaction so that a conflict does not
+	// abort an enclosing PostgreSQL transaction before the row is fetched again.
+	err = s.db(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.returning(ctx, tx).Create(obj).Error
+	})
+	if err == nil {
+		s.collectReturned(ctx, obj)
+		s.publish(ctx, OperationCreate, nil, []*T{obj})
+		return obj, true, runHooks(ctx, s.hooks.afterCreate, obj)
+	}
+	if !isDuplicateKeyError(err) {
+		s.logger.Error(ctx, err, "Failed to insert object into database", "object", obj)
+		return nil, false, wrapError(err)
+	}
+
+	// Lost the race against a concurrent insert, return the winning row.
+	if err := s.db(ctx, opts).First(&existing).Error; err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object from database", "conditions", opts)
+		return nil, false, wrapError(err)
+	}
+	return &existing, false, nil
+}
+
+// Each iterates over the objects matching the provided where options in batches of
+// batchSize objects ordered by primary key, calling fn for every batch. The batch slice
+// is reused between calls, so fn must not retain it. Iteration stops at the first error
+// returned by fn or when ctx is canceled. Order carried by opts is ignored.
+func (s *Store[T]) Each(ctx context.Context, opts *where.Options, batchSize int, fn func([]*T) error) (err error) {
+	ctx, op := s.begin(ctx, "Each")
+	defer func() { op.end(err) }()
+
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var whr where.Options
+	if opts != nil {
+		whr = *opts
+	}
+	whr.Order, whr.Sorts = "", nil
+
+	var batch []*T
+	err = s.reader(ctx, &whr).FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(batch)
+	}).Error
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to iterate objects from database", "conditions", opts)
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Pluck queries a single column of the objects matching the provided where options
+// and scans the values into dest, which must be a pointer to a slice.
+// Combine it with where.D(true) to fetch distinct values only.
+func (s *Store[T]) Pluck(ctx context.Context, column string, dest any, opts *where.Options) (err error) {
+	ctx, op := s.begin(ctx, "Pluck")
+	defer func() { op.end(err) }()
+
+	if err := s.retry(ctx, func() error { return s.reader(ctx, opts).Model(new(T)).Pluck(column, dest).Error }); err != nil {
+		s.logger.Error(ctx, err, "Failed to pluck column from database", "column", column, "conditions", opts)
+		return wrapError(err)
+	}
+	return nil
 }
@@ -0,0 +1,2483 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/miladystack/miladystack/pkg/store/where"
+)
+
+type testUser struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:255"`
+	Email     string `gorm:"size:255;uniqueIndex"`
+	Age       int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type testProvider struct {
+	db *gorm.DB
+}
+
+func (p *testProvider) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return p.db.WithContext(ctx)
+}
+
+// newTestStore creates a Store backed by a private in-memory SQLite database.
+func newTestStore(t *testing.T) (*Store[testUser], *testProvider) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open sqlite: %v", err)
+	}
+	// Every connection to :memory: opens a new database, keep a single one.
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&testUser{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	provider := &testProvider{db: db}
+	return NewStore[testUser](provider), provider
+}
+
+func TestGetNotFound(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	_, err := s.Get(context.Background(), where.F("id", 42))
+	if !IsNotFound(err) {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected error to match both ErrNotFound and gorm.ErrRecordNotFound")
+	}
+}
+
+func TestGetOrNil(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if user, err := s.GetOrNil(ctx, where.F("id", 42)); user != nil || err != nil {
+		t.Errorf("Expected nil without error, got %+v, %v", user, err)
+	}
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if user, err := s.GetOrNil(ctx, where.F("name", "alice")); user == nil || err != nil {
+		t.Errorf("Expected alice, got %+v, %v", user, err)
+	}
+}
+
+func TestCreateDuplicateKey(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Create(ctx, &testUser{Name: "a", Email: "a@example.com"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	err := s.Create(ctx, &testUser{Name: "b", Email: "a@example.com"})
+	if !IsDuplicateKey(err) {
+		t.Fatalf("Expected duplicate key error, got %v", err)
+	}
+	if IsNotFound(err) {
+		t.Errorf("Duplicate key error should not be classified as not found")
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	errAbort := errors.New("abort")
+	err := s.Tx(ctx, func(txCtx context.Context) error {
+		if err := s.Create(txCtx, &testUser{Name: "tx", Email: "tx@example.com"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected abort error, got %v", err)
+	}
+
+	count, _, err := s.List(ctx, where.F("email", "tx@example.com"))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected rolled back insert, got %d rows", count)
+	}
+}
+
+func TestCreateBatch(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	users := make([]*testUser, 0, 5)
+	for _, email := range []string{"1@x.io", "2@x.io", "3@x.io", "4@x.io", "2@x.io"} {
+		users = append(users, &testUser{Email: email})
+	}
+
+	inserted, err := s.CreateBatch(ctx, users, 2)
+	if !IsDuplicateKey(err) {
+		t.Fatalf("Expected duplicate key error from last batch, got %v", err)
+	}
+	if inserted != 4 {
+		t.Errorf("Expected 4 rows inserted before failure, got %d", inserted)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Upsert(ctx, &testUser{Name: "old", Email: "u@x.io"}, []string{"email"}, []string{"name"}); err != nil {
+		t.Fatalf("Upsert insert failed: %v", err)
+	}
+	if err := s.Upsert(ctx, &testUser{Name: "new", Email: "u@x.io"}, []string{"email"}, []string{"name"}); err != nil {
+		t.Fatalf("Upsert update failed: %v", err)
+	}
+
+	count, users, err := s.List(ctx, where.F("email", "u@x.io"))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if count != 1 || users[0].Name != "new" {
+		t.Errorf("Expected a single updated row, got %d rows", count)
+	}
+}
+
+func TestUpsertBatch(t *testing.T) {
+	s, provider := newTestStore(t)
+	ctx := context.Background()
+
+	var statements atomic.Int64
+	if err := provider.db.Callback().Create().Before("gorm:create").Register("test:count", func(db *gorm.DB) {
+		statements.Add(1)
+	}); err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	users := make([]*testUser, 150)
+	for i := range users {
+		users[i] = &testUser{Name: "old", Email: fmt.Sprintf("%d@x.io", i)}
+	}
+	if _, err := s.UpsertBatch(ctx, users, []string{"email"}, []string{"name"}); err != nil {
+		t.Fatalf("UpsertBatch insert failed: %v", err)
+	}
+	if statements.Load() != 2 {
+		t.Errorf("Expected a statement per batch of 100 objects, got %d", statements.Load())
+	}
+
+	// Existing rows are updated, new ones inserted.
+	users = []*testUser{{Name: "new", Email: "0@x.io", Age: 40}, {Name: "new", Email: "150@x.io"}}
+	if _, err := s.UpsertBatch(ctx, users, []string{"email"}, []string{"name"}); err != nil {
+		t.Fatalf("UpsertBatch update failed: %v", err)
+	}
+	if count, _ := s.Count(ctx, nil); count != 151 {
+		t.Errorf("Expected 151 rows, got %d", count)
+	}
+	user, err := s.Get(ctx, where.F("email", "0@x.io"))
+	if err != nil || user.Name != "new" || user.Age != 0 {
+		t.Errorf("Expected only the name of the conflicting row to be updated, got %+v, %v", user, err)
+	}
+}
+
+func TestCount(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"1@x.io", "2@x.io", "3@x.io"} {
+		if err := s.Create(ctx, &testUser{Name: "n", Email: email}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	count, err := s.Count(ctx, where.F("name", "n").P(1, 1))
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected count 3 ignoring pagination, got %d", count)
+	}
+
+	if count, _ = s.Count(ctx, nil); count != 3 {
+		t.Errorf("Expected count 3 without conditions, got %d", count)
+	}
+}
+
+func TestExists(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Create(ctx, &testUser{Name: "e", Email: "e@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if ok, err := s.Exists(ctx, where.F("email", "e@x.io")); err != nil || !ok {
+		t.Errorf("Expected existing email, got %v, %v", ok, err)
+	}
+	if ok, err := s.Exists(ctx, where.F("email", "none@x.io")); err != nil || ok {
+		t.Errorf("Expected missing email, got %v, %v", ok, err)
+	}
+}
+
+func TestGetOrCreate(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	user, created, err := s.GetOrCreate(ctx, where.F("email", "g@x.io"), &testUser{Name: "first", Email: "g@x.io"})
+	if err != nil || !created {
+		t.Fatalf("Expected object to be created, got %v, %v", created, err)
+	}
+
+	again, created, err := s.GetOrCreate(ctx, where.F("email", "g@x.io"), &testUser{Name: "second", Email: "g@x.io"})
+	if err != nil || created {
+		t.Fatalf("Expected existing object, got %v, %v", created, err)
+	}
+	if again.ID != user.ID || again.Name != "first" {
+		t.Errorf("Expected existing object %d, got %+v", user.ID, again)
+	}
+}
+
+func TestUpdateWhere(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"1@x.io", "2@x.io", "3@x.io"} {
+		if err := s.Create(ctx, &testUser{Name: "n", Email: email, Age: 10}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	affected, err := s.UpdateWhere(ctx, where.F("name", "n").Q("email <> ?", "3@x.io"), map[string]any{"age": 20})
+	if err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 rows affected, got %d", affected)
+	}
+
+	user, err := s.Get(ctx, where.F("email", "1@x.io"))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if user.Age != 20 || user.Name != "n" {
+		t.Errorf("Expected only age to be updated, got %+v", user)
+	}
+
+	if affected, err := s.UpdateMany(ctx, where.F("age", 10), map[string]any{"age": 30}); err != nil || affected != 1 {
+		t.Errorf("Expected UpdateMany to update 1 row, got %d, %v", affected, err)
+	}
+	if _, err := s.UpdateMany(ctx, nil, map[string]any{"age": 0}); err == nil {
+		t.Errorf("Expected UpdateMany without conditions to be refused")
+	}
+}
+
+func TestUpdateNonZero(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	user := &testUser{Name: "z", Email: "z@x.io", Age: 30}
+	if err := s.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := s.UpdateNonZero(ctx, &testUser{ID: user.ID, Name: "renamed"}); err != nil {
+		t.Fatalf("UpdateNonZero failed: %v", err)
+	}
+	got, _ := s.Get(ctx, where.F("id", user.ID))
+	if got.Name != "renamed" || got.Age != 30 || got.Email != "z@x.io" {
+		t.Errorf("Expected only the name to be updated, got %+v", got)
+	}
+
+	if err := s.UpdateNonZero(ctx, &testUser{ID: user.ID}, "age"); err != nil {
+		t.Fatalf("UpdateNonZero failed: %v", err)
+	}
+	got, _ = s.Get(ctx, where.F("id", user.ID))
+	if got.Age != 0 || got.Name != "renamed" {
+		t.Errorf("Expected the selected age to be cleared, got %+v", got)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Create(ctx, &testUser{Name: "c", Email: "c@x.io", Age: 10}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if affected, err := s.Increment(ctx, where.F("email", "c@x.io"), "age", 5); err != nil || affected != 1 {
+		t.Fatalf("Expected Increment to update 1 row, got %d, %v", affected, err)
+	}
+	if _, err := s.Increment(ctx, where.F("email", "c@x.io"), "age", -2); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if _, err := s.UpdateExpr(ctx, where.F("email", "c@x.io"), "age", "age * ?", 2); err != nil {
+		t.Fatalf("UpdateExpr failed: %v", err)
+	}
+
+	user, err := s.Get(ctx, where.F("email", "c@x.io"))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if user.Age != 26 {
+		t.Errorf("Expected age 26, got %d", user.Age)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	user := &testUser{Name: "r", Email: "r@x.io"}
+	if err := s.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := s.Delete(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(ctx, where.F("id", user.ID)); !IsNotFound(err) {
+		t.Fatalf("Expected soft-deleted object to be hidden, got %v", err)
+	}
+
+	restored, err := s.Restore(ctx, where.F("id", user.ID))
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored != 1 {
+		t.Errorf("Expected 1 row restored, got %d", restored)
+	}
+	if _, err := s.Get(ctx, where.F("id", user.ID)); err != nil {
+		t.Errorf("Expected restored object to be visible, got %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	user := &testUser{Name: "p", Email: "p@x.io"}
+	if err := s.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := s.Purge(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if _, err := s.Get(ctx, where.F("id", user.ID).U(true)); !IsNotFound(err) {
+		t.Errorf("Expected purged object to be gone even unscoped, got %v", err)
+	}
+}
+
+func TestListByCursor(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := s.Create(ctx, &testUser{Name: "c", Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	var ids []uint
+	cursor := ""
+	for page := 0; ; page++ {
+		users, next, err := s.ListByCursor(ctx, where.F("name", "c"), cursor, 2)
+		if err != nil {
+			t.Fatalf("ListByCursor failed: %v", err)
+		}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if page == 0 {
+			// Rows inserted while iterating must not show up in later pages.
+			if err := s.Create(ctx, &testUser{Name: "c", Email: "late@x.io"}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" {
+		t.Errorf("Expected ids [5 4 3 2 1], got %v", ids)
+	}
+
+	if _, _, err := s.ListByCursor(ctx, nil, "not-a-cursor", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestEach(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		if err := s.Create(ctx, &testUser{Name: "each", Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	var sizes []int
+	err := s.Each(ctx, where.F("name", "each"), 2, func(batch []*testUser) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each failed: %v", err)
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Errorf("Expected batch sizes [2 2 1], got %v", sizes)
+	}
+
+	calls := 0
+	err = s.Each(ctx, nil, 2, func([]*testUser) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Expected iteration to stop after cancel, got %d calls and %v", calls, err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i, age := range []int{10, 20, 30} {
+		if err := s.Create(ctx, &testUser{Name: "agg", Email: fmt.Sprintf("%d@x.io", i), Age: age}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	cases := map[Agg]float64{AggSum: 60, AggAvg: 20, AggMin: 10, AggMax: 30}
+	for agg, want := range cases {
+		got, err := s.Aggregate(ctx, where.F("name", "agg").P(1, 1).Or("name asc"), agg, "age")
+		if err != nil {
+			t.Fatalf("Aggregate %s failed: %v", agg, err)
+		}
+		if got != want {
+			t.Errorf("Expected %s(age) = %v, got %v", agg, want, got)
+		}
+	}
+
+	if got, err := s.Aggregate(ctx, where.F("name", "none"), AggSum, "age"); err != nil || got != 0 {
+		t.Errorf("Expected 0 without matching rows, got %v, %v", got, err)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i, name := range []string{"a", "b", "a", "c", "a", "b"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i), Age: 10 * (i + 1)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	var rows []struct {
+		Name  string
+		Total int64
+		Ages  int
+	}
+	err := s.GroupBy(ctx, where.Having("COUNT(*) > ?", 1).Or("total desc"),
+		[]string{"name"}, []string{"name", "COUNT(*) AS total", "SUM(age) AS ages"}, &rows)
+	if err != nil {
+		t.Fatalf("GroupBy failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "a" || rows[0].Total != 3 || rows[0].Ages != 90 || rows[1].Name != "b" {
+		t.Errorf("Expected groups a and b, got %+v", rows)
+	}
+
+	var names []map[string]any
+	if err := s.GroupBy(ctx, where.F("age", []int{10, 20}), []string{"name"}, nil, &names); err != nil || len(names) != 2 {
+		t.Errorf("Expected 2 groups, got %v, %v", names, err)
+	}
+}
+
+func TestPluck(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i, name := range []string{"a", "b", "a"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	var names []string
+	if err := s.Pluck(ctx, "name", &names, where.D(true).Or("name asc")); err != nil {
+		t.Fatalf("Pluck failed: %v", err)
+	}
+	if fmt.Sprint(names) != "[a b]" {
+		t.Errorf("Expected distinct names [a b], got %v", names)
+	}
+}
+
+type testCustomer struct {
+	ID     uint `gorm:"primaryKey"`
+	Name   string
+	Orders []testOrder `gorm:"foreignKey:CustomerID"`
+}
+
+type testOrder struct {
+	ID         uint `gorm:"primaryKey"`
+	CustomerID uint
+	Customer   *testCustomer
+	Status     string
+	Items      []testItem `gorm:"foreignKey:OrderID"`
+}
+
+type testItem struct {
+	ID      uint `gorm:"primaryKey"`
+	OrderID uint
+	SKU     string
+}
+
+func TestPreload(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&testCustomer{}, &testOrder{}, &testItem{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	customers := NewStore[testCustomer](provider)
+	orders := NewStore[testOrder](provider)
+	ctx := context.Background()
+
+	alice := &testCustomer{Name: "alice", Orders: []testOrder{
+		{Status: "paid", Items: []testItem{{SKU: "a1"}, {SKU: "a2"}}},
+		{Status: "pending", Items: []testItem{{SKU: "a3"}}},
+	}}
+	bob := &testCustomer{Name: "bob", Orders: []testOrder{{Status: "paid", Items: []testItem{{SKU: "b1"}}}}}
+	if err := provider.db.Create([]*testCustomer{alice, bob}).Error; err != nil {
+		t.Fatalf("Failed to create fixtures: %v", err)
+	}
+
+	// Has many association through Get.
+	got, err := customers.Get(ctx, where.F("id", alice.ID).Preload("Orders"))
+	if err != nil || len(got.Orders) != 2 {
+		t.Fatalf("Expected the 2 orders of alice, got %+v, %v", got, err)
+	}
+	if got.Orders[0].Items != nil {
+		t.Errorf("Expected items not to be loaded without nested preload, got %+v", got.Orders[0].Items)
+	}
+
+	// Belongs to association through Get.
+	order, err := orders.Get(ctx, where.F("id", bob.Orders[0].ID).Preload("Customer"))
+	if err != nil || order.Customer == nil || order.Customer.Name != "bob" {
+		t.Errorf("Expected the order of bob with its customer, got %+v, %v", order, err)
+	}
+
+	// Nested path through List.
+	_, list, err := customers.List(ctx, where.Preload("Orders.Items").Or("id asc"))
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Expected 2 customers, got %v, %v", list, err)
+	}
+	var skus []string
+	for _, o := range list[0].Orders {
+		for _, item := range o.Items {
+			skus = append(skus, item.SKU)
+		}
+	}
+	if slices.Sort(skus); fmt.Sprint(skus) != "[a1 a2 a3]" || len(list[1].Orders) != 1 || len(list[1].Orders[0].Items) != 1 {
+		t.Errorf("Expected the orders and items of every customer, got %v and %+v", skus, list[1].Orders)
+	}
+
+	// Conditional preload through List and Get.
+	_, list, err = customers.List(ctx, where.PreloadWhere("Orders", "status = ?", "pending").Or("id asc"))
+	if err != nil || len(list) != 2 || len(list[0].Orders) != 1 || list[0].Orders[0].Status != "pending" || len(list[1].Orders) != 0 {
+		t.Errorf("Expected only the pending order of alice, got %+v, %v", list, err)
+	}
+	got, err = customers.Get(ctx, where.F("id", alice.ID).PreloadWhere("Orders", "status = ?", "paid"))
+	if err != nil || len(got.Orders) != 1 || got.Orders[0].Status != "paid" {
+		t.Errorf("Expected only the paid order of alice, got %+v, %v", got, err)
+	}
+}
+
+func TestListWithJoin(t *testing.T) {
+	s, provider := newTestStore(t)
+	ctx := context.Background()
+
+	if err := provider.db.Exec("CREATE TABLE profiles (user_id INTEGER, city TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create profiles table: %v", err)
+	}
+	for i, city := range []string{"paris", "tokyo", "paris"} {
+		user := &testUser{Name: "j", Email: fmt.Sprintf("%d@x.io", i)}
+		if err := s.Create(ctx, user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		provider.db.Exec("INSERT INTO profiles (user_id, city) VALUES (?, ?)", user.ID, city)
+	}
+
+	count, users, err := s.List(ctx, where.Join("LEFT JOIN profiles ON profiles.user_id = test_users.id").
+		F("profiles.city", "paris"))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if count != 2 || len(users) != 2 || users[0].ID != 3 {
+		t.Errorf("Expected users [3 1] living in paris, got %d users", count)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	var calls []string
+	errRejected := errors.New("rejected")
+	s.OnBeforeCreate(func(ctx context.Context, u *testUser) error {
+		calls = append(calls, "before:"+u.Email)
+		if u.Email == "" {
+			return errRejected
+		}
+		return nil
+	}).OnAfterCreate(func(ctx context.Context, u *testUser) error {
+		calls = append(calls, "after:"+u.Email)
+		return nil
+	}).OnAfterDelete(func(ctx context.Context, opts *where.Options) error {
+		calls = append(calls, "deleted")
+		return nil
+	})
+
+	if err := s.Create(ctx, &testUser{}); !errors.Is(err, errRejected) {
+		t.Fatalf("Expected before hook to reject object, got %v", err)
+	}
+	if err := s.Create(ctx, &testUser{Email: "h@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := s.Delete(ctx, where.F("email", "h@x.io")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if got := fmt.Sprint(calls); got != "[before: before:h@x.io after:h@x.io deleted]" {
+		t.Errorf("Unexpected hook calls %s", got)
+	}
+}
+
+func TestChangePublisher(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+
+	events := make(chan Event[testUser], 10)
+	s := NewStore[testUser](provider, WithChangePublisher(ChannelPublisher(events)))
+
+	user := &testUser{Name: "before", Email: "ev@x.io"}
+	if err := s.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	user.Name = "after"
+	if err := s.Update(ctx, user); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := s.Delete(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// Events of a rolled back transaction are dropped.
+	_ = s.Tx(ctx, func(txCtx context.Context) error {
+		_ = s.Create(txCtx, &testUser{Email: "rollback@x.io"})
+		return errors.New("rollback")
+	})
+
+	close(events)
+	var got []string
+	for event := range events {
+		desc := string(event.Operation)
+		if event.Before != nil {
+			desc += " " + event.Before.Name
+		}
+		if event.After != nil {
+			desc += " " + event.After.Name
+		}
+		got = append(got, desc)
+	}
+	if fmt.Sprint(got) != "[create before update before after delete after]" {
+		t.Errorf("Unexpected events %v", got)
+	}
+}
+
+func TestChangePublisherBulkAndUpsert(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+
+	events := make(chan Event[testUser], 10)
+	s := NewStore[testUser](provider, WithChangePublisher(ChannelPublisher(events)))
+	describe := func() []string {
+		var got []string
+		for len(events) > 0 {
+			event := <-events
+			desc := string(event.Operation)
+			if event.Before != nil {
+				desc += fmt.Sprintf(" %s/%d", event.Before.Name, event.Before.Age)
+			}
+			if event.After != nil {
+				desc += fmt.Sprintf(" %s/%d", event.After.Name, event.After.Age)
+			}
+			got = append(got, desc)
+		}
+		return got
+	}
+
+	if err := s.Upsert(ctx, &testUser{Name: "alice", Email: "up@x.io", Age: 20}, []string{"email"}, nil); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := s.Upsert(ctx, &testUser{Name: "ignored", Email: "up@x.io", Age: 21}, []string{"email"}, []string{"age"}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if got := describe(); fmt.Sprint(got) != "[create alice/20 update alice/20 alice/21]" {
+		t.Errorf("Unexpected upsert events %v", got)
+	}
+
+	_, err := s.UpsertBatch(ctx, []*testUser{
+		{Name: "alice", Email: "up@x.io", Age: 22},
+		{Name: "bob", Email: "bob@x.io", Age: 30},
+	}, []string{"email"}, []string{"age"})
+	if err != nil {
+		t.Fatalf("UpsertBatch failed: %v", err)
+	}
+	if got := describe(); fmt.Sprint(got) != "[create bob/30 update alice/21 alice/22]" {
+		t.Errorf("Unexpected batch upsert events %v", got)
+	}
+
+	if _, err := s.UpdateWhere(ctx, where.F("email", "up@x.io"), map[string]any{"name": "alicia"}); err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if _, err := s.Increment(ctx, where.F("email", "up@x.io"), "age", 1); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if got := describe(); fmt.Sprint(got) != "[update alice/22 alicia/22 update alicia/22 alicia/23]" {
+		t.Errorf("Unexpected update events %v", got)
+	}
+}
+
+func TestReplicaProvider(t *testing.T) {
+	_, primary := newTestStore(t)
+	_, replica := newTestStore(t)
+	ctx := context.Background()
+
+	s := NewStore[testUser](NewReplicaProvider(primary.db, []*gorm.DB{replica.db}))
+	if err := s.Create(ctx, &testUser{Email: "rw@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// The replica has not received the row, reads served by it see nothing.
+	if count, _ := s.Count(ctx, nil); count != 0 {
+		t.Errorf("Expected read to be served by the replica, got %d rows", count)
+	}
+	if count, _ := s.Count(WithPrimary(ctx), nil); count != 1 {
+		t.Errorf("Expected read to be served by the primary, got %d rows", count)
+	}
+
+	rywCtx := WithReadYourWrites(ctx)
+	if count, _ := s.Count(rywCtx, nil); count != 0 {
+		t.Errorf("Expected read before any write to be served by the replica, got %d rows", count)
+	}
+	if err := s.Create(rywCtx, &testUser{Email: "ryw@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if count, _ := s.Count(rywCtx, nil); count != 2 {
+		t.Errorf("Expected read after write to be served by the primary, got %d rows", count)
+	}
+}
+
+func TestShardProvider(t *testing.T) {
+	_, shard0 := newTestStore(t)
+	_, shard1 := newTestStore(t)
+	ctx := context.Background()
+
+	s := NewStore[testUser](NewShardProvider("age", []*gorm.DB{shard0.db, shard1.db}))
+	if err := s.Create(WithShardKey(ctx, 3), &testUser{Email: "shard@x.io", Age: 3}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var count int64
+	shard1.db.Model(&testUser{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the row to be stored on shard 1, got %d rows", count)
+	}
+
+	if count, err := s.Count(ctx, where.F("age", 3)); err != nil || count != 1 {
+		t.Errorf("Expected count 1 from the shard of the filter, got %d, %v", count, err)
+	}
+	if _, err := s.Count(ctx, nil); !errors.Is(err, ErrNoShardKey) {
+		t.Errorf("Expected ErrNoShardKey without shard key, got %v", err)
+	}
+}
+
+func TestTenancy(t *testing.T) {
+	_, provider := newTestStore(t)
+	s := NewStore[testUser](provider, WithTenancy[testUser]("age"))
+	ctx7 := WithTenant(context.Background(), 7)
+	ctx8 := WithTenant(context.Background(), 8)
+
+	user := &testUser{Name: "alice", Email: "tenant@x.io"}
+	if err := s.Create(ctx7, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if user.Age != 7 {
+		t.Errorf("Expected tenant column to be filled with 7, got %d", user.Age)
+	}
+
+	if count, _ := s.Count(ctx8, nil); count != 0 {
+		t.Errorf("Expected other tenant to see no rows, got %d", count)
+	}
+	if _, err := s.Get(ctx8, where.F("id", user.ID)); !IsNotFound(err) {
+		t.Errorf("Expected ErrNotFound from other tenant, got %v", err)
+	}
+
+	if err := s.Update(ctx8, &testUser{ID: user.ID, Name: "mallory", Email: "tenant@x.io"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := s.Get(ctx7, where.F("id", user.ID))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Name != "alice" || got.Age != 7 {
+		t.Errorf("Expected row to be untouched by other tenant, got %+v", got)
+	}
+
+	// Without tenant, operations fail instead of spanning all the tenants.
+	ctx := context.Background()
+	if _, err := s.Count(ctx, nil); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant from Count, got %v", err)
+	}
+	if _, err := s.Get(ctx, where.F("id", user.ID)); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant from Get, got %v", err)
+	}
+	if err := s.Create(ctx, &testUser{Name: "bob", Email: "notenant@x.io"}); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant from Create, got %v", err)
+	}
+
+	// Administration jobs opt out of tenancy explicitly.
+	admin := WithoutTenant(ctx)
+	if err := s.Create(admin, &testUser{Name: "bob", Email: "admin@x.io", Age: 8}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if count, err := s.Count(admin, nil); err != nil || count != 2 {
+		t.Errorf("Expected the rows of all the tenants, got %d, %v", count, err)
+	}
+}
+
+func TestCachedStore(t *testing.T) {
+	s, provider := newTestStore(t)
+	cs := NewCachedStore(s, MemoryCache(16), WithNegativeCacheTTL(time.Minute))
+	ctx := context.Background()
+
+	user := &testUser{Name: "alice", Email: "cache@x.io"}
+	if err := cs.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := cs.Get(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// Changes made behind the cache are not visible until invalidation.
+	provider.db.Model(&testUser{}).Where("id = ?", user.ID).Update("name", "bob")
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Name != "alice" {
+		t.Errorf("Expected cached name alice, got %s", got.Name)
+	}
+
+	user.Name = "carol"
+	if err := cs.Update(ctx, user); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Name != "carol" {
+		t.Errorf("Expected name carol after invalidation, got %s", got.Name)
+	}
+
+	// Missing objects are cached until created through the store.
+	if _, err := cs.Get(ctx, where.F("id", 100)); !IsNotFound(err) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := cs.Create(ctx, &testUser{ID: 100, Email: "late@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := cs.Get(ctx, where.F("id", 100)); err != nil {
+		t.Errorf("Expected created object to be found, got %v", err)
+	}
+
+	if err := cs.Delete(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := cs.Get(ctx, where.F("id", user.ID)); !IsNotFound(err) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	if stats := cs.Stats(); stats.Hits != 1 || stats.Misses != 5 {
+		t.Errorf("Expected 1 hit and 5 misses, got %+v", stats)
+	}
+
+	// Lookups of several keys are not cached, updates could not invalidate them.
+	dave := &testUser{Name: "dave", Email: "dave@x.io"}
+	if err := cs.Create(ctx, dave); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := cs.Get(ctx, where.F("id", []uint64{uint64(dave.ID)})); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	dave.Name = "david"
+	if err := cs.Update(ctx, dave); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, err := cs.Get(ctx, where.F("id", []uint64{uint64(dave.ID)})); err != nil || got.Name != "david" {
+		t.Errorf("Expected name david after update, got %+v, %v", got, err)
+	}
+}
+
+func TestCachedStoreInvalidation(t *testing.T) {
+	s, provider := newTestStore(t)
+	cs := NewCachedStore(s, MemoryCache(16))
+	ctx := context.Background()
+
+	user := &testUser{Name: "alice", Email: "inval@x.io", Age: 30}
+	if err := cs.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// stale caches the object, renames it behind the cache and checks that it is still cached.
+	stale := func(name string) {
+		t.Helper()
+		if _, err := cs.Get(ctx, where.F("id", user.ID)); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		provider.db.Model(&testUser{}).Where("id = ?", user.ID).Update("name", name)
+		if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Name == name {
+			t.Fatalf("Expected object to be cached")
+		}
+	}
+
+	stale("behind")
+	if _, err := cs.Increment(ctx, where.F("id", user.ID), "age", 1); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Age != 31 || got.Name != "behind" {
+		t.Errorf("Expected fresh object after Increment, got %+v", got)
+	}
+
+	stale("bob")
+	if _, err := cs.UpdateMany(ctx, where.F("id", user.ID), map[string]any{"name": "many"}); err != nil {
+		t.Fatalf("UpdateMany failed: %v", err)
+	}
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Name != "many" {
+		t.Errorf("Expected name many after UpdateMany, got %s", got.Name)
+	}
+
+	stale("carol")
+	if _, err := cs.Exec(ctx, "UPDATE test_users SET age = 40"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Age != 40 {
+		t.Errorf("Expected age 40 after Exec, got %d", got.Age)
+	}
+
+	err := cs.Tx(ctx, func(ctx context.Context) error {
+		_, err := cs.Exec(ctx, "UPDATE test_users SET age = 41")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Tx failed: %v", err)
+	}
+	if got, _ := cs.Get(ctx, where.F("id", user.ID)); got.Age != 41 {
+		t.Errorf("Expected age 41 after committed Exec, got %d", got.Age)
+	}
+
+	if err := cs.DeleteByKey(ctx, map[string]any{"id": user.ID}); err != nil {
+		t.Fatalf("DeleteByKey failed: %v", err)
+	}
+	if _, err := cs.Get(ctx, where.F("id", user.ID)); !IsNotFound(err) {
+		t.Errorf("Expected ErrNotFound after DeleteByKey, got %v", err)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := MemoryCache(2)
+	ctx := context.Background()
+
+	_ = cache.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = cache.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _ = cache.Get(ctx, "a")
+	_ = cache.Set(ctx, "c", []byte("3"), time.Minute)
+	_ = cache.Set(ctx, "d", []byte("4"), -time.Second)
+
+	if _, err := cache.Get(ctx, "b"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected least recently used entry to be evicted, got %v", err)
+	}
+	if _, err := cache.Get(ctx, "d"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected expired entry to be missed, got %v", err)
+	}
+	if value, err := cache.Get(ctx, "c"); err != nil || string(value) != "3" {
+		t.Errorf("Expected c=3, got %q, %v", value, err)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	_, provider := newTestStore(t)
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	s := NewStore[testUser](provider, WithTracing[testUser](tp, WithStatements(true)))
+	ctx := context.Background()
+
+	if err := s.Create(ctx, &testUser{Email: "trace@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	_, _ = s.Get(ctx, where.F("id", 42))
+	_ = s.Create(ctx, &testUser{Email: "trace@x.io"})
+	_, _ = s.GetOrNil(ctx, where.F("id", 42))
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	for i, want := range []struct {
+		name   string
+		status codes.Code
+	}{
+		{"testUser.Create", codes.Unset},
+		{"testUser.Get", codes.Unset},
+		{"testUser.Create", codes.Error},
+		{"testUser.GetOrNil", codes.Unset},
+	} {
+		if spans[i].Name() != want.name || spans[i].Status().Code != want.status {
+			t.Errorf("Span %d: expected %s with status %v, got %s with status %v",
+				i, want.name, want.status, spans[i].Name(), spans[i].Status().Code)
+		}
+	}
+
+	events := spans[0].Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 query event, got %d", len(events))
+	}
+	var statement string
+	for _, attr := range events[0].Attributes {
+		if attr.Key == "db.statement" {
+			statement = attr.Value.AsString()
+		}
+	}
+	if statement == "" {
+		t.Errorf("Expected the statement to be recorded, got attributes %v", events[0].Attributes)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	_, provider := newTestStore(t)
+	failures := 0
+	err := provider.db.Callback().Create().Before("gorm:create").Register("test:fail", func(db *gorm.DB) {
+		if failures > 0 {
+			failures--
+			_ = db.AddError(driver.ErrBadConn)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return 0 }}
+	logger := &recordingLogger{}
+	s := NewStore[testUser](provider, WithRetry[testUser](policy), WithLogger[testUser](logger))
+	ctx := context.Background()
+
+	failures = 2
+	if err := s.Create(ctx, &testUser{Email: "retry@x.io"}); err != nil {
+		t.Errorf("Expected Create to succeed after 2 transient failures, got %v", err)
+	}
+	if len(logger.warnings) != 2 {
+		t.Errorf("Expected the 2 retries to be logged as warnings, got %v", logger.warnings)
+	}
+
+	failures = 3
+	if err := s.Create(ctx, &testUser{Email: "giveup@x.io"}); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("Expected Create to give up after 3 attempts, got %v", err)
+	}
+	if len(logger.warnings) != 4 {
+		t.Errorf("Expected only the 2 retries of the final failure to be logged as warnings, got %v", logger.warnings)
+	}
+
+	failures = 1
+	err = s.Tx(ctx, func(txCtx context.Context) error {
+		return s.Create(txCtx, &testUser{Email: "tx@x.io"})
+	})
+	if err != nil {
+		t.Errorf("Expected transaction to be retried as a whole, got %v", err)
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	s, provider := newTestStore(t)
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return 0 }}
+
+	attempts := 0
+	err := WithTxRetry(ctx, provider, nil, policy, func(txCtx context.Context) error {
+		attempts++
+		if err := s.Create(txCtx, &testUser{Email: fmt.Sprintf("%d@x.io", attempts)}); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("Expected the transaction to succeed at the third attempt, got %d attempts, %v", attempts, err)
+	}
+	if count, _ := s.Count(ctx, nil); count != 1 {
+		t.Errorf("Expected the aborted attempts to be rolled back, got %d rows", count)
+	}
+
+	attempts = 0
+	err = WithTxRetry(ctx, provider, nil, policy, func(txCtx context.Context) error {
+		attempts++
+		return errors.New("permanent")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected errors other than deadlocks not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestBreakerStore(t *testing.T) {
+	s, provider := newTestStore(t)
+	failing := true
+	err := provider.db.Callback().Query().Before("gorm:query").Register("test:fail", func(db *gorm.DB) {
+		if failing {
+			_ = db.AddError(driver.ErrBadConn)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	var transitions []BreakerState
+	breaker := NewCircuitBreaker(
+		WithBreakerThreshold(2),
+		WithBreakerOpenTimeout(20*time.Millisecond),
+		WithBreakerStateChange(func(_, to BreakerState) { transitions = append(transitions, to) }),
+	)
+	bs := NewBreakerStore(s, breaker)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := bs.Get(ctx, where.F("id", 1)); !errors.Is(err, driver.ErrBadConn) {
+			t.Fatalf("Expected database failure, got %v", err)
+		}
+	}
+	if _, err := bs.Get(ctx, where.F("id", 1)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen once the threshold is reached, got %v", err)
+	}
+
+	failing = false
+	time.Sleep(30 * time.Millisecond)
+	if _, err := bs.Get(ctx, where.F("id", 1)); !IsNotFound(err) {
+		t.Fatalf("Expected probe to reach the database, got %v", err)
+	}
+
+	stats := breaker.Stats()
+	if stats.State != BreakerClosed || stats.Failures != 2 || stats.Rejected != 1 || stats.Opened != 1 {
+		t.Errorf("Unexpected breaker stats: %+v", stats)
+	}
+	if fmt.Sprint(transitions) != "[open half-open closed]" {
+		t.Errorf("Unexpected transitions: %v", transitions)
+	}
+}
+
+func TestBreakerStoreCallerErrors(t *testing.T) {
+	s, _ := newTestStore(t)
+	breaker := NewCircuitBreaker(WithBreakerThreshold(1))
+	bs := NewBreakerStore(s, breaker)
+	ctx := context.Background()
+
+	// None of the errors caused by the caller opens the circuit.
+	if _, err := bs.Get(ctx, where.F("id", 42)); !IsNotFound(err) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := bs.Get(ctx, where.F("email", "x").AllowFields("name")); !errors.Is(err, where.ErrFieldNotAllowed) {
+		t.Errorf("Expected ErrFieldNotAllowed, got %v", err)
+	}
+	if _, err := bs.GetByKey(ctx, map[string]any{}); !errors.Is(err, ErrIncompleteKey) {
+		t.Errorf("Expected ErrIncompleteKey, got %v", err)
+	}
+	errCaller := errors.New("caller")
+	if err := bs.Tx(ctx, func(context.Context) error { return errCaller }); !errors.Is(err, errCaller) {
+		t.Errorf("Expected the error of fn, got %v", err)
+	}
+	expired, cancel := context.WithTimeout(ctx, -time.Second)
+	defer cancel()
+	if _, err := bs.Count(expired, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if stats := breaker.Stats(); stats.State != BreakerClosed || stats.Failures != 0 {
+		t.Errorf("Expected the circuit to stay closed without failures, got %+v", stats)
+	}
+
+	// Statement timeouts are database failures.
+	if !isBreakerFailure(fmt.Errorf("query: %w", context.DeadlineExceeded)) || !isBreakerFailure(driver.ErrBadConn) {
+		t.Errorf("Expected statement timeouts and lost connections to be failures")
+	}
+}
+
+func TestDecorate(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	cache := MemoryCache(16)
+	var users IStore[testUser] = Decorate[testUser](s,
+		Cached[testUser](cache),
+		Breaking[testUser](NewCircuitBreaker()),
+	)
+	if _, ok := users.(*CachedStore[testUser]); !ok {
+		t.Fatalf("Expected the first decorator to be the outermost one, got %T", users)
+	}
+	if base, ok := baseStore(users); !ok || base != s {
+		t.Fatalf("Expected the decorated store to unwrap to the base store")
+	}
+
+	user := &testUser{Name: "alice", Email: "alice@example.com"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := users.Get(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, err := cache.Get(ctx, "store:testUser:1"); err != nil {
+		t.Errorf("Expected object to be cached through the decorator chain, got %v", err)
+	}
+	if count, _, err := users.List(ctx, nil); err != nil || count != 1 {
+		t.Errorf("Expected List to be passed through the chain, got %d, %v", count, err)
+	}
+}
+
+func TestRawQueryAndExec(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@example.com", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	affected, err := s.Exec(ctx, "UPDATE test_users SET age = age + ? WHERE name = ?", 5, "bob")
+	if err != nil || affected != 1 {
+		t.Fatalf("Expected 1 row affected, got %d, %v", affected, err)
+	}
+
+	var rows []struct {
+		Name string
+		Age  int
+	}
+	if err := s.RawQuery(ctx, &rows, "SELECT name, age FROM test_users WHERE age > ? ORDER BY name", 30); err != nil {
+		t.Fatalf("RawQuery failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "bob" || rows[0].Age != 35 {
+		t.Errorf("Expected bob aged 35, got %+v", rows)
+	}
+
+	if _, err := s.Exec(ctx, "UPDATE missing_table SET x = 1"); err == nil {
+		t.Errorf("Expected error for invalid statement")
+	}
+}
+
+func TestListPage(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i := range 5 {
+		if err := s.Create(ctx, &testUser{Name: fmt.Sprint("user", i), Email: fmt.Sprint(i, "@example.com")}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		opts       *where.Options
+		items      int
+		page       int
+		pageSize   int
+		totalPages int
+		hasNext    bool
+	}{
+		{opts: where.P(1, 2), items: 2, page: 1, pageSize: 2, totalPages: 3, hasNext: true},
+		{opts: where.P(3, 2), items: 1, page: 3, pageSize: 2, totalPages: 3, hasNext: false},
+		{opts: where.NewWhere(), items: 5, page: 1, pageSize: 5, totalPages: 1, hasNext: false},
+		{opts: where.P(2, 2).NoCount(), items: 2, page: 2, pageSize: 2, totalPages: -1, hasNext: true},
+	}
+	for _, tt := range tests {
+		ret, err := ListPage[testUser](ctx, s, tt.opts)
+		if err != nil {
+			t.Fatalf("ListPage failed: %v", err)
+		}
+		total := int64(5)
+		if tt.opts.SkipCount {
+			total = -1
+		}
+		if len(ret.Items) != tt.items || ret.TotalCount != total || ret.Page != tt.page || ret.PageSize != tt.pageSize ||
+			ret.TotalPages != tt.totalPages || ret.HasNext != tt.hasNext {
+			t.Errorf("Unexpected page for offset %d and limit %d: %d items, %+v",
+				tt.opts.Offset, tt.opts.Limit, len(ret.Items), *ret)
+		}
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	s, provider := newTestStore(t)
+	s = NewStore[testUser](provider, WithStatementTimeout[testUser](50*time.Millisecond))
+	ctx := context.Background()
+
+	start := time.Now()
+	var n int64
+	err := s.RawQuery(ctx, &n, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c")
+	if err == nil {
+		t.Fatalf("Expected runaway query to be canceled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected query to be canceled after the timeout, took %v", elapsed)
+	}
+
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice@example.com"}); err != nil {
+		t.Errorf("Expected fast operations to succeed, got %v", err)
+	}
+}
+
+func TestStatementTimeoutExport(t *testing.T) {
+	_, provider := newTestStore(t)
+	s := NewStore[testUser](provider, WithStatementTimeout[testUser](time.Nanosecond))
+	ctx := context.Background()
+	if err := provider.db.Create(&testUser{Name: "alice", Email: "alice@example.com"}).Error; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Exports run for long and are not bounded as a whole.
+	var csv strings.Builder
+	if err := s.Export(ctx, nil, &csv, ExportCSV); err != nil {
+		t.Fatalf("Expected export not to be bounded, got %v", err)
+	}
+	if !strings.Contains(csv.String(), "alice") {
+		t.Errorf("Expected alice to be exported, got %q", csv.String())
+	}
+}
+
+// recordingLogger is a Logger recording the warnings it receives.
+type recordingLogger struct {
+	warnings []string
+	kvs      [][]any
+}
+
+func (l *recordingLogger) Error(ctx context.Context, err error, message string, kvs ...any) {}
+
+func (l *recordingLogger) Warn(ctx context.Context, message string, kvs ...any) {
+	l.warnings = append(l.warnings, message)
+	l.kvs = append(l.kvs, kvs)
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+
+	logger := &recordingLogger{}
+	fast := NewStore[testUser](provider, WithLogger[testUser](logger), WithSlowQueryThreshold[testUser](time.Hour))
+	if _, err := fast.Count(ctx, where.F("name", "alice")); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if len(logger.warnings) != 0 {
+		t.Fatalf("Expected no warning under the threshold, got %v", logger.warnings)
+	}
+
+	slow := NewStore[testUser](provider, WithLogger[testUser](logger), WithSlowQueryThreshold[testUser](time.Nanosecond))
+	if _, err := slow.Count(ctx, where.F("name", "alice")); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if len(logger.warnings) != 1 {
+		t.Fatalf("Expected 1 slow query warning, got %v", logger.warnings)
+	}
+	kvs := fmt.Sprint(logger.kvs[0]...)
+	for _, want := range []string{"SELECT count(*) FROM `test_users`", "duration", "conditions"} {
+		if !strings.Contains(kvs, want) {
+			t.Errorf("Expected slow query warning to contain %q, got %s", want, kvs)
+		}
+	}
+
+	// Loggers not implementing WarnLogger receive warnings as errors.
+	errorsOnly := &errorLogger{}
+	legacy := NewStore[testUser](provider, WithLogger[testUser](errorsOnly), WithSlowQueryThreshold[testUser](time.Nanosecond))
+	if _, err := legacy.Count(ctx, where.F("name", "alice")); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if len(errorsOnly.messages) != 1 || errorsOnly.messages[0] != "Slow database query" {
+		t.Errorf("Expected the slow query warning as an error, got %v", errorsOnly.messages)
+	}
+}
+
+// errorLogger is a Logger implementing Error only, recording the messages it receives.
+type errorLogger struct {
+	messages []string
+}
+
+func (l *errorLogger) Error(ctx context.Context, err error, message string, kvs ...any) {
+	l.messages = append(l.messages, message)
+}
+
+func TestDryRun(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx, result := DryRun(context.Background())
+
+	affected, err := s.UpdateWhere(ctx, where.F("name", "alice"), map[string]any{"age": 42})
+	if err != nil || affected != 0 {
+		t.Fatalf("Expected dry run update to affect no rows, got %d, %v", affected, err)
+	}
+	if err := s.Create(ctx, &testUser{Name: "bob", Email: "bob@example.com"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	statements := result.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %+v", statements)
+	}
+	if !strings.HasPrefix(statements[0].SQL, "UPDATE `test_users` SET `age`=?") || len(statements[0].Vars) == 0 {
+		t.Errorf("Unexpected update statement: %+v", statements[0])
+	}
+	if !strings.HasPrefix(statements[1].SQL, "INSERT INTO `test_users`") {
+		t.Errorf("Unexpected insert statement: %+v", statements[1])
+	}
+
+	if n, _ := s.Count(context.Background(), nil); n != 0 {
+		t.Errorf("Expected dry run statements not to be executed, got %d objects", n)
+	}
+}
+
+type auditedDoc struct {
+	ID        uint `gorm:"primaryKey"`
+	Title     string
+	CreatedBy string
+	UpdatedBy string
+	DeletedBy string
+	DeletedAt gorm.DeletedAt
+}
+
+func TestAudit(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&auditedDoc{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	s := NewStore[auditedDoc](provider, WithAudit[auditedDoc](DefaultAuditColumns))
+
+	doc := &auditedDoc{Title: "draft"}
+	if err := s.Create(WithOperator(context.Background(), "alice"), doc); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if doc.CreatedBy != "alice" || doc.UpdatedBy != "alice" {
+		t.Errorf("Expected creation to be audited, got %+v", doc)
+	}
+
+	bob := WithOperator(context.Background(), "bob")
+	if _, err := s.UpdateWhere(bob, where.F("id", doc.ID), map[string]any{"title": "final"}); err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if got, _ := s.Get(bob, where.F("id", doc.ID)); got.CreatedBy != "alice" || got.UpdatedBy != "bob" {
+		t.Errorf("Expected update to be audited, got %+v", got)
+	}
+
+	if err := s.Delete(WithOperator(context.Background(), "carol"), where.F("id", doc.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(context.Background(), where.F("id", doc.ID)); !IsNotFound(err) {
+		t.Errorf("Expected object to be soft-deleted, got %v", err)
+	}
+	got, err := s.Get(context.Background(), where.U(true).F("id", doc.ID))
+	if err != nil || got.DeletedBy != "carol" || !got.DeletedAt.Valid {
+		t.Errorf("Expected deletion to be audited, got %+v, %v", got, err)
+	}
+}
+
+type secretUser struct {
+	ID       uint `gorm:"primaryKey"`
+	Name     string
+	Email    string `log:"mask"`
+	Password string `log:"-"`
+}
+
+// capturingLogger is a Logger recording the key-value pairs of the errors it receives.
+type capturingLogger struct {
+	kvs [][]any
+}
+
+func (l *capturingLogger) Error(ctx context.Context, err error, message string, kvs ...any) {
+	l.kvs = append(l.kvs, kvs)
+}
+
+func (l *capturingLogger) Warn(ctx context.Context, message string, kvs ...any) {}
+
+func TestLogCorrelation(t *testing.T) {
+	s, provider := newTestStore(t)
+	logger := &capturingLogger{}
+	type requestIDKey struct{}
+	s = NewStore[testUser](provider, WithLogger[testUser](logger), WithContextExtractors[testUser](ContextExtractors{
+		"requestID": func(ctx context.Context) string {
+			id, _ := ctx.Value(requestIDKey{}).(string)
+			return id
+		},
+	}))
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}})
+	ctx := trace.ContextWithSpanContext(context.WithValue(context.Background(), requestIDKey{}, "req-1"), span)
+	_, _ = s.Get(ctx, where.F("unknown_column", 1))
+	_, _ = s.Get(context.Background(), where.F("unknown_column", 1))
+	if len(logger.kvs) != 2 {
+		t.Fatalf("Expected 2 logged errors, got %d", len(logger.kvs))
+	}
+
+	got := fmt.Sprint(logger.kvs[0][2:])
+	want := fmt.Sprint([]any{"traceID", span.TraceID().String(), "spanID", span.SpanID().String(), "requestID", "req-1"})
+	if got != want {
+		t.Errorf("Expected correlation values %s, got %s", want, got)
+	}
+	if len(logger.kvs[1]) != 2 {
+		t.Errorf("Expected no correlation values without request, got %v", logger.kvs[1])
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	_, provider := newTestStore(t)
+	logger := &capturingLogger{}
+	// The table is not migrated, so that every operation fails and gets logged.
+	s := NewStore[secretUser](provider, WithLogger[secretUser](logger))
+	ctx := context.Background()
+
+	user := &secretUser{Name: "alice", Email: "alice@example.com", Password: "hunter2"}
+	_ = s.Create(ctx, user)
+	_, _ = s.Get(ctx, where.F("email", "alice@example.com", "name", "alice"))
+
+	if len(logger.kvs) != 2 {
+		t.Fatalf("Expected 2 logged errors, got %d", len(logger.kvs))
+	}
+	var logged string
+	for _, kvs := range logger.kvs {
+		for _, v := range kvs {
+			switch v := v.(type) {
+			case *secretUser:
+				logged += fmt.Sprintf("%+v ", *v)
+			case *where.Options:
+				logged += fmt.Sprintf("%+v ", v.Filters)
+			}
+		}
+	}
+	for _, secret := range []string{"alice@example.com", "hunter2"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted from the logs, got %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "alice") || !strings.Contains(logged, redactedValue) {
+		t.Errorf("Expected untagged fields to be logged and tagged ones masked, got %s", logged)
+	}
+	if user.Email != "alice@example.com" || user.Password != "hunter2" {
+		t.Errorf("Expected redaction not to modify the caller object, got %+v", user)
+	}
+}
+
+func TestDeleteInChunks(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for i := range 7 {
+		if err := s.Create(ctx, &testUser{Name: "expired", Email: fmt.Sprint(i, "@x.io")}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if err := s.Create(ctx, &testUser{Name: "active", Email: "active@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var progress []int64
+	deleted, err := s.DeleteInChunks(ctx, where.F("name", "expired"), 3, func(n int64) { progress = append(progress, n) })
+	if err != nil || deleted != 7 {
+		t.Fatalf("Expected 7 objects deleted, got %d, %v", deleted, err)
+	}
+	if fmt.Sprint(progress) != "[3 6 7]" {
+		t.Errorf("Expected progress after every chunk, got %v", progress)
+	}
+	if count, _ := s.Count(ctx, nil); count != 1 {
+		t.Errorf("Expected only the active object to remain, got %d", count)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.DeleteInChunks(canceled, where.F("name", "active"), 3, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected canceled deletion to stop, got %v", err)
+	}
+}
+
+type testGroup struct {
+	ID      uint `gorm:"primaryKey"`
+	Name    string
+	Members []testMember `gorm:"many2many:test_group_members"`
+}
+
+type testMember struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func TestAssociation(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&testGroup{}, &testMember{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	s := NewStore[testGroup](provider)
+	ctx := context.Background()
+
+	group := &testGroup{Name: "admins"}
+	if err := s.Create(ctx, group); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	members := s.Association(ctx, group, "Members")
+	if err := members.Append(&testMember{Name: "alice"}, &testMember{Name: "bob"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if count, err := members.Count(); err != nil || count != 2 {
+		t.Errorf("Expected 2 members, got %d, %v", count, err)
+	}
+
+	if err := members.Replace(&testMember{Name: "carol"}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	var found []testMember
+	if err := members.Find(&found); err != nil || len(found) != 1 || found[0].Name != "carol" {
+		t.Errorf("Expected carol as the only member, got %+v, %v", found, err)
+	}
+
+	if err := members.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if count, _ := members.Count(); count != 0 {
+		t.Errorf("Expected no member after Clear, got %d", count)
+	}
+
+	if err := s.Association(ctx, group, "Unknown").Clear(); err == nil {
+		t.Errorf("Expected error for unknown association")
+	}
+}
+
+func TestPing(t *testing.T) {
+	s, provider := newTestStore(t)
+	_, replica := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if stats := s.Stats(); stats.OpenConnections < 1 {
+		t.Errorf("Expected an open connection, got %+v", stats)
+	}
+
+	rs := NewStore[testUser](NewReplicaProvider(provider.db, []*gorm.DB{replica.db}))
+	if err := rs.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if stats := rs.Stats(); stats.OpenConnections < 2 {
+		t.Errorf("Expected the connections of both databases, got %+v", stats)
+	}
+
+	sqlDB, _ := replica.db.DB()
+	_ = sqlDB.Close()
+	if err := rs.Ping(ctx); err == nil || !strings.Contains(err.Error(), "replica 0") {
+		t.Errorf("Expected the closed replica to fail, got %v", err)
+	}
+}
+
+func TestNewMySQLProvider(t *testing.T) {
+	if _, err := NewMySQLProvider("not a dsn"); err == nil {
+		t.Errorf("Expected error for invalid DSN")
+	}
+
+	var retries []int
+	backoff := func(attempt int) time.Duration {
+		retries = append(retries, attempt)
+		return time.Millisecond
+	}
+	_, err := NewMySQLProvider("app:secret@tcp(127.0.0.1:1)/app?timeout=1s", WithStartupRetry(3, backoff))
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Expected connection failure after 3 attempts, got %v", err)
+	}
+	if fmt.Sprint(retries) != "[1 2]" {
+		t.Errorf("Expected 2 retries, got %v", retries)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	var ids []any
+	for _, name := range []string{"a", "b", "c"} {
+		user := &testUser{Name: name, Email: name + "@x.io"}
+		if err := s.Create(ctx, user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	users, err := s.GetMany(ctx, []any{ids[2], 999, ids[0]})
+	if err != nil {
+		t.Fatalf("GetMany failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "c" || users[1].Name != "a" {
+		t.Errorf("Expected users c and a in the order of the ids, got %+v", users)
+	}
+
+	users, err = s.GetManyBy(ctx, "email", []any{"b@x.io", "a@x.io"})
+	if err != nil || len(users) != 2 || users[0].Name != "b" {
+		t.Errorf("Expected users b and a, got %+v, %v", users, err)
+	}
+
+	byID, err := GetMap(ctx, s, []int{1, 3})
+	if err != nil {
+		t.Fatalf("GetMap failed: %v", err)
+	}
+	if len(byID) != 2 || byID[3].Name != "c" {
+		t.Errorf("Expected users 1 and 3 keyed by id, got %+v", byID)
+	}
+}
+
+type legacyDoc struct {
+	ID        uint `gorm:"primaryKey"`
+	Title     string
+	IsDeleted int8
+	DeletedBy string
+}
+
+func TestFlagSoftDelete(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&legacyDoc{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	ctx := WithOperator(context.Background(), "alice")
+
+	s := NewStore[legacyDoc](provider, WithSoftDelete[legacyDoc](FlagSoftDelete("is_deleted").WithDeletedBy("deleted_by")))
+	for _, title := range []string{"a", "b"} {
+		if err := s.Create(ctx, &legacyDoc{Title: title}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	if err := s.Delete(ctx, where.F("title", "a")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	var row legacyDoc
+	provider.db.Where("title = ?", "a").First(&row)
+	if row.IsDeleted != 1 || row.DeletedBy != "alice" {
+		t.Errorf("Expected the row to be flagged as deleted by alice, got %+v", row)
+	}
+
+	if count, _, err := s.List(ctx, nil); err != nil || count != 1 {
+		t.Errorf("Expected deleted rows to be hidden, got %d, %v", count, err)
+	}
+	if _, err := s.Get(ctx, where.F("title", "a")); !IsNotFound(err) {
+		t.Errorf("Expected deleted row not to be found, got %v", err)
+	}
+	if count, _ := s.Count(ctx, where.F("title", "a").U(true)); count != 1 {
+		t.Errorf("Expected unscoped count to include the deleted row, got %d", count)
+	}
+
+	if restored, err := s.Restore(ctx, where.F("title", "a")); err != nil || restored != 1 {
+		t.Fatalf("Expected 1 row restored, got %d, %v", restored, err)
+	}
+	if _, err := s.Get(ctx, where.F("title", "a")); err != nil {
+		t.Errorf("Expected restored row to be found, got %v", err)
+	}
+
+	if err := s.Purge(ctx, where.F("title", "b")); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	var total int64
+	provider.db.Model(&legacyDoc{}).Count(&total)
+	if total != 1 {
+		t.Errorf("Expected purge to remove the row, got %d rows", total)
+	}
+
+	// Without a deleted by column of its own, the strategy uses the one of WithAudit.
+	audited := NewStore[legacyDoc](provider, WithSoftDelete[legacyDoc](FlagSoftDelete("is_deleted")), WithAudit[legacyDoc](DefaultAuditColumns))
+	if err := audited.Delete(WithOperator(context.Background(), "bob"), where.F("title", "a")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	provider.db.Where("title = ?", "a").First(&row)
+	if row.IsDeleted != 1 || row.DeletedBy != "bob" {
+		t.Errorf("Expected the row to be flagged as deleted by bob, got %+v", row)
+	}
+	if restored, err := audited.Restore(ctx, where.F("title", "a")); err != nil || restored != 1 {
+		t.Fatalf("Expected 1 row restored, got %d, %v", restored, err)
+	}
+	row = legacyDoc{}
+	provider.db.Where("title = ?", "a").First(&row)
+	if row.IsDeleted != 0 || row.DeletedBy != "" {
+		t.Errorf("Expected the restored row to have no deleted by, got %+v", row)
+	}
+}
+
+func TestReturning(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	ctx, returned := Returning[testUser](context.Background(), "name", "age")
+	for _, name := range []string{"a", "b", "c"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@x.io", Age: 1}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if objs := returned.Objects(); len(objs) != 3 || objs[2].ID == 0 {
+		t.Fatalf("Expected the created objects with their ids, got %+v", objs)
+	}
+
+	ctx, returned = Returning[testUser](context.Background(), "id", "age")
+	if _, err := s.Increment(ctx, where.F("name", []string{"a", "b"}), "age", 1); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	objs := returned.Objects()
+	if len(objs) != 2 || objs[0].Age != 2 || objs[0].ID == 0 || objs[0].Name != "" {
+		t.Errorf("Expected the returned columns of the updated rows, got %+v", objs)
+	}
+
+	ctx, returned = Returning[testUser](context.Background())
+	if err := s.Delete(ctx, where.F("name", "c")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if objs := returned.Objects(); len(objs) != 1 || objs[0].Email != "c@x.io" {
+		t.Errorf("Expected the deleted row, got %+v", objs)
+	}
+}
+
+func TestTableFunc(t *testing.T) {
+	_, provider := newTestStore(t)
+	for _, table := range []string{"users_2024_05", "users_2024_06"} {
+		if err := provider.db.Table(table).AutoMigrate(&testUser{}); err != nil {
+			t.Fatalf("AutoMigrate failed: %v", err)
+		}
+	}
+	type monthKey struct{}
+	ctx := context.WithValue(context.Background(), monthKey{}, "2024_05")
+
+	s := NewStore[testUser](provider, WithTableFunc[testUser](func(ctx context.Context) string {
+		if month, ok := ctx.Value(monthKey{}).(string); ok {
+			return "users_" + month
+		}
+		return ""
+	}))
+	if err := s.Create(ctx, &testUser{Name: "may", Email: "may@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var count int64
+	provider.db.Table("users_2024_05").Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the object to be created in users_2024_05, got %d rows", count)
+	}
+	if count, _ := s.Count(context.Background(), nil); count != 0 {
+		t.Errorf("Expected the model table to be used without month, got %d rows", count)
+	}
+	if _, err := s.Get(ctx, where.F("name", "may")); err != nil {
+		t.Errorf("Get failed: %v", err)
+	}
+	if count, _, err := s.List(ctx, where.Table("users_2024_06")); err != nil || count != 0 {
+		t.Errorf("Expected the where table to take precedence, got %d, %v", count, err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@x.io", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	user, err := s.Get(ctx, where.Select("id", "name").F("name", "bob"))
+	if err != nil || user.ID == 0 || user.Name != "bob" || user.Email != "" || user.Age != 0 {
+		t.Errorf("Expected only id and name to be fetched, got %+v, %v", user, err)
+	}
+
+	count, users, err := s.List(ctx, where.Select("name"))
+	if err != nil || count != 2 || len(users) != 2 || users[0].Name != "bob" || users[0].Email != "" {
+		t.Errorf("Expected 2 objects holding their name only, got %d, %v, %v", count, users, err)
+	}
+}
+
+func TestListInto(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: name + "@x.io", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	type userSummary struct {
+		ID       uint
+		Name     string
+		Nickname string
+	}
+	count, users, err := ListInto[userSummary](ctx, s, where.L(2).Or("name"))
+	if err != nil {
+		t.Fatalf("ListInto failed: %v", err)
+	}
+	if count != 3 || len(users) != 2 || users[0].Name != "alice" || users[0].ID == 0 || users[1].Name != "bob" {
+		t.Errorf("Expected 3 objects and the first 2 summaries, got %d, %+v", count, users)
+	}
+
+	type userContact struct {
+		Name  string
+		Email string
+	}
+	_, contacts, err := ListInto[userContact](ctx, s, where.Select("name").F("name", "carol"))
+	if err != nil || len(contacts) != 1 || contacts[0].Name != "carol" || contacts[0].Email != "" {
+		t.Errorf("Expected the selected columns only, got %+v, %v", contacts, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	for _, user := range []testUser{
+		{Name: "John Doe", Email: "jdoe@x.io"},
+		{Name: "Jane", Email: "john.smith@x.io"},
+		{Name: "100% Bob", Email: "bob@x.io"},
+		{Name: "1000 Alice", Email: "alice@x.io"},
+	} {
+		if err := s.Create(ctx, &user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	count, users, err := s.List(ctx, where.Search("john", "name", "email").Or("id"))
+	if err != nil || count != 2 || users[0].Name != "John Doe" || users[1].Name != "Jane" {
+		t.Errorf("Expected the users named or mailed john, got %d, %+v, %v", count, users, err)
+	}
+	// Wildcards are matched literally.
+	count, users, err = s.List(ctx, where.Search("100%", "name"))
+	if err != nil || count != 1 || users[0].Name != "100% Bob" {
+		t.Errorf("Expected the user named 100%%, got %d, %+v, %v", count, users, err)
+	}
+	count, users, err = s.List(ctx, where.ILike("name", where.Prefix("JOHN")).NotLike("email", "%@y.io"))
+	if err != nil || count != 1 || users[0].Name != "John Doe" {
+		t.Errorf("Expected the user named John regardless of case, got %d, %+v, %v", count, users, err)
+	}
+	if count, err := s.Count(ctx, where.Search("", "name")); err != nil || count != 4 {
+		t.Errorf("Expected an empty search to match every user, got %d, %v", count, err)
+	}
+}
+
+func TestAllowedFields(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice@x.io", Age: 30}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	opts, err := where.ParseFilter(`name = "alice" age >= 18`)
+	if err != nil {
+		t.Fatalf("ParseFilter failed: %v", err)
+	}
+	count, users, err := s.List(ctx, opts.AllowFields("name", "age").AllowSorts("name").Or("name desc").Select("name"))
+	if err != nil || count != 1 || users[0].Name != "alice" || users[0].Email != "" {
+		t.Errorf("Expected the name of alice, got %d, %+v, %v", count, users, err)
+	}
+
+	if count, users, err := s.List(ctx, where.AllowSorts("name").Sort("name", where.Desc)); err != nil || count != 1 || len(users) != 1 {
+		t.Errorf("Expected alice sorted by name, got %d, %+v, %v", count, users, err)
+	}
+
+	for _, opts := range []*where.Options{
+		where.AllowFields("name").Gte("age", 18),
+		where.AllowSorts("name").Or("name; DROP TABLE test_users"),
+		where.AllowSorts("name").Or("email asc"),
+		where.AllowFields("name").Select("name", "(SELECT 1)"),
+		where.AllowSorts("name").Sort("email", where.Asc),
+	} {
+		if _, _, err := s.List(ctx, opts); !errors.Is(err, where.ErrFieldNotAllowed) {
+			t.Errorf("Expected ErrFieldNotAllowed for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestQueryCache(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+	s := NewStore[testUser](provider, WithQueryCache[testUser](time.Minute))
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var queries atomic.Int64
+	if err := provider.db.Callback().Query().Before("gorm:query").Register("test:count", func(db *gorm.DB) {
+		if !db.DryRun {
+			queries.Add(1)
+		}
+	}); err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if count, users, err := s.List(ctx, where.F("name", "alice")); err != nil || count != 1 || len(users) != 1 {
+				t.Errorf("Expected alice, got %d, %v, %v", count, users, err)
+			}
+		}()
+	}
+	wg.Wait()
+	// A List runs a SELECT and a COUNT.
+	if n := queries.Load(); n != 2 {
+		t.Errorf("Expected concurrent identical lists to run once, got %d queries", n)
+	}
+
+	// Reads do not invalidate the cache.
+	for range 2 {
+		if user, err := s.GetOrNil(ctx, where.F("name", "alice")); user == nil || err != nil {
+			t.Errorf("Expected alice, got %+v, %v", user, err)
+		}
+	}
+	if _, _, err := s.List(ctx, where.F("name", "alice")); err != nil {
+		t.Errorf("List failed: %v", err)
+	}
+	if n := queries.Load(); n != 3 {
+		t.Errorf("Expected GetOrNil to be cached without clearing the cache, got %d queries", n)
+	}
+
+	user, _ := s.Get(ctx, where.F("name", "alice"))
+	user.Name = "changed"
+	if again, _ := s.Get(ctx, where.F("name", "alice")); again == nil || again.Name != "alice" {
+		t.Errorf("Expected cached objects to be unaffected by changes to a returned copy, got %+v", again)
+	}
+
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice2@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if count, _, _ := s.List(ctx, where.F("name", "alice")); count != 2 {
+		t.Errorf("Expected writes to invalidate the cache, got %d objects", count)
+	}
+
+	// Raw statements and raw queries may write, within transactions or not.
+	err := s.Tx(ctx, func(txCtx context.Context) error {
+		_, err := s.Exec(txCtx, "UPDATE test_users SET name = ? WHERE email = ?", "bob", "alice2@x.io")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Tx failed: %v", err)
+	}
+	if count, _, _ := s.List(ctx, where.F("name", "alice")); count != 1 {
+		t.Errorf("Expected a committed Exec to invalidate the cache, got %d objects", count)
+	}
+	var updated []testUser
+	if err := s.RawQuery(ctx, &updated, "UPDATE test_users SET name = ? RETURNING *", "carol"); err != nil {
+		t.Fatalf("RawQuery failed: %v", err)
+	}
+	if count, _, _ := s.List(ctx, where.F("name", "alice")); count != 0 {
+		t.Errorf("Expected RawQuery to invalidate the cache, got %d objects", count)
+	}
+
+	// Preloaded associations are part of the cached results.
+	if err := provider.db.AutoMigrate(&testCustomer{}, &testOrder{}, &testItem{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	customers := NewStore[testCustomer](provider, WithQueryCache[testCustomer](time.Minute))
+	dave := &testCustomer{Name: "dave", Orders: []testOrder{{Status: "paid"}, {Status: "pending"}}}
+	if err := customers.Create(ctx, dave); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got, err := customers.Get(ctx, where.F("id", dave.ID)); err != nil || len(got.Orders) != 0 {
+		t.Fatalf("Expected dave without orders, got %+v, %v", got, err)
+	}
+	if got, err := customers.Get(ctx, where.F("id", dave.ID).Preload("Orders")); err != nil || len(got.Orders) != 2 {
+		t.Errorf("Expected dave with the 2 orders, got %+v, %v", got, err)
+	}
+	if got, err := customers.Get(ctx, where.F("id", dave.ID).PreloadWhere("Orders", "status = ?", "paid")); err != nil || len(got.Orders) != 1 {
+		t.Errorf("Expected dave with the paid order, got %+v, %v", got, err)
+	}
+}
+
+type testCountry struct {
+	Code string `gorm:"primaryKey"`
+	Name string
+}
+
+func TestDefaultOrder(t *testing.T) {
+	s, provider := newTestStore(t)
+	ctx := context.Background()
+	for i, name := range []string{"b", "a", "c"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	ordered := NewStore[testUser](provider, WithDefaultOrder[testUser]("name asc"))
+	if _, users, err := ordered.List(ctx, nil); err != nil || len(users) != 3 || users[0].Name != "a" || users[2].Name != "c" {
+		t.Errorf("Expected objects ordered by name, got %v, %v", users, err)
+	}
+
+	// Models without id are ordered by primary key.
+	if err := provider.db.AutoMigrate(&testCountry{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	countries := NewStore[testCountry](provider)
+	for _, code := range []string{"FR", "JP", "DE"} {
+		if err := countries.Create(ctx, &testCountry{Code: code}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if _, ret, err := countries.List(ctx, nil); err != nil || len(ret) != 3 || ret[0].Code != "JP" || ret[2].Code != "DE" {
+		t.Errorf("Expected countries ordered by code descending, got %v, %v", ret, err)
+	}
+}
+
+func TestOrderByCase(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+	s := NewStore[testUser](provider, WithDefaultOrder[testUser]("name asc"))
+	for i, name := range []string{"low", "high", "mid", "high"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i), Age: i}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	names := func(users []*testUser) string {
+		var ret []string
+		for _, user := range users {
+			ret = append(ret, fmt.Sprintf("%s/%d", user.Name, user.Age))
+		}
+		return strings.Join(ret, ",")
+	}
+
+	// Sorts by values replace the default order of the store.
+	_, users, err := s.List(ctx, where.OrderByCase("name", []string{"high", "mid"}).ThenBy("age", where.Desc))
+	if err != nil || names(users) != "high/3,high/1,mid/2,low/0" {
+		t.Errorf("Expected objects ordered by priority then age, got %s, %v", names(users), err)
+	}
+	_, users, err = s.List(ctx, where.OrderByCase("name", []string{"mid"}).OrderByCase("age", []string{"3", "0"}))
+	if err != nil || names(users) != "mid/2,high/3,low/0,high/1" {
+		t.Errorf("Expected objects ordered by both priorities, got %s, %v", names(users), err)
+	}
+
+	// ListByCursor ignores the order of opts and pages by primary key.
+	var got []*testUser
+	for cursor := ""; ; {
+		page, next, err := s.ListByCursor(ctx, where.OrderByCase("name", []string{"mid"}), cursor, 3)
+		if err != nil {
+			t.Fatalf("ListByCursor failed: %v", err)
+		}
+		got = append(got, page...)
+		if cursor = next; cursor == "" {
+			break
+		}
+	}
+	if names(got) != "high/3,mid/2,high/1,low/0" {
+		t.Errorf("Expected every object once by descending primary key, got %s", names(got))
+	}
+}
+
+func TestDefaultWhere(t *testing.T) {
+	_, provider := newTestStore(t)
+	ctx := context.Background()
+	s := NewStore[testUser](provider, WithDefaultWhere[testUser](where.NewWhere().Q("name <> ?", "draft").L(1)))
+	for i, name := range []string{"draft", "a", "b"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	for range 2 {
+		if count, users, err := s.List(ctx, nil); err != nil || count != 2 || len(users) != 2 {
+			t.Errorf("Expected the 2 objects matching the default conditions, got %d, %v, %v", count, users, err)
+		}
+	}
+	if _, err := s.Get(ctx, where.F("name", "draft")); !IsNotFound(err) {
+		t.Errorf("Expected the draft to be hidden, got %v", err)
+	}
+	if count, err := s.Count(ctx, where.NoDefaultWhere()); err != nil || count != 3 {
+		t.Errorf("Expected the default conditions to be bypassed, got %d, %v", count, err)
+	}
+}
+
+type hookedUser struct {
+	ID   uint
+	Name string
+}
+
+func (u *hookedUser) BeforeCreate(*gorm.DB) error {
+	u.Name = "hooked"
+	return nil
+}
+
+func TestSessionOptions(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&hookedUser{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	s := NewStore[hookedUser](provider)
+	ctx := context.Background()
+
+	user := &hookedUser{Name: "alice"}
+	if err := s.Create(ctx, user); err != nil || user.Name != "hooked" {
+		t.Fatalf("Expected the model hook to run, got %+v, %v", user, err)
+	}
+
+	user = &hookedUser{Name: "bob"}
+	if err := s.Create(WithSessionOptions(ctx, SessionOptions{SkipHooks: true}), user); err != nil || user.Name != "bob" {
+		t.Errorf("Expected the model hook to be skipped, got %+v, %v", user, err)
+	}
+}
+
+type testSetting struct {
+	TenantID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Code     string `gorm:"primaryKey"`
+	Value    string
+}
+
+func TestCompositeKey(t *testing.T) {
+	_, provider := newTestStore(t)
+	if err := provider.db.AutoMigrate(&testSetting{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	s := NewStore[testSetting](provider)
+	ctx := context.Background()
+	for _, setting := range []testSetting{{1, "lang", "fr"}, {2, "lang", "ja"}} {
+		if err := s.Create(ctx, &setting); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	setting, err := s.GetByKey(ctx, map[string]any{"tenant_id": 2, "code": "lang"})
+	if err != nil || setting.Value != "ja" {
+		t.Errorf("Expected the setting of tenant 2, got %+v, %v", setting, err)
+	}
+
+	if err := s.DeleteByKey(ctx, map[string]any{"code": "lang"}); !errors.Is(err, ErrIncompleteKey) {
+		t.Errorf("Expected incomplete key error, got %v", err)
+	}
+	if err := s.DeleteByKey(ctx, map[string]any{"tenant_id": 1, "code": ""}); !errors.Is(err, ErrIncompleteKey) {
+		t.Errorf("Expected incomplete key error for a zero part, got %v", err)
+	}
+	if count, _ := s.Count(ctx, nil); count != 2 {
+		t.Errorf("Expected incomplete keys not to delete anything, got %d objects left", count)
+	}
+
+	if err := s.DeleteByKey(ctx, map[string]any{"tenant_id": 1, "code": "lang"}); err != nil {
+		t.Fatalf("DeleteByKey failed: %v", err)
+	}
+	if count, _ := s.Count(ctx, nil); count != 1 {
+		t.Errorf("Expected a single object to be deleted, got %d objects left", count)
+	}
+}
+
+func TestApproxCount(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	for i := range 3 {
+		if err := s.Create(ctx, &testUser{Name: "a", Email: fmt.Sprintf("%d@x.io", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	// SQLite has no table statistics, the count stays exact.
+	if count, users, err := s.List(ctx, where.ApproxCount().L(1)); err != nil || count != 3 || len(users) != 1 {
+		t.Errorf("Expected an exact count of 3, got %d, %v, %v", count, users, err)
+	}
+	if _, ok := s.estimateCount(ctx, where.ApproxCount().F("name", "a")); ok {
+		t.Errorf("Expected queries with conditions not to be estimated")
+	}
+}
+
+type testUserView struct {
+	ID   uint
+	Name string
+}
+
+func (testUserView) TableName() string { return "test_user_names" }
+
+func TestReadOnlyStore(t *testing.T) {
+	s, provider := newTestStore(t)
+	ctx := context.Background()
+	if err := provider.db.Exec("CREATE VIEW test_user_names AS SELECT id, name FROM test_users WHERE deleted_at IS NULL").Error; err != nil {
+		t.Fatalf("Failed to create view: %v", err)
+	}
+	if err := s.Create(ctx, &testUser{Name: "alice", Email: "alice@x.io"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var view IReadStore[testUserView] = NewReadOnlyStore[testUserView](provider)
+	if count, rows, err := view.List(ctx, where.F("name", "alice")); err != nil || count != 1 || rows[0].Name != "alice" {
+		t.Errorf("Expected alice from the view, got %d, %v, %v", count, rows, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	_, provider := newTestStore(t)
+	s := NewStore[testUser](provider, WithHistory[testUser]())
+	ctx := WithOperator(context.Background(), "alice")
+	if err := s.MigrateHistory(ctx); err != nil {
+		t.Fatalf("MigrateHistory failed: %v", err)
+	}
+
+	user := &testUser{Name: "v1", Email: "user@x.io"}
+	if err := s.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	user.Name = "v2"
+	if err := s.Update(ctx, user); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := s.UpdateWhere(ctx, where.F("id", user.ID), map[string]any{"name": "v3"}); err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if err := s.Delete(ctx, where.F("id", user.ID)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	history, err := s.History(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	want := []struct {
+		op   Operation
+		name string
+	}{{OperationUpdate, "v1"}, {OperationUpdate, "v2"}, {OperationDelete, "v3"}}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d versions, got %+v", len(want), history)
+	}
+	for i, entry := range history {
+		if entry.Operation != want[i].op || entry.Before.Name != want[i].name || entry.Actor != "alice" {
+			t.Errorf("Expected version %d to be %v of %s by alice, got %+v", i, want[i].op, want[i].name, entry)
+		}
+	}
+
+	// Writes matching more rows than a batch record all of them.
+	users := make([]*testUser, 250)
+	for i := range users {
+		users[i] = &testUser{Name: "bulk", Email: fmt.Sprintf("bulk%d@x.io", i)}
+	}
+	if _, err := s.CreateBatch(ctx, users, 100); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	if _, err := s.UpdateWhere(ctx, where.F("name", "bulk"), map[string]any{"age": 40}); err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	var recorded int64
+	if err := provider.db.Table("test_users_history").Where("operation = ?", OperationUpdate).Count(&recorded).Error; err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if recorded != 2+250 {
+		t.Errorf("Expected 252 update versions, got %d", recorded)
+	}
+}
+
+func TestExport(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	for i, name := range []string{"alice", "bob, jr"} {
+		if err := s.Create(ctx, &testUser{Name: name, Email: fmt.Sprintf("%d@x.io", i), Age: 30 + i}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	var csv strings.Builder
+	if err := s.Export(ctx, where.Select("id", "name", "age"), &csv, ExportCSV); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if want := "id,name,age\n1,alice,30\n2,\"bob, jr\",31\n"; csv.String() != want {
+		t.Errorf("Expected CSV:\n%s\ngot:\n%s", want, csv.String())
+	}
+
+	var ndjson strings.Builder
+	if err := s.Export(ctx, where.F("name", "alice"), &ndjson, ExportNDJSON); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(ndjson.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"Name":"alice"`) {
+		t.Errorf("Expected a JSON line for alice, got %q", ndjson.String())
+	}
+
+	// An empty export holds the header and is imported back.
+	empty, _ := newTestStore(t)
+	var header strings.Builder
+	if err := empty.Export(ctx, nil, &header, ExportCSV); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.HasPrefix(header.String(), "id,name,") {
+		t.Errorf("Expected the CSV header, got %q", header.String())
+	}
+	result, err := s.Import(ctx, strings.NewReader(header.String()), ExportCSV)
+	if err != nil || result.Imported != 0 || len(result.Errors) != 0 {
+		t.Errorf("Expected the empty export to be imported back, got %+v, %v", result, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	input := "name,email,age\nalice,alice@x.io,30\nbob,bob@x.io,abc\ncarol,alice@x.io,40\ndave,dave@x.io,\neve,,50\n"
+	hook := WithImportHook(func(_ context.Context, u *testUser) error {
+		if u.Email == "" {
+			return errors.New("email is required")
+		}
+		return nil
+	})
+	result, err := s.Import(ctx, strings.NewReader(input), ExportCSV, WithImportBatchSize[testUser](2), hook)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("Expected alice and dave to be imported, got %d", result.Imported)
+	}
+	var lines []int
+	for _, e := range result.Errors {
+		lines = append(lines, e.Line)
+	}
+	// bob has an invalid age, carol a duplicate email and eve no email.
+	if !slices.Equal(lines, []int{3, 4, 6}) || !IsDuplicateKey(result.Errors[1]) {
+		t.Errorf("Expected errors on lines 3, 4 and 6, got %v", result.Errors)
+	}
+
+	var exported strings.Builder
+	if err := s.Export(ctx, nil, &exported, ExportNDJSON); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	other, _ := newTestStore(t)
+	result, err = other.Import(ctx, strings.NewReader(exported.String()+"\n{bad json}\n"), ExportNDJSON)
+	if err != nil || result.Imported != 2 || len(result.Errors) != 1 || result.Errors[0].Line != 4 {
+		t.Errorf("Expected the export to be imported back, got %+v, %v", result, err)
+	}
+
+	// A malformed record is skipped and reported, not a failure of the import.
+	malformed, _ := newTestStore(t)
+	result, err = malformed.Import(ctx, strings.NewReader("name,email\nalice,a@x.io\n\"bad,b@x.io\n"), ExportCSV)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	var parseErr *csv.ParseError
+	if result.Imported != 1 || len(result.Errors) != 1 || !errors.As(result.Errors[0], &parseErr) || result.Errors[0].Line != 3 {
+		t.Errorf("Expected alice to be imported and the malformed record reported, got %+v", result)
+	}
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// WithTableFunc returns an Option resolving the table of every operation of the store
+// with fn, so that a single store can target the physical tables of a model split by
+// date or tenant, such as monthly partitions:
+//
+//	events := store.NewStore[Event](provider, store.WithTableFunc[Event](func(ctx context.Context) string {
+//		return "events_" + time.Now().Format("2006_01")
+//	}))
+//
+// The table of the model is used when fn returns an empty string. The table given to
+// the where options of an operation (see where.Options.Table) takes precedence. fn must
+// return trusted table names, as they are not escaped.
+func WithTableFunc[T any](fn func(ctx context.Context) string) Option[T] {
+	return func(s *Store[T]) {
+		s.tableFunc = fn
+	}
+}
+
+// scopeTable makes db target the table resolved for ctx, if any.
+func (s *Store[T]) scopeTable(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if s.tableFunc == nil {
+		return db
+	}
+	if table := s.tableFunc(ctx); table != "" {
+		return db.Table(table)
+	}
+	return db
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantKey is the context key holding the tenant of the current request.
+type tenantKey struct{}
+
+// withoutTenantKey is the context key marking contexts operating on all the tenants.
+type withoutTenantKey struct{}
+
+// WithTenant returns a context carrying the tenant ID used by stores configured with
+// WithTenancy to scope their queries.
+func WithTenant(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// WithoutTenant returns a context on which stores configured with WithTenancy operate
+// on the rows of all the tenants, for administration and maintenance jobs. A tenant
+// carried by ctx still takes precedence.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutTenantKey{}, true)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx, if any.
+func TenantFromContext(ctx context.Context) (any, bool) {
+	id := ctx.Value(tenantKey{})
+	return id, id != nil
+}
+
+// WithTenancy returns an Option scoping the store to the tenant carried by the context
+// (see WithTenant): every query is restricted with `column = <tenant ID>` and the tenant
+// column of created objects is filled. Update only modifies rows of the tenant and
+// no longer inserts objects that do not exist. Upsert conflicts are resolved by the
+// unique constraints of the table, which should therefore include the tenant column.
+// Operations called with a context carrying no tenant fail with an error matching
+// ErrNoTenant, so that a missing tenant cannot expose the rows of all the tenants:
+// use WithoutTenant for the operations legitimately spanning tenants. RawQuery and
+// Exec are never scoped.
+func WithTenancy[T any](column string) Option[T] {
+	return func(s *Store[T]) {
+		s.tenantColumn = column
+	}
+}
+
+// tenant returns the tenant the operations called with ctx are scoped to.
+func (s *Store[T]) tenant(ctx context.Context) (any, bool) {
+	if s.tenantColumn == "" {
+		return nil, false
+	}
+	return TenantFromContext(ctx)
+}
+
+// checkTenant returns an error matching ErrNoTenant when the store is configured with
+// WithTenancy and ctx neither carries a tenant nor is marked with WithoutTenant.
+func (s *Store[T]) checkTenant(ctx context.Context) error {
+	if s.tenantColumn == "" || ctx.Value(withoutTenantKey{}) != nil {
+		return nil
+	}
+	if _, ok := TenantFromContext(ctx); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: store of %s requires a tenant in context", ErrNoTenant, reflect.TypeFor[T]().Name())
+}
+
+// scopeTenant restricts db to the rows of the tenant carried by ctx.
+func (s *Store[T]) scopeTenant(ctx context.Context, db *gorm.DB) *gorm.DB {
+	id, ok := s.tenant(ctx)
+	if !ok {
+		if err := s.checkTenant(ctx); err != nil {
+			_ = db.AddError(err)
+		}
+		return db
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: s.tenantColumn}
+	return db.Where(clause.Eq{Column: column, Value: id})
+}
+
+// fillTenant sets the tenant column of objs to the tenant carried by ctx.
+func (s *Store[T]) fillTenant(ctx context.Context, objs ...*T) error {
+	id, ok := s.tenant(ctx)
+	if !ok {
+		return s.checkTenant(ctx)
+	}
+
+	sch, err := schemaOf[T](s.db(ctx))
+	if err != nil {
+		return err
+	}
+	field := sch.LookUpField(s.tenantColumn)
+	if field == nil {
+		return fmt.Errorf("model %s has no tenant column %s", sch.Name, s.tenantColumn)
+	}
+	for _, obj := range objs {
+		if err := field.Set(ctx, reflect.ValueOf(obj).Elem(), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
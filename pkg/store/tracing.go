@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// tracerName is the instrumentation scope of the spans emitted by the store.
+const tracerName = "github.com/miladystack/miladystack/pkg/store"
+
+// TracingOption defines a function type for configuring the tracing of a Store.
+type TracingOption func(*tracing)
+
+// tracing holds the tracing configuration of a Store.
+type tracing struct {
+	tracer     trace.Tracer
+	statements bool
+}
+
+// WithStatements records the SQL statements executed by an operation on its span.
+// Statements include the query arguments, so it should not be enabled when they
+// contain sensitive data.
+func WithStatements(enabled bool) TracingOption {
+	return func(t *tracing) {
+		t.statements = enabled
+	}
+}
+
+// WithTracing returns an Option emitting an OpenTelemetry span for every store
+// operation, as a child of the span carried by the context. Spans carry the entity
+// name, the number of rows affected by every statement and the error status.
+// The global TracerProvider is used when tp is nil.
+func WithTracing[T any](tp trace.TracerProvider, opts ...TracingOption) Option[T] {
+	return func(s *Store[T]) {
+		if tp == nil {
+			tp = otel.GetTracerProvider()
+		}
+		t := &tracing{tracer: tp.Tracer(tracerName)}
+		for _, opt := range opts {
+			opt(t)
+		}
+		s.tracing = t
+	}
+}
+
+// startSpan starts the span of the given operation.
+func (t *tracing) startSpan(ctx context.Context, entity string, operation string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, entity+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("store.entity", entity),
+			attribute.String("store.operation", operation),
+		),
+	)
+}
+
+// endSpan records err on span and ends it. Not found errors are expected outcomes
+// of lookups and do not mark the span as failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !IsNotFound(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traced makes db report the statements it executes on the span carried by its context.
+func (s *Store[T]) traced(db *gorm.DB) *gorm.DB {
+	if s.tracing == nil {
+		return db
+	}
+	return db.Session(&gorm.Session{Logger: &tracingLogger{Interface: db.Logger, statements: s.tracing.statements}})
+}
+
+// tracingLogger is a GORM logger adding every executed statement as an event to the
+// span carried by the statement context, then delegating to the wrapped logger.
+type tracingLogger struct {
+	gormlogger.Interface
+	statements bool
+}
+
+// LogMode implements gorm logger.Interface.
+func (l *tracingLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &tracingLogger{Interface: l.Interface.LogMode(level), statements: l.statements}
+}
+
+// Trace implements gorm logger.Interface.
+func (l *tracingLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		sql, rows := fc()
+		attrs := []attribute.KeyValue{
+			attribute.Int64("db.rows_affected", rows),
+			attribute.Int64("db.duration_ms", time.Since(begin).Milliseconds()),
+		}
+		if l.statements {
+			attrs = append(attrs, attribute.String("db.statement", sql))
+		}
+		span.AddEvent("query", trace.WithAttributes(attrs...))
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key holding the transaction opened on a DBProvider.
+// Keying by provider lets stores backed by different databases run side by side
+// while every store sharing the same provider joins the same transaction.
+// The provider must therefore be comparable, which is the case for pointer implementations.
+type txKey struct {
+	provider DBProvider
+}
+
+// txState holds a transaction and the callbacks to run once it has been committed.
+type txState struct {
+	db          *gorm.DB
+	afterCommit []func()
+}
+
+// WithTx runs fn inside a database transaction opened on the given DBProvider.
+// The transaction is stored in the context passed to fn, so every Store created
+// with the same provider and called with txCtx participates in it.
+// The transaction is committed when fn returns nil and rolled back when fn
+// returns an error or panics. Nested calls create a savepoint within the outer transaction.
+func WithTx(ctx context.Context, provider DBProvider, fn func(txCtx context.Context) error) error {
+	parent, nested := txFromContext(ctx, provider)
+
+	var db *gorm.DB
+	if nested {
+		db = parent.db
+	} else {
+		db = provider.DB(ctx)
+	}
+
+	state := &txState{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		state.db = tx
+		return fn(context.WithValue(ctx, txKey{provider: provider}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Callbacks of a savepoint only run once the outermost transaction has been committed.
+	if nested {
+		parent.afterCommit = append(parent.afterCommit, state.afterCommit...)
+		return nil
+	}
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+// Tx runs fn inside a transaction opened on the DBProvider of the store.
+// See WithTx for details. When the store is configured with WithRetry, a transaction
+// failing with a transient error is retried as a whole, unless it is nested.
+func (s *Store[T]) Tx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	ctx, op := s.begin(ctx, "Tx")
+	defer func() { op.end(err) }()
+
+	_, nested := txFromContext(ctx, s.storage)
+	if s.retryPolicy != nil && !nested {
+		err = s.retryPolicy.do(ctx, s.logger, func() error { return WithTx(ctx, s.storage, fn) })
+	} else {
+		err = WithTx(ctx, s.storage, fn)
+	}
+	if err != nil {
+		s.logger.Error(ctx, err, "Transaction failed and was rolled back")
+		return wrapError(err)
+	}
+	return nil
+}
+
+// txFromContext returns the transaction opened on provider and stored in ctx, if any.
+func txFromContext(ctx context.Context, provider DBProvider) (*txState, bool) {
+	state, ok := ctx.Value(txKey{provider: provider}).(*txState)
+	return state, ok
+}
+
+// afterCommit runs callback once the transaction carried by ctx has been committed,
+// or immediately when ctx does not carry a transaction opened on provider.
+// The callback is dropped when the transaction is rolled back.
+func afterCommit(ctx context.Context, provider DBProvider, callback func()) {
+	if state, ok := txFromContext(ctx, provider); ok {
+		state.afterCommit = append(state.afterCommit, callback)
+		return
+	}
+	callback()
+}
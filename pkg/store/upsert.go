@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// Upsert inserts obj or, when a row with the same conflictColumns already exists,
+// updates the updateColumns of that row instead. When updateColumns is empty all
+// columns except the primary key are updated.
+//
+// Change events describe the creation of obj or the update of the conflicting row.
+//
+// conflictColumns is used as the ON CONFLICT target by PostgreSQL and SQLite.
+// MySQL ignores it and resolves the conflict with any unique index (ON DUPLICATE KEY UPDATE).
+func (s *Store[T]) Upsert(ctx context.Context, obj *T, conflictColumns []string, updateColumns []string) (err error) {
+	ctx, op := s.begin(ctx, "Upsert")
+	defer func() { op.end(err) }()
+
+	if err := runHooks(ctx, s.hooks.beforeCreate, obj); err != nil {
+		return err
+	}
+	if err := s.fillTenant(ctx, obj); err != nil {
+		return err
+	}
+	if err := s.fillAudit(ctx, true, obj); err != nil {
+		return err
+	}
+
+	before, err := s.snapshotConflicts(ctx, []*T{obj}, conflictColumns)
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to retrieve object state before upsert", "object", obj)
+		return wrapError(err)
+	}
+
+	err = s.retry(ctx, func() error {
+		return s.returning(ctx, s.db(ctx)).Clauses(onConflict(conflictColumns, updateColumns)).Create(obj).Error
+	})
+	if err != nil {
+		s.logger.Error(ctx, err, "Failed to upsert object into database",
+			"object", obj, "conflictColumns", conflictColumns, "updateColumns", updateColumns)
+		return wrapError(err)
+	}
+	s.collectReturned(ctx, obj)
+	s.publishUpserted(ctx, before, []*T{obj})
+	return runHooks(ctx, s.hooks.afterCreate, obj)
+}
+
+// UpsertBatch inserts objs with a single multi-row INSERT ... ON CONFLICT (ON DUPLICATE
+// KEY UPDATE on MySQL) statement per batch of defaultBatchSize objects, updating the
+// updateColumns of the rows conflicting on conflictColumns instead, like Upsert. It is
+// meant for high-throughput synchronization jobs, and returns the number of rows
+// affected as reported by the database: MySQL counts the updated rows twice.
+//
+// Each batch is committed on its own, so when a batch fails the rows of the previous
+// batches stay upserted; run it inside Tx for all-or-nothing semantics. objs must not
+// hold two objects conflicting with each other, which PostgreSQL rejects.
+func (s *Store[T]) UpsertBatch(ctx context.Context, objs []*T, conflictColumns []string, updateColumns []string) (affected int64, err error) {
+	ctx, op := s.begin(ctx, "UpsertBatch")
+	defer func() { op.end(err) }()
+
+	for start := 0; start < len(objs); start += defaultBatchSize {
+		batch := objs[start:min(start+defaultBatchSize, len(objs))]
+		if err := runHooks(ctx, s.hooks.beforeCreate, batch...); err != nil {
+			return affected, err
+		}
+		if err := s.fillTenant(ctx, batch...); err != nil {
+			return affected, err
+		}
+		if err := s.fillAudit(ctx, true, batch...); err != nil {
+			return affected, err
+		}
+		before, err := s.snapshotConflicts(ctx, batch, conflictColumns)
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to retrieve objects state before upsert", "offset", start, "size", len(batch))
+			return affected, wrapError(err)
+		}
+
+		var rows int64
+		err = s.retry(ctx, func() error {
+			result := s.returning(ctx, s.db(ctx)).Clauses(onConflict(conflictColumns, updateColumns)).Create(batch)
+			rows = result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			s.logger.Error(ctx, err, "Failed to upsert batch into database", "offset", start, "size", len(batch),
+				"conflictColumns", conflictColumns, "updateColumns", updateColumns)
+			return affected, wrapError(err)
+		}
+		affected += rows
+		s.collectReturned(ctx, batch...)
+		s.publishUpserted(ctx, before, batch)
+
+		if err := runHooks(ctx, s.hooks.afterCreate, batch...); err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// onConflict builds the ON CONFLICT clause shared by the upsert operations.
+func onConflict(conflictColumns []string, updateColumns []string) clause.OnConflict {
+	columns := make([]clause.Column, 0, len(conflictColumns))
+	for _, name := range conflictColumns {
+		columns = append(columns, clause.Column{Name: name})
+	}
+
+	if len(updateColumns) == 0 {
+		return clause.OnConflict{Columns: columns, UpdateAll: true}
+	}
+	return clause.OnConflict{Columns: columns, DoUpdates: clause.AssignmentColumns(updateColumns)}
+}
@@ -0,0 +1,135 @@
+package where
+
+import (
+	"errors"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrFieldNotAllowed is returned when options filter on, select or sort by a column
+// missing from their allow-list.
+var ErrFieldNotAllowed = errors.New("field not allowed")
+
+// WithAllowedFields creates an Option that restricts the columns the query may filter on.
+func WithAllowedFields(columns ...string) Option {
+	return func(whr *Options) {
+		whr.AllowedFields = columns
+	}
+}
+
+// WithAllowedSorts creates an Option that restricts the columns the query may be ordered by.
+func WithAllowedSorts(columns ...string) Option {
+	return func(whr *Options) {
+		whr.AllowedSorts = columns
+	}
+}
+
+// WithMaxLimit creates an Option that restricts the page size of the query.
+func WithMaxLimit(max int) Option {
+	return func(whr *Options) {
+		whr.MaxLimit = max
+	}
+}
+
+// AllowFields restricts the columns the filters and clauses may refer to, and the
+// columns Select may fetch, for options built from user input such as ParseFilter or
+// a field mask. Queries and raw SQL clauses are not checked, as they cannot carry
+// user input safely anyway.
+func (whr *Options) AllowFields(columns ...string) *Options {
+	whr.AllowedFields = columns
+	return whr
+}
+
+// AllowSorts restricts the columns the query may be ordered by, for orders read from
+// user input such as Or(req.OrderBy). The order must then be a comma separated list
+// of allowed columns, each optionally followed by asc or desc.
+func (whr *Options) AllowSorts(columns ...string) *Options {
+	whr.AllowedSorts = columns
+	return whr
+}
+
+// AllowLimit restricts the page size of the query to max, for pagination read from user
+// input such as P(req.Page, req.PageSize): the limit must then be between 1 and max.
+func (whr *Options) AllowLimit(max int) *Options {
+	whr.MaxLimit = max
+	return whr
+}
+
+// AllowFields is a convenience function to create a new Options restricting the columns filtered on.
+func AllowFields(columns ...string) *Options {
+	return NewWhere().AllowFields(columns...)
+}
+
+// AllowSorts is a convenience function to create a new Options restricting the columns ordered by.
+func AllowSorts(columns ...string) *Options {
+	return NewWhere().AllowSorts(columns...)
+}
+
+// AllowLimit is a convenience function to create a new Options restricting the page size.
+func AllowLimit(max int) *Options {
+	return NewWhere().AllowLimit(max)
+}
+
+// visitColumns calls fn with the columns the conditions of expr refer to. Raw SQL
+// expressions are skipped.
+func visitColumns(expr clause.Expression, fn func(column string) error) error {
+	var column any
+	switch e := expr.(type) {
+	case clause.Eq:
+		column = e.Column
+	case clause.Neq:
+		column = e.Column
+	case clause.Gt:
+		column = e.Column
+	case clause.Gte:
+		column = e.Column
+	case clause.Lt:
+		column = e.Column
+	case clause.Lte:
+		column = e.Column
+	case clause.IN:
+		column = e.Column
+	case clause.Like:
+		column = e.Column
+	case LikeExpr:
+		column = e.Column
+	case BetweenExpr:
+		column = e.Column
+	case JSONEqExpr:
+		column = e.Column
+	case JSONContainsExpr:
+		column = e.Column
+	case OperatorExpr:
+		column = e.Column
+	case NotExpr:
+		return visitColumns(e.Expr, fn)
+	case clause.AndConditions:
+		return visitAllColumns(e.Exprs, fn)
+	case clause.OrConditions:
+		return visitAllColumns(e.Exprs, fn)
+	case clause.NotConditions:
+		return visitAllColumns(e.Exprs, fn)
+	case clause.Where:
+		return visitAllColumns(e.Exprs, fn)
+	default:
+		return nil
+	}
+
+	switch c := column.(type) {
+	case string:
+		return fn(c)
+	case clause.Column:
+		return fn(c.Name)
+	}
+	return nil
+}
+
+// visitAllColumns calls fn with the columns the conditions of exprs refer to.
+func visitAllColumns(exprs []clause.Expression, fn func(column string) error) error {
+	for _, expr := range exprs {
+		if err := visitColumns(expr, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package where
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+)
+
+// optionsJSON is the portable form of Options.
+type optionsJSON struct {
+	Offset           int             `json:"offset"`
+	Limit            int             `json:"limit"`
+	Filters          map[string]any  `json:"filters,omitempty"`
+	Conditions       []conditionJSON `json:"conditions,omitempty"`
+	Queries          []queryJSON     `json:"queries,omitempty"`
+	Order            string          `json:"order,omitempty"`
+	Unscoped         bool            `json:"unscoped,omitempty"`
+	Distinct         bool            `json:"distinct,omitempty"`
+	Preloads         []preloadJSON   `json:"preloads,omitempty"`
+	Joins            []queryJSON     `json:"joins,omitempty"`
+	Locking          LockStrength    `json:"locking,omitempty"`
+	SkipCount        bool            `json:"skipCount,omitempty"`
+	EstimateCount    bool            `json:"estimateCount,omitempty"`
+	Scopes           []string        `json:"scopes,omitempty"`
+	SkipDefaultWhere bool            `json:"skipDefaultWhere,omitempty"`
+	Havings          []queryJSON     `json:"havings,omitempty"`
+	Columns          []string        `json:"columns,omitempty"`
+	TableName        string          `json:"tableName,omitempty"`
+	Searches         []SearchTerm    `json:"searches,omitempty"`
+	Sorts            []SortColumn    `json:"sorts,omitempty"`
+	AllowedFields    []string        `json:"allowedFields,omitempty"`
+	AllowedSorts     []string        `json:"allowedSorts,omitempty"`
+	MaxLimit         int             `json:"maxLimit,omitempty"`
+}
+
+// queryJSON is the portable form of a Query, whose query is an SQL string or a map
+// of column values.
+type queryJSON struct {
+	Query any   `json:"query"`
+	Args  []any `json:"args,omitempty"`
+}
+
+// preloadJSON is the portable form of an Association.
+type preloadJSON struct {
+	Name string `json:"name"`
+	Args []any  `json:"args,omitempty"`
+}
+
+// conditionJSON is the portable form of a clause expression. Op names the type of
+// the expression, and the other fields hold the ones it uses.
+type conditionJSON struct {
+	Op              string          `json:"op"`
+	Name            string          `json:"name,omitempty"`
+	Table           string          `json:"table,omitempty"`
+	Column          string          `json:"column,omitempty"`
+	Path            string          `json:"path,omitempty"`
+	Value           any             `json:"value,omitempty"`
+	Values          []any           `json:"values,omitempty"`
+	CaseInsensitive bool            `json:"caseInsensitive,omitempty"`
+	SQL             string          `json:"sql,omitempty"`
+	Exprs           []conditionJSON `json:"exprs,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, so that options can be passed between
+// services, stored in job queues or logged, and decoded with UnmarshalJSON:
+//
+//	data, err := json.Marshal(where.F("status", "active").Gt("age", 18).P(1, 20))
+//
+// The conditions built by this package, the comparison, IN and LIKE expressions of
+// gorm/clause combined with clause.And, clause.Or and clause.Not, and raw SQL
+// expressions are supported. Queries must be SQL strings or maps, and preloads cannot
+// be filtered by functions.
+func (whr Options) MarshalJSON() ([]byte, error) {
+	dto := optionsJSON{
+		Offset:           whr.Offset,
+		Limit:            whr.Limit,
+		Order:            whr.Order,
+		Unscoped:         whr.Unscoped,
+		Distinct:         whr.Distinct,
+		Locking:          whr.Locking,
+		SkipCount:        whr.SkipCount,
+		EstimateCount:    whr.EstimateCount,
+		Scopes:           whr.Scopes,
+		SkipDefaultWhere: whr.SkipDefaultWhere,
+		Columns:          whr.Columns,
+		TableName:        whr.TableName,
+		Searches:         whr.Searches,
+		Sorts:            whr.Sorts,
+		AllowedFields:    whr.AllowedFields,
+		AllowedSorts:     whr.AllowedSorts,
+		MaxLimit:         whr.MaxLimit,
+	}
+	if len(whr.Filters) > 0 {
+		dto.Filters = make(map[string]any, len(whr.Filters))
+		for key, value := range whr.Filters {
+			dto.Filters[fmt.Sprint(key)] = value
+		}
+	}
+	for _, expr := range whr.Clauses {
+		cond, err := encodeCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		dto.Conditions = append(dto.Conditions, cond)
+	}
+
+	var err error
+	if dto.Queries, err = encodeQueries(whr.Queries); err != nil {
+		return nil, err
+	}
+	if dto.Joins, err = encodeQueries(whr.Joins); err != nil {
+		return nil, err
+	}
+	if dto.Havings, err = encodeQueries(whr.Havings); err != nil {
+		return nil, err
+	}
+	for _, preload := range whr.Preloads {
+		for _, arg := range preload.Args {
+			if arg != nil && reflect.TypeOf(arg).Kind() == reflect.Func {
+				return nil, fmt.Errorf("cannot encode preload %s filtered by a function", preload.Name)
+			}
+		}
+		dto.Preloads = append(dto.Preloads, preloadJSON{Name: preload.Name, Args: preload.Args})
+	}
+	return json.Marshal(dto)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding options encoded by MarshalJSON.
+// Values are decoded as JSON values: integers as int64, other numbers as float64,
+// and times as strings, which the databases convert when comparing them to columns.
+//
+// As the data may come from clients, options holding raw SQL, which are raw
+// conditions, string queries, joins, havings, preloads filtered by a string and
+// order, or changing the queried rows, which are tableName, unscoped and
+// skipDefaultWhere, are refused with ErrInvalidOptions. DecodeTrusted accepts them.
+func (whr *Options) UnmarshalJSON(data []byte) error {
+	return whr.decode(data, false)
+}
+
+// DecodeTrusted decodes options encoded by MarshalJSON like UnmarshalJSON does,
+// accepting all of them. It must only be used with data from trusted sources, such as
+// the job queues of the service.
+func DecodeTrusted(data []byte) (*Options, error) {
+	whr := new(Options)
+	if err := whr.decode(data, true); err != nil {
+		return nil, err
+	}
+	return whr, nil
+}
+
+// decode decodes options encoded by MarshalJSON, refusing the unsafe ones unless
+// trusted is set.
+func (whr *Options) decode(data []byte, trusted bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dto := optionsJSON{Limit: defaultLimit}
+	if err := dec.Decode(&dto); err != nil {
+		return err
+	}
+	if field := dto.unsafeField(); field != "" && !trusted {
+		return fmt.Errorf("%w: %s requires trusted options", ErrInvalidOptions, field)
+	}
+
+	*whr = Options{
+		Offset:           dto.Offset,
+		Limit:            dto.Limit,
+		Filters:          make(map[any]any, len(dto.Filters)),
+		Clauses:          make([]clause.Expression, 0, len(dto.Conditions)),
+		Order:            dto.Order,
+		Unscoped:         dto.Unscoped,
+		Distinct:         dto.Distinct,
+		Locking:          dto.Locking,
+		SkipCount:        dto.SkipCount,
+		EstimateCount:    dto.EstimateCount,
+		Scopes:           dto.Scopes,
+		SkipDefaultWhere: dto.SkipDefaultWhere,
+		Columns:          dto.Columns,
+		TableName:        dto.TableName,
+		Searches:         dto.Searches,
+		Sorts:            dto.Sorts,
+		AllowedFields:    dto.AllowedFields,
+		AllowedSorts:     dto.AllowedSorts,
+		MaxLimit:         dto.MaxLimit,
+	}
+	for key, value := range dto.Filters {
+		whr.Filters[key] = decodeValue(value)
+	}
+	for _, cond := range dto.Conditions {
+		expr, err := decodeCondition(cond)
+		if err != nil {
+			return err
+		}
+		whr.Clauses = append(whr.Clauses, expr)
+	}
+	whr.Queries = decodeQueries(dto.Queries)
+	whr.Joins = decodeQueries(dto.Joins)
+	whr.Havings = decodeQueries(dto.Havings)
+	for _, preload := range dto.Preloads {
+		whr.Preloads = append(whr.Preloads, Association{Name: preload.Name, Args: decodeValues(preload.Args)})
+	}
+	return nil
+}
+
+// unsafeField returns the name of the first field of dto holding raw SQL or changing
+// the queried rows, if any.
+func (dto *optionsJSON) unsafeField() string {
+	switch {
+	case dto.Order != "":
+		return "order"
+	case dto.TableName != "":
+		return "tableName"
+	case dto.Unscoped:
+		return "unscoped"
+	case dto.SkipDefaultWhere:
+		return "skipDefaultWhere"
+	case len(dto.Joins) > 0:
+		return "joins"
+	case len(dto.Havings) > 0:
+		return "havings"
+	}
+	for _, q := range dto.Queries {
+		if _, ok := q.Query.(string); ok {
+			return "queries"
+		}
+	}
+	for _, preload := range dto.Preloads {
+		if len(preload.Args) > 0 {
+			if _, ok := preload.Args[0].(string); ok {
+				return "preloads"
+			}
+		}
+	}
+	for _, cond := range dto.Conditions {
+		if cond.raw() {
+			return "conditions"
+		}
+	}
+	return ""
+}
+
+// raw reports whether cond is or combines raw SQL expressions.
+func (cond *conditionJSON) raw() bool {
+	if cond.Op == "raw" {
+		return true
+	}
+	for i := range cond.Exprs {
+		if cond.Exprs[i].raw() {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeQueries returns the portable form of queries.
+func encodeQueries(queries []Query) ([]queryJSON, error) {
+	var ret []queryJSON
+	for _, query := range queries {
+		q := queryJSON{Args: query.Args}
+		switch v := query.Query.(type) {
+		case string, map[string]any:
+			q.Query = v
+		case map[any]any:
+			m := make(map[string]any, len(v))
+			for key, value := range v {
+				m[fmt.Sprint(key)] = value
+			}
+			q.Query = m
+		default:
+			return nil, fmt.Errorf("cannot encode %T query", query.Query)
+		}
+		ret = append(ret, q)
+	}
+	return ret, nil
+}
+
+// decodeQueries returns the queries of their portable form.
+func decodeQueries(queries []queryJSON) []Query {
+	var ret []Query
+	for _, q := range queries {
+		ret = append(ret, Query{Query: decodeValue(q.Query), Args: decodeValues(q.Args)})
+	}
+	return ret
+}
+
+// encodeCondition returns the portable form of expr.
+func encodeCondition(expr clause.Expression) (conditionJSON, error) {
+	var (
+		cond   conditionJSON
+		column any
+	)
+	switch e := expr.(type) {
+	case clause.Eq:
+		cond, column = conditionJSON{Op: "eq", Value: e.Value}, e.Column
+	case clause.Neq:
+		cond, column = conditionJSON{Op: "neq", Value: e.Value}, e.Column
+	case clause.Gt:
+		cond, column = conditionJSON{Op: "gt", Value: e.Value}, e.Column
+	case clause.Gte:
+		cond, column = conditionJSON{Op: "gte", Value: e.Value}, e.Column
+	case clause.Lt:
+		cond, column = conditionJSON{Op: "lt", Value: e.Value}, e.Column
+	case clause.Lte:
+		cond, column = conditionJSON{Op: "lte", Value: e.Value}, e.Column
+	case clause.IN:
+		cond, column = conditionJSON{Op: "in", Values: e.Values}, e.Column
+	case clause.Like:
+		cond, column = conditionJSON{Op: "clauseLike", Value: e.Value}, e.Column
+	case LikeExpr:
+		cond, column = conditionJSON{Op: "like", Value: e.Pattern, CaseInsensitive: e.CaseInsensitive}, e.Column
+	case BetweenExpr:
+		cond, column = conditionJSON{Op: "between", Values: []any{e.From, e.To}}, e.Column
+	case JSONEqExpr:
+		cond, column = conditionJSON{Op: "jsonEq", Path: e.Path, Value: e.Value}, e.Column
+	case JSONContainsExpr:
+		cond, column = conditionJSON{Op: "jsonContains", Value: e.Value}, e.Column
+	case OperatorExpr:
+		cond, column = conditionJSON{Op: "operator", Name: e.Name, Values: e.Args}, e.Column
+	case clause.Expr:
+		return conditionJSON{Op: "raw", SQL: e.SQL, Values: e.Vars}, nil
+	case NotExpr:
+		return encodeConditions("not", []clause.Expression{e.Expr})
+	case clause.AndConditions:
+		return encodeConditions("and", e.Exprs)
+	case clause.OrConditions:
+		return encodeConditions("or", e.Exprs)
+	case clause.NotConditions:
+		return encodeConditions("notEach", e.Exprs)
+	case clause.Where:
+		return encodeConditions("and", e.Exprs)
+	default:
+		return conditionJSON{}, fmt.Errorf("cannot encode %T condition", expr)
+	}
+
+	switch c := column.(type) {
+	case string:
+		cond.Column = c
+	case clause.Column:
+		if c.Raw || c.Alias != "" {
+			return conditionJSON{}, fmt.Errorf("cannot encode raw or aliased column %s", c.Name)
+		}
+		cond.Table, cond.Column = c.Table, c.Name
+	default:
+		return conditionJSON{}, fmt.Errorf("cannot encode %T column", column)
+	}
+	return cond, nil
+}
+
+// encodeConditions returns the portable form of the expression combining exprs.
+func encodeConditions(op string, exprs []clause.Expression) (conditionJSON, error) {
+	cond := conditionJSON{Op: op}
+	for _, expr := range exprs {
+		c, err := encodeCondition(expr)
+		if err != nil {
+			return conditionJSON{}, err
+		}
+		cond.Exprs = append(cond.Exprs, c)
+	}
+	return cond, nil
+}
+
+// decodeCondition returns the expression of its portable form.
+func decodeCondition(cond conditionJSON) (clause.Expression, error) {
+	column := clause.Column{Table: cond.Table, Name: cond.Column}
+	value := decodeValue(cond.Value)
+	switch cond.Op {
+	case "eq":
+		return clause.Eq{Column: column, Value: value}, nil
+	case "neq":
+		return clause.Neq{Column: column, Value: value}, nil
+	case "gt":
+		return clause.Gt{Column: column, Value: value}, nil
+	case "gte":
+		return clause.Gte{Column: column, Value: value}, nil
+	case "lt":
+		return clause.Lt{Column: column, Value: value}, nil
+	case "lte":
+		return clause.Lte{Column: column, Value: value}, nil
+	case "in":
+		return clause.IN{Column: column, Values: decodeValues(cond.Values)}, nil
+	case "clauseLike":
+		return clause.Like{Column: column, Value: value}, nil
+	case "like":
+		pattern, _ := value.(string)
+		return LikeExpr{Column: cond.Column, Pattern: pattern, CaseInsensitive: cond.CaseInsensitive}, nil
+	case "between":
+		if len(cond.Values) != 2 {
+			return nil, fmt.Errorf("between condition on %s requires 2 values, got %d", cond.Column, len(cond.Values))
+		}
+		values := decodeValues(cond.Values)
+		return BetweenExpr{Column: cond.Column, From: values[0], To: values[1]}, nil
+	case "jsonEq":
+		return JSONEqExpr{Column: cond.Column, Path: cond.Path, Value: value}, nil
+	case "jsonContains":
+		return JSONContainsExpr{Column: cond.Column, Value: value}, nil
+	case "operator":
+		return OperatorExpr{Name: cond.Name, Column: cond.Column, Args: decodeValues(cond.Values)}, nil
+	case "raw":
+		return clause.Expr{SQL: cond.SQL, Vars: decodeValues(cond.Values)}, nil
+	}
+
+	exprs := make([]clause.Expression, 0, len(cond.Exprs))
+	for _, c := range cond.Exprs {
+		expr, err := decodeCondition(c)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	switch cond.Op {
+	case "not":
+		if len(exprs) != 1 {
+			return nil, fmt.Errorf("not condition requires 1 condition, got %d", len(exprs))
+		}
+		return NotExpr{Expr: exprs[0]}, nil
+	case "and":
+		return clause.AndConditions{Exprs: exprs}, nil
+	case "or":
+		return clause.OrConditions{Exprs: exprs}, nil
+	case "notEach":
+		return clause.NotConditions{Exprs: exprs}, nil
+	}
+	return nil, fmt.Errorf("unknown condition %q", cond.Op)
+}
+
+// decodeValues converts the numbers of values decoded with json.Decoder.UseNumber.
+func decodeValues(values []any) []any {
+	if values == nil {
+		return nil
+	}
+	ret := make([]any, len(values))
+	for i, value := range values {
+		ret[i] = decodeValue(value)
+	}
+	return ret
+}
+
+// decodeValue converts the numbers of a value decoded with json.Decoder.UseNumber
+// into int64, or float64 when they are not integers.
+func decodeValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		return decodeValues(v)
+	case map[string]any:
+		m := maps.Clone(v)
+		for key, elem := range m {
+			m[key] = decodeValue(elem)
+		}
+		return m
+	}
+	return value
+}
@@ -0,0 +1,111 @@
+package where
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded, because it
+// is malformed, was tampered with or was issued for another sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorMACSize is the size of the truncated HMAC-SHA256 prefixing cursors.
+const cursorMACSize = 16
+
+// cursorKey holds the key signing cursors, random until set with SetCursorKey.
+var cursorKey atomic.Pointer[[]byte]
+
+func init() {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("where: generate cursor key: %v", err))
+	}
+	cursorKey.Store(&key)
+}
+
+// SetCursorKey sets the secret key signing the cursors, so that clients cannot forge
+// cursors pointing to arbitrary positions. It is usually set during initialization.
+// Until it is set, cursors are signed with a random key generated when the process
+// starts, so they are only valid within the process that issued them: services
+// running several instances, or issuing cursors that must survive restarts, must set
+// the same key on all of them.
+func SetCursorKey(key []byte) {
+	key = append([]byte(nil), key...)
+	cursorKey.Store(&key)
+}
+
+// cursorPayload is the content of a cursor.
+type cursorPayload struct {
+	// Values holds the values of the sort columns of the last record of a page.
+	Values []any `json:"v"`
+}
+
+// Cursor returns the opaque cursor pointing right after a record, for keyset
+// pagination: values are the values of the sort columns of the last record of a page,
+// in the order of sorts. The cursor is tied to sorts, so that DecodeCursor rejects it
+// for another sort specification.
+func Cursor(sorts []SortColumn, values ...any) (string, error) {
+	if len(values) != len(sorts) {
+		return "", fmt.Errorf("encode cursor: %d values for %d sorts", len(values), len(sorts))
+	}
+	data, err := json.Marshal(cursorPayload{Values: values})
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	mac, err := cursorMAC(sorts, data)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(mac, data...)), nil
+}
+
+// DecodeCursor returns the values encoded in a cursor returned by Cursor for the same
+// sorts, as JSON to be decoded into the types of the sort columns. It returns an error
+// wrapping ErrInvalidCursor when the cursor is malformed, was tampered with or was
+// issued for other sorts.
+func DecodeCursor(cursor string, sorts []SortColumn) ([]json.RawMessage, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if len(data) < cursorMACSize {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidCursor)
+	}
+
+	mac, err := cursorMAC(sorts, data[cursorMACSize:])
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, data[:cursorMACSize]) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+	}
+
+	var payload struct {
+		Values []json.RawMessage `json:"v"`
+	}
+	if err := json.Unmarshal(data[cursorMACSize:], &payload); err != nil || len(payload.Values) != len(sorts) {
+		return nil, fmt.Errorf("%w: malformed payload", ErrInvalidCursor)
+	}
+	return payload.Values, nil
+}
+
+// cursorMAC returns the truncated HMAC of the payload of a cursor and of the sorts it
+// was issued for.
+func cursorMAC(sorts []SortColumn, data []byte) ([]byte, error) {
+	spec, err := json.Marshal(sorts)
+	if err != nil {
+		return nil, fmt.Errorf("encode cursor: %w", err)
+	}
+
+	h := hmac.New(sha256.New, *cursorKey.Load())
+	h.Write(spec)
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)[:cursorMACSize], nil
+}
@@ -0,0 +1,506 @@
+package where
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidFilter is returned by ParseFilter for malformed filter expressions.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// maxFilterDepth bounds the nesting of parentheses and negations of filter expressions,
+// which usually come from API clients.
+const maxFilterDepth = 32
+
+// ParseFilter parses a filter expression following the syntax of AIP-160, as exposed
+// by the filter parameter of List methods of gRPC and REST APIs, into Options holding
+// the equivalent conditions:
+//
+//	opts, err := where.ParseFilter(`name = "john" AND age > 30 OR status:("a", "b")`)
+//
+// The supported syntax is:
+//
+//   - comparisons of a field with a value: =, !=, <, <=, > and >=. Values are quoted
+//     strings, numbers, true, false, null or bare words, such as 2024-01-01 or active;
+//   - the has operator :, matching a value, any value of a parenthesized list, or any
+//     non-null value with *, as in status:("a", "b") or deleted_at:*;
+//   - wildcards in quoted strings compared with = or :, as in name = "jo*";
+//   - AND, OR and juxtaposition, which means AND. OR binds tighter than AND, so that
+//     a AND b OR c means a AND (b OR c);
+//   - negation with NOT or -, and grouping with parentheses.
+//
+// Fields are column names, possibly qualified with a dot. They are not checked
+// against the model: restrict them to the allowed ones before querying.
+// Errors wrap ErrInvalidFilter.
+func ParseFilter(filter string) (*Options, error) {
+	p := &filterParser{input: filter}
+	if err := p.scan(); err != nil {
+		return nil, err
+	}
+
+	opts := NewWhere()
+	if len(p.tokens) == 0 {
+		return opts, nil
+	}
+	expr, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return opts.C(expr), nil
+}
+
+// Parse parses a query of the filter syntax of ParseFilter, optionally followed by ORDER
+// BY, LIMIT and OFFSET clauses, into Options, for the command line and admin tools
+// querying stores:
+//
+//	opts, err := where.Parse(`status = active AND created_at > "2024-01-01" ORDER BY id DESC LIMIT 50`)
+//
+// ORDER BY lists columns, each optionally followed by ASC or DESC, which are sorted by
+// with Sort, so that they are checked against the model. Every part of the query is
+// optional. Errors wrap ErrInvalidFilter.
+func Parse(query string) (*Options, error) {
+	p := &filterParser{input: query}
+	if err := p.scan(); err != nil {
+		return nil, err
+	}
+
+	opts := NewWhere()
+	if tok := p.peek(); tok.kind != tokenEOF && !p.atClause() {
+		expr, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		opts.C(expr)
+	}
+
+	if p.keyword("ORDER") {
+		if !p.keyword("BY") {
+			return nil, p.errorf(p.peek(), "missing BY after ORDER")
+		}
+		for {
+			column := p.consume()
+			if column.kind != tokenWord || !validField(column.text) {
+				return nil, p.errorf(column, "invalid sort column %q", column.text)
+			}
+			direction := Asc
+			if p.keyword("DESC") {
+				direction = Desc
+			} else {
+				p.keyword("ASC")
+			}
+			opts.ThenBy(column.text, direction)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next++
+		}
+	}
+	if p.keyword("LIMIT") {
+		n, err := p.count()
+		if err != nil {
+			return nil, err
+		}
+		opts.L(n)
+	}
+	if p.keyword("OFFSET") {
+		n, err := p.count()
+		if err != nil {
+			return nil, err
+		}
+		opts.O(n)
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return opts, nil
+}
+
+// atClause reports whether the next token starts a clause following the filter of a
+// query parsed by Parse.
+func (p *filterParser) atClause() bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && (tok.text == "ORDER" || tok.text == "LIMIT" || tok.text == "OFFSET")
+}
+
+// count parses the non-negative integer of a LIMIT or OFFSET clause.
+func (p *filterParser) count() (int, error) {
+	tok := p.consume()
+	n, err := strconv.Atoi(tok.text)
+	if tok.kind != tokenWord || err != nil || n < 0 {
+		return 0, p.errorf(tok, "invalid count %q", tok.text)
+	}
+	return n, nil
+}
+
+// tokenKind is the kind of a token of a filter expression.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenComparator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+// filterToken is a token of a filter expression.
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	input  string
+	tokens []filterToken
+	next   int
+}
+
+// scan splits the input into tokens.
+func (p *filterParser) scan() error {
+	s := p.input
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			p.tokens = append(p.tokens, filterToken{kind: tokenLeftParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, filterToken{kind: tokenRightParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			p.tokens = append(p.tokens, filterToken{kind: tokenComma, text: ",", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			text, n, err := unquoteFilter(s[i:])
+			if err != nil {
+				return p.errorf(filterToken{pos: i}, "%v", err)
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenString, text: text, pos: i})
+			i += n
+		case strings.ContainsRune("=!<>:", r):
+			op := s[i : i+1]
+			if i+1 < len(s) && s[i+1] == '=' && op != "=" && op != ":" {
+				op = s[i : i+2]
+			}
+			if op == "!" {
+				return p.errorf(filterToken{pos: i}, "unexpected %q", op)
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenComparator, text: op, pos: i})
+			i += len(op)
+		default:
+			start := i
+			for i < len(s) {
+				r, size := utf8.DecodeRuneInString(s[i:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()",'=!<>:`, r) {
+					break
+				}
+				i += size
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenWord, text: s[start:i], pos: start})
+		}
+	}
+	return nil
+}
+
+// unquoteFilter returns the string literal starting s, quoted with double or single
+// quotes, and its length in s. Quotes and backslashes are escaped with a backslash.
+func unquoteFilter(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(s) {
+				break
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// peek returns the next token without consuming it.
+func (p *filterParser) peek() filterToken {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next]
+	}
+	return filterToken{kind: tokenEOF, pos: len(p.input)}
+}
+
+// consume returns the next token and moves past it.
+func (p *filterParser) consume() filterToken {
+	tok := p.peek()
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the keyword kw, consuming it if so.
+func (p *filterParser) keyword(kw string) bool {
+	if tok := p.peek(); tok.kind == tokenWord && tok.text == kw {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// errorf returns an error wrapping ErrInvalidFilter at the position of tok.
+func (p *filterParser) errorf(tok filterToken, format string, args ...any) error {
+	return fmt.Errorf("%w: %s at position %d", ErrInvalidFilter, fmt.Sprintf(format, args...), tok.pos)
+}
+
+// expression parses sequences joined by AND.
+func (p *filterParser) expression(depth int) (clause.Expression, error) {
+	if depth > maxFilterDepth {
+		return nil, p.errorf(p.peek(), "filter nested too deeply")
+	}
+
+	var exprs []clause.Expression
+	for {
+		expr, err := p.sequence(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.keyword("AND") {
+			return clause.And(exprs...), nil
+		}
+	}
+}
+
+// sequence parses juxtaposed factors, which are implicitly joined by AND.
+func (p *filterParser) sequence(depth int) (clause.Expression, error) {
+	var exprs []clause.Expression
+	for {
+		expr, err := p.factor(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.kind == tokenRightParen || (tok.kind == tokenWord && tok.text == "AND") || p.atClause() {
+			return clause.And(exprs...), nil
+		}
+	}
+}
+
+// factor parses terms joined by OR.
+func (p *filterParser) factor(depth int) (clause.Expression, error) {
+	var exprs []clause.Expression
+	for {
+		expr, err := p.term(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return clause.Or(exprs...), nil
+}
+
+// term parses a possibly negated restriction or parenthesized expression.
+func (p *filterParser) term(depth int) (clause.Expression, error) {
+	if p.keyword("NOT") {
+		return p.negate(depth)
+	}
+	if tok := p.peek(); tok.kind == tokenWord && strings.HasPrefix(tok.text, "-") {
+		if tok.text == "-" {
+			// Negated parenthesized expression, as in -(a OR b).
+			p.next++
+		} else {
+			p.tokens[p.next].text = tok.text[1:]
+			p.tokens[p.next].pos++
+		}
+		return p.negate(depth)
+	}
+
+	tok := p.consume()
+	switch tok.kind {
+	case tokenLeftParen:
+		expr, err := p.expression(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if end := p.consume(); end.kind != tokenRightParen {
+			return nil, p.errorf(end, "missing closing parenthesis")
+		}
+		return expr, nil
+	case tokenWord:
+		if tok.text == "AND" || tok.text == "OR" || tok.text == "NOT" {
+			return nil, p.errorf(tok, "unexpected %s", tok.text)
+		}
+		return p.restriction(tok)
+	case tokenEOF:
+		return nil, p.errorf(tok, "unexpected end of filter")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// negate parses the term following a negation.
+func (p *filterParser) negate(depth int) (clause.Expression, error) {
+	if depth+1 > maxFilterDepth {
+		return nil, p.errorf(p.peek(), "filter nested too deeply")
+	}
+	expr, err := p.term(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return NotExpr{Expr: expr}, nil
+}
+
+// restriction parses the comparison of the field named by tok.
+func (p *filterParser) restriction(field filterToken) (clause.Expression, error) {
+	if !validField(field.text) {
+		return nil, p.errorf(field, "invalid field %q", field.text)
+	}
+	op := p.consume()
+	if op.kind != tokenComparator {
+		return nil, p.errorf(field, "missing comparison of field %s", field.text)
+	}
+	column := clause.Column{Name: field.text}
+
+	if op.text == ":" {
+		if tok := p.peek(); tok.kind == tokenWord && tok.text == "*" {
+			p.next++
+			return clause.Neq{Column: column, Value: nil}, nil
+		}
+		if p.peek().kind == tokenLeftParen {
+			p.next++
+			values, err := p.list()
+			if err != nil {
+				return nil, err
+			}
+			return clause.IN{Column: column, Values: values}, nil
+		}
+	}
+
+	tok := p.consume()
+	value, err := p.value(tok)
+	if err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "=", ":":
+		if s, ok := value.(string); ok && tok.kind == tokenString && strings.Contains(s, "*") {
+			return LikeExpr{Column: field.text, Pattern: wildcardPattern(s)}, nil
+		}
+		return clause.Eq{Column: column, Value: value}, nil
+	case "!=":
+		return clause.Neq{Column: column, Value: value}, nil
+	case "<":
+		return clause.Lt{Column: column, Value: value}, nil
+	case "<=":
+		return clause.Lte{Column: column, Value: value}, nil
+	case ">":
+		return clause.Gt{Column: column, Value: value}, nil
+	case ">=":
+		return clause.Gte{Column: column, Value: value}, nil
+	}
+	return nil, p.errorf(op, "unknown comparator %q", op.text)
+}
+
+// list parses the values of a parenthesized list, separated by commas or OR.
+func (p *filterParser) list() ([]any, error) {
+	var values []any
+	for {
+		value, err := p.value(p.consume())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		tok := p.consume()
+		switch {
+		case tok.kind == tokenRightParen:
+			return values, nil
+		case tok.kind == tokenComma, tok.kind == tokenWord && tok.text == "OR":
+		default:
+			return nil, p.errorf(tok, "expected , or ) in list")
+		}
+	}
+}
+
+// value converts tok into the value compared: quoted strings are strings, bare words
+// are numbers, booleans, null or strings.
+func (p *filterParser) value(tok filterToken) (any, error) {
+	switch tok.kind {
+	case tokenString:
+		return tok.text, nil
+	case tokenWord:
+		switch tok.text {
+		case "AND", "OR", "NOT":
+			return nil, p.errorf(tok, "missing value before %s", tok.text)
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return i, nil
+		}
+		// Only accept decimal numbers, not the words ParseFloat parses such as inf.
+		if strings.ContainsAny(tok.text[:1], "+-.0123456789") {
+			if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+				return f, nil
+			}
+		}
+		return tok.text, nil
+	case tokenEOF:
+		return nil, p.errorf(tok, "missing value")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// validField reports whether name is a column name, possibly qualified with dots.
+func validField(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			return false
+		}
+		for i, r := range part {
+			if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// wildcardPattern converts a value with * wildcards into a LIKE pattern.
+func wildcardPattern(value string) string {
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = EscapeLike(part)
+	}
+	return strings.Join(parts, "%")
+}
@@ -0,0 +1,175 @@
+package where
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JSONEqExpr is the condition matching the records whose JSON column holds Value at
+// Path, built by JSONEq.
+type JSONEqExpr struct {
+	// Column is the JSON column matched.
+	Column string
+	// Path is the JSON path of the value compared, such as $.plan or $.items[0].id.
+	Path string
+	// Value is the value compared, encoded to JSON.
+	Value any
+}
+
+// Build implements clause.Expression. The condition is written with JSON_EXTRACT on
+// MySQL, json_extract on SQLite and the jsonb operators on PostgreSQL.
+func (e JSONEqExpr) Build(builder clause.Builder) {
+	keys, err := JSONPathKeys(e.Path)
+	if err != nil {
+		builder.AddError(err)
+		return
+	}
+	value, err := json.Marshal(e.Value)
+	if err != nil {
+		builder.AddError(err)
+		return
+	}
+
+	column := clause.Column{Name: e.Column}
+	switch dialect(builder) {
+	case "postgres":
+		builder.WriteString("CAST(")
+		builder.WriteQuoted(column)
+		builder.WriteString(" AS JSONB) #> ")
+		builder.AddVar(builder, postgresPath(keys))
+		builder.WriteString(" = CAST(")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(" AS JSONB)")
+	case "sqlite":
+		builder.WriteString("json_extract(")
+		builder.WriteQuoted(column)
+		builder.WriteString(", ")
+		builder.AddVar(builder, e.Path)
+		builder.WriteString(") = json_extract(")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(", '$')")
+	default:
+		builder.WriteString("JSON_EXTRACT(")
+		builder.WriteQuoted(column)
+		builder.WriteString(", ")
+		builder.AddVar(builder, e.Path)
+		builder.WriteString(") = CAST(")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(" AS JSON)")
+	}
+}
+
+// JSONContainsExpr is the condition matching the records whose JSON column contains
+// Value, built by JSONContains.
+type JSONContainsExpr struct {
+	// Column is the JSON column matched.
+	Column string
+	// Value is the value contained, encoded to JSON.
+	Value any
+}
+
+// Build implements clause.Expression. The condition is written with JSON_CONTAINS on
+// MySQL and the @> operator on PostgreSQL. SQLite has no equivalent, so only the top
+// level is compared there: arrays contain the elements of Value, and objects the keys
+// of Value with equal values.
+func (e JSONContainsExpr) Build(builder clause.Builder) {
+	value, err := json.Marshal(e.Value)
+	if err != nil {
+		builder.AddError(err)
+		return
+	}
+
+	column := clause.Column{Name: e.Column}
+	switch dialect(builder) {
+	case "postgres":
+		builder.WriteString("CAST(")
+		builder.WriteQuoted(column)
+		builder.WriteString(" AS JSONB) @> CAST(")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(" AS JSONB)")
+	case "sqlite":
+		builder.WriteString("NOT EXISTS (SELECT 1 FROM json_each(")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(") AS c WHERE NOT EXISTS (SELECT 1 FROM json_each(")
+		builder.WriteQuoted(column)
+		builder.WriteString(") AS t WHERE t.value IS c.value AND (t.key IS c.key OR json_type(")
+		builder.WriteQuoted(column)
+		builder.WriteString(") = 'array')))")
+	default:
+		builder.WriteString("JSON_CONTAINS(")
+		builder.WriteQuoted(column)
+		builder.WriteString(", ")
+		builder.AddVar(builder, string(value))
+		builder.WriteString(")")
+	}
+}
+
+// JSONPathKeys returns the keys of the JSON path, such as [items 0 id] for
+// $.items[0].id. Array indexes are returned as decimal strings. Keys may be quoted
+// when they are not identifiers, as in $."first name".
+func JSONPathKeys(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+
+	var keys []string
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `."`):
+			key, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: %w", path, err)
+			}
+			rest = rest[1+len(key):]
+			key, _ = strconv.Unquote(key)
+			keys = append(keys, key)
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+			}
+			keys = append(keys, rest[1:1+end])
+			rest = rest[1+end:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unterminated index", path)
+			}
+			if _, err := strconv.ParseUint(rest[1:end], 10, 0); err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: invalid index %q", path, rest[1:end])
+			}
+			keys = append(keys, rest[1:end])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+	}
+	return keys, nil
+}
+
+// postgresPath returns the text array literal of keys used by the #> operator.
+func postgresPath(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// dialect returns the name of the dialector the condition is built for, empty when
+// unknown.
+func dialect(builder clause.Builder) string {
+	if stmt, ok := builder.(*gorm.Statement); ok && stmt.Dialector != nil {
+		return stmt.Dialector.Name()
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package where
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// likeEscape is the escape character of the LIKE patterns. It is not a backslash,
+// which MySQL string literals would interpret.
+const likeEscape = '!'
+
+// likeReplacer escapes the wildcards of LIKE patterns.
+var likeReplacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// LikeExpr is the condition matching the records whose column matches a LIKE pattern,
+// built by Like, NotLike and ILike. ! is the escape character of the pattern.
+type LikeExpr struct {
+	// Column is the column matched.
+	Column string
+	// Pattern is the LIKE pattern the column matches.
+	Pattern string
+	// CaseInsensitive specifies whether the case of the column and pattern is ignored.
+	CaseInsensitive bool
+}
+
+// Build implements clause.Expression.
+func (e LikeExpr) Build(builder clause.Builder) {
+	e.build(builder, " LIKE ")
+}
+
+// NegationBuild implements clause.NegationExpressionBuilder, used by clause.Not.
+func (e LikeExpr) NegationBuild(builder clause.Builder) {
+	e.build(builder, " NOT LIKE ")
+}
+
+// build writes the condition with the LIKE or NOT LIKE operator.
+func (e LikeExpr) build(builder clause.Builder, operator string) {
+	column := clause.Column{Name: e.Column}
+	lower := false
+	if e.CaseInsensitive {
+		if dialect(builder) == "postgres" {
+			operator = strings.Replace(operator, "LIKE", "ILIKE", 1)
+		} else {
+			lower = true
+		}
+	}
+
+	if lower {
+		builder.WriteString("LOWER(")
+		builder.WriteQuoted(column)
+		builder.WriteString(")" + operator + "LOWER(")
+		builder.AddVar(builder, e.Pattern)
+		builder.WriteString(")")
+	} else {
+		builder.WriteQuoted(column)
+		builder.WriteString(operator)
+		builder.AddVar(builder, e.Pattern)
+	}
+	builder.WriteString(" ESCAPE '" + string(likeEscape) + "'")
+}
+
+// Regexp returns the anchored regular expression matching the same values as the
+// pattern, for the stores evaluating the conditions themselves.
+func (e LikeExpr) Regexp() string {
+	var b strings.Builder
+	b.WriteString("(?s")
+	if e.CaseInsensitive {
+		b.WriteString("i")
+	}
+	b.WriteString(")^")
+	escaped := false
+	for _, r := range e.Pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == likeEscape:
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// EscapeLike escapes the wildcards of s, so that it is matched literally by a LIKE
+// pattern of Like, NotLike or ILike.
+func EscapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
+
+// Contains returns the LIKE pattern matching the values containing s literally.
+func Contains(s string) string {
+	return "%" + EscapeLike(s) + "%"
+}
+
+// Prefix returns the LIKE pattern matching the values starting with s literally.
+func Prefix(s string) string {
+	return EscapeLike(s) + "%"
+}
+
+// Suffix returns the LIKE pattern matching the values ending with s literally.
+func Suffix(s string) string {
+	return "%" + EscapeLike(s)
+}
+
+// condition returns the condition matching the records containing the keyword in any
+// column, or false when the search is empty.
+func (s SearchTerm) condition() (clause.Expression, bool) {
+	if s.Keyword == "" || len(s.Columns) == 0 {
+		return nil, false
+	}
+	exprs := make([]clause.Expression, 0, len(s.Columns))
+	for _, column := range s.Columns {
+		exprs = append(exprs, LikeExpr{Column: column, Pattern: Contains(s.Keyword)})
+	}
+	return clause.Or(exprs...), true
+}
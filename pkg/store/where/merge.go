@@ -0,0 +1,115 @@
+package where
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a copy of the options that can be modified without affecting them,
+// such as base options shared by the handlers of a service. The values of filters and
+// the arguments of queries are shared.
+func (whr *Options) Clone() *Options {
+	if whr == nil {
+		return nil
+	}
+	clone := *whr
+	clone.Filters = maps.Clone(whr.Filters)
+	if clone.Filters == nil {
+		clone.Filters = map[any]any{}
+	}
+	clone.Clauses = slices.Clone(whr.Clauses)
+	clone.Queries = slices.Clone(whr.Queries)
+	clone.Preloads = slices.Clone(whr.Preloads)
+	clone.Joins = slices.Clone(whr.Joins)
+	clone.Scopes = slices.Clone(whr.Scopes)
+	clone.Havings = slices.Clone(whr.Havings)
+	clone.Columns = slices.Clone(whr.Columns)
+	clone.Searches = slices.Clone(whr.Searches)
+	clone.Sorts = slices.Clone(whr.Sorts)
+	clone.AllowedFields = slices.Clone(whr.AllowedFields)
+	clone.AllowedSorts = slices.Clone(whr.AllowedSorts)
+	return &clone
+}
+
+// Merge returns new options combining opts, usually base options built by a middleware,
+// such as the tenant and scopes, followed by the options of a handler:
+//
+//	opts := where.Merge(base, where.F("status", "active").P(page, size))
+//
+// Conditions, joins, preloads, scopes and searches of all options apply. When options
+// conflict, the later ones take precedence:
+//
+//   - filters on the same column keep the value of the later options;
+//   - pagination, order, selected columns, lock and table are the ones of the last
+//     options setting them;
+//   - flags such as Unscoped are set when any of the options sets them;
+//   - allow-lists only keep the columns allowed by all the options setting them, and
+//     the maximum page size is the smallest one, so that later options cannot widen
+//     them.
+//
+// Nil options are ignored and opts are not modified.
+func Merge(opts ...*Options) *Options {
+	merged := NewWhere()
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o = o.Clone()
+
+		maps.Copy(merged.Filters, o.Filters)
+		merged.Clauses = append(merged.Clauses, o.Clauses...)
+		merged.Queries = append(merged.Queries, o.Queries...)
+		merged.Preloads = append(merged.Preloads, o.Preloads...)
+		merged.Joins = append(merged.Joins, o.Joins...)
+		merged.Scopes = append(merged.Scopes, o.Scopes...)
+		merged.Havings = append(merged.Havings, o.Havings...)
+		merged.Searches = append(merged.Searches, o.Searches...)
+
+		if o.Offset != 0 || o.Limit != defaultLimit {
+			merged.Offset, merged.Limit = o.Offset, o.Limit
+		}
+		if o.Order != "" || len(o.Sorts) > 0 {
+			merged.Order, merged.Sorts = o.Order, o.Sorts
+		}
+		if len(o.Columns) > 0 {
+			merged.Columns = o.Columns
+		}
+		if o.Locking != "" {
+			merged.Locking = o.Locking
+		}
+		if o.TableName != "" {
+			merged.TableName = o.TableName
+		}
+
+		merged.Unscoped = merged.Unscoped || o.Unscoped
+		merged.Distinct = merged.Distinct || o.Distinct
+		merged.SkipCount = merged.SkipCount || o.SkipCount
+		merged.EstimateCount = merged.EstimateCount || o.EstimateCount
+		merged.SkipDefaultWhere = merged.SkipDefaultWhere || o.SkipDefaultWhere
+
+		merged.AllowedFields = intersect(merged.AllowedFields, o.AllowedFields)
+		merged.AllowedSorts = intersect(merged.AllowedSorts, o.AllowedSorts)
+		if o.MaxLimit > 0 && (merged.MaxLimit == 0 || o.MaxLimit < merged.MaxLimit) {
+			merged.MaxLimit = o.MaxLimit
+		}
+	}
+	return merged
+}
+
+// intersect returns the allow-list combining the allow-lists a and b, empty when both
+// allow any column.
+func intersect(a, b []string) []string {
+	switch {
+	case len(a) == 0:
+		return b
+	case len(b) == 0:
+		return a
+	}
+	ret := slices.DeleteFunc(slices.Clone(a), func(column string) bool { return !slices.Contains(b, column) })
+	if len(ret) == 0 {
+		// Nothing is allowed by both: keep an allow-list rejecting every column rather
+		// than an empty one allowing them all.
+		return []string{""}
+	}
+	return ret
+}
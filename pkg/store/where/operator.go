@@ -0,0 +1,71 @@
+package where
+
+import (
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm/clause"
+)
+
+// OperatorFunc builds the condition of a custom operator applied to column with args,
+// for the dialector named dialect, such as mysql, postgres or sqlite. It usually
+// returns a clause.Expr binding the column and arguments to placeholders:
+//
+//	func(dialect, column string, args []any) (clause.Expression, error) {
+//		if dialect != "postgres" {
+//			return nil, fmt.Errorf("geo_within is not supported by %s", dialect)
+//		}
+//		return clause.Expr{SQL: "ST_Within(?, ST_GeomFromText(?))", Vars: []any{clause.Column{Name: column}, args[0]}}, nil
+//	}
+type OperatorFunc func(dialect string, column string, args []any) (clause.Expression, error)
+
+// registeredOperators holds the operators registered with RegisterOperator.
+var registeredOperators sync.Map
+
+// RegisterOperator registers a custom operator, such as full-text, geographic or array
+// conditions, to be applied by name with Op. Registering an operator under an existing
+// name replaces it. Operators are usually registered during initialization by the
+// packages defining them.
+func RegisterOperator(name string, fn OperatorFunc) {
+	registeredOperators.Store(name, fn)
+}
+
+// OperatorExpr is the condition applying the registered operator Name to Column,
+// built by Op.
+type OperatorExpr struct {
+	// Name is the name of the operator.
+	Name string
+	// Column is the column the operator applies to.
+	Column string
+	// Args are the arguments of the operator.
+	Args []any
+}
+
+// Build implements clause.Expression, writing the condition built by the operator for
+// the dialector of the statement.
+func (e OperatorExpr) Build(builder clause.Builder) {
+	fn, ok := registeredOperators.Load(e.Name)
+	if !ok {
+		builder.AddError(fmt.Errorf("unknown operator %q", e.Name))
+		return
+	}
+	expr, err := fn.(OperatorFunc)(dialect(builder), e.Column, e.Args)
+	if err != nil {
+		builder.AddError(fmt.Errorf("operator %s: %w", e.Name, err))
+		return
+	}
+	expr.Build(builder)
+}
+
+// Op adds a condition applying the operator registered under name with RegisterOperator
+// to column with args, such as Op("geo_within", "location", polygon). Unknown
+// operators fail the query.
+func (whr *Options) Op(name string, column string, args ...any) *Options {
+	whr.Clauses = append(whr.Clauses, OperatorExpr{Name: name, Column: column, Args: args})
+	return whr
+}
+
+// Op is a convenience function to create a new Options applying a registered operator.
+func Op(name string, column string, args ...any) *Options {
+	return NewWhere().Op(name, column, args...)
+}
@@ -0,0 +1,180 @@
+package where
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Direction is the direction of a sort.
+type Direction string
+
+const (
+	// Asc sorts in ascending order.
+	Asc Direction = "asc"
+	// Desc sorts in descending order.
+	Desc Direction = "desc"
+)
+
+// SortColumn represents a column the query results are sorted by.
+type SortColumn struct {
+	// Column is the column sorted by, or the name of the field of the model holding it.
+	Column string
+
+	// Direction is the direction of the sort.
+	Direction Direction
+
+	// Values, when set, sorts by the position of the value of the column in Values
+	// rather than by the value itself, values not listed coming last. See OrderByCase.
+	Values []string `json:",omitempty"`
+}
+
+// WithSort creates an Option that sorts the query results by column.
+func WithSort(column string, direction Direction) Option {
+	return func(whr *Options) {
+		whr.Sort(column, direction)
+	}
+}
+
+// Sort sorts the query results by column, replacing any previous order, such as
+// Sort("name", Asc).ThenBy("id", Desc). Unlike Or, the column is quoted and checked
+// against the columns of the model when the query runs.
+func (whr *Options) Sort(column string, direction Direction) *Options {
+	whr.Order = ""
+	whr.Sorts = []SortColumn{{Column: column, Direction: direction}}
+	return whr
+}
+
+// ThenBy sorts the query results by column among the results equal for the previous
+// sorts.
+func (whr *Options) ThenBy(column string, direction Direction) *Options {
+	whr.Sorts = append(whr.Sorts, SortColumn{Column: column, Direction: direction})
+	return whr
+}
+
+// OrderByCase sorts the query results by the position of the value of column in values,
+// among the results equal for the previous sorts, such as a custom priority of
+// statuses:
+//
+//	where.OrderByCase("status", []string{"urgent", "open", "closed"}).ThenBy("id", where.Desc)
+//
+// Results whose value is not listed come last. The sort is built as an ORDER BY CASE
+// expression binding values to placeholders, so they may come from clients.
+func (whr *Options) OrderByCase(column string, values []string) *Options {
+	whr.Sorts = append(whr.Sorts, SortColumn{Column: column, Direction: Asc, Values: values})
+	return whr
+}
+
+// Sort is a convenience function to create a new Options sorting the query results by column.
+func Sort(column string, direction Direction) *Options {
+	return NewWhere().Sort(column, direction)
+}
+
+// OrderByCase is a convenience function to create a new Options sorting the query results by the position of the value of column in values.
+func OrderByCase(column string, values []string) *Options {
+	return NewWhere().OrderByCase(column, values)
+}
+
+// Ordering returns the order of the query results as an order string, Order followed by
+// Sorts, such as "name asc, id desc", for the stores not building SQL queries. Sorts by
+// values, which have no such form, are written as "CASE column direction", which these
+// stores reject.
+func (whr *Options) Ordering() string {
+	if whr == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(whr.Sorts)+1)
+	if whr.Order != "" {
+		parts = append(parts, whr.Order)
+	}
+	for _, sort := range whr.Sorts {
+		if sort.Values != nil {
+			parts = append(parts, "CASE "+sort.Column+" "+string(sort.Direction))
+			continue
+		}
+		parts = append(parts, sort.Column+" "+string(sort.Direction))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortScope returns the scope ordering a query by sorts, after checking them against
+// the model queried, if known.
+func sortScope(sorts []SortColumn) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		stmt := db.Statement
+		if stmt.Schema == nil {
+			model := stmt.Model
+			if model == nil {
+				model = stmt.Dest
+			}
+			// Queries of maps or tables without model are not checked.
+			if model != nil {
+				_ = stmt.Parse(model)
+			}
+		}
+
+		columns := make([]clause.OrderByColumn, 0, len(sorts))
+		for _, sort := range sorts {
+			if sort.Direction != Asc && sort.Direction != Desc {
+				_ = db.AddError(fmt.Errorf("invalid direction %q of sort by %s", sort.Direction, sort.Column))
+				return db
+			}
+			column := clause.Column{Name: sort.Column}
+			if stmt.Schema != nil {
+				field := stmt.Schema.LookUpField(sort.Column)
+				if field == nil || field.DBName == "" {
+					_ = db.AddError(fmt.Errorf("unknown sort column %s of model %s", sort.Column, stmt.Schema.Name))
+					return db
+				}
+				column = clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+			}
+			columns = append(columns, clause.OrderByColumn{Column: column, Desc: sort.Direction == Desc})
+		}
+
+		// An ORDER BY expression, such as the one of a previous sort by values, is
+		// replaced by the columns ordered by afterwards.
+		var prev clause.OrderBy
+		if c, ok := stmt.Clauses["ORDER BY"]; ok {
+			prev, _ = c.Expression.(clause.OrderBy)
+		}
+		if prev.Expression == nil && !slices.ContainsFunc(sorts, func(sort SortColumn) bool { return sort.Values != nil }) {
+			for _, column := range columns {
+				db = db.Order(column)
+			}
+			return db
+		}
+
+		// An ORDER BY expression replaces the columns ordered by, which are thus written
+		// in the expression, after the ones ordered by before. The columns ordered by
+		// before a previous expression are already written in it.
+		var expr clause.Expr
+		if prev.Expression != nil {
+			expr.SQL, expr.Vars, prev.Columns = "?", []any{prev.Expression}, nil
+		}
+		for i, column := range append(prev.Columns, columns...) {
+			if expr.SQL != "" {
+				expr.SQL += ","
+			}
+			if i < len(prev.Columns) || sorts[i-len(prev.Columns)].Values == nil {
+				expr.SQL += "?"
+				expr.Vars = append(expr.Vars, column.Column)
+			} else {
+				values := sorts[i-len(prev.Columns)].Values
+				expr.SQL += "CASE ?"
+				expr.Vars = append(expr.Vars, column.Column)
+				for j, value := range values {
+					expr.SQL += fmt.Sprintf(" WHEN ? THEN %d", j)
+					expr.Vars = append(expr.Vars, value)
+				}
+				expr.SQL += fmt.Sprintf(" ELSE %d END", len(values))
+			}
+			if column.Desc {
+				expr.SQL += " DESC"
+			}
+		}
+		return db.Order(clause.OrderBy{Expression: expr})
+	}
+}
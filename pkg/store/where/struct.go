@@ -0,0 +1,161 @@
+package where
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// structOperators are the operators of the where tags of FromStruct.
+var structOperators = []string{"eq", "neq", "gt", "gte", "lt", "lte", "in", "notIn", "like", "ilike", "contains", "prefix", "suffix", "null"}
+
+// FromMap returns new options filtering on the columns of filters, matching their value
+// or, for slices, any of their values:
+//
+//	opts := where.FromMap(map[string]any{"status": "active", "role": []string{"admin", "owner"}})
+func FromMap(filters map[string]any) *Options {
+	whr := NewWhere()
+	for column, value := range filters {
+		whr.Filters[column] = value
+	}
+	return whr
+}
+
+// FromStruct returns new options built from the fields of filter, a struct or a pointer
+// to a struct, so that handlers can declare the filters of their requests:
+//
+//	type UserFilter struct {
+//		Status   *string  `where:"status"`
+//		MinAge   int      `where:"age,gte"`
+//		Name     string   `where:"name,contains"`
+//		Roles    []string `where:"role,in"`
+//		Internal string   `where:"-"`
+//	}
+//
+// The where tag holds the column and the operator, the column defaulting to the snake
+// case name of the field and the operator to eq. The operators are eq, neq, gt, gte,
+// lt, lte, in, notIn, like, ilike, contains, prefix, suffix and null, which matches
+// NULL columns when the field is true and others when false. Fields holding their zero
+// value, nil pointers and empty slices are skipped, so that they are optional: use a
+// pointer to filter on a zero value, such as a *bool for null. Embedded structs are
+// flattened.
+// Errors wrap ErrInvalidOptions.
+func FromStruct(filter any) (*Options, error) {
+	rv := reflect.ValueOf(filter)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return NewWhere(), nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: filter of type %T is not a struct", ErrInvalidOptions, filter)
+	}
+
+	whr := NewWhere()
+	if err := whr.fromStruct(rv); err != nil {
+		return nil, err
+	}
+	return whr, nil
+}
+
+// fromStruct adds the conditions of the fields of the struct rv.
+func (whr *Options) fromStruct(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field, value := rt.Field(i), rv.Field(i)
+		tag := field.Tag.Get("where")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			for value.Kind() == reflect.Pointer && !value.IsNil() {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				if err := whr.fromStruct(value); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		column, op, _ := strings.Cut(tag, ",")
+		if column == "" {
+			column = schema.NamingStrategy{}.ColumnName("", field.Name)
+		}
+		if op == "" {
+			op = "eq"
+		}
+		if !slices.Contains(structOperators, op) {
+			return fmt.Errorf("%w: field %s: unknown operator %q", ErrInvalidOptions, field.Name, op)
+		}
+
+		if value.IsZero() || ((value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0) {
+			continue
+		}
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if err := whr.fromField(column, op, value.Interface()); err != nil {
+			return fmt.Errorf("%w: field %s: %w", ErrInvalidOptions, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// fromField adds the condition applying op to column and the value of a field.
+func (whr *Options) fromField(column string, op string, value any) error {
+	switch op {
+	case "eq":
+		whr.F(column, value)
+	case "neq":
+		whr.Neq(column, value)
+	case "gt":
+		whr.Gt(column, value)
+	case "gte":
+		whr.Gte(column, value)
+	case "lt":
+		whr.Lt(column, value)
+	case "lte":
+		whr.Lte(column, value)
+	case "in":
+		whr.In(column, value)
+	case "notIn":
+		whr.NotIn(column, value)
+	case "null":
+		null, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("operator null requires a bool, got %T", value)
+		}
+		if null {
+			whr.IsNull(column)
+		} else {
+			whr.NotNull(column)
+		}
+	case "like", "ilike", "contains", "prefix", "suffix":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("operator %s requires a string, got %T", op, value)
+		}
+		switch op {
+		case "like":
+			whr.Like(column, s)
+		case "ilike":
+			whr.ILike(column, s)
+		case "contains":
+			whr.Like(column, Contains(s))
+		case "prefix":
+			whr.Like(column, Prefix(s))
+		case "suffix":
+			whr.Like(column, Suffix(s))
+		}
+	}
+	return nil
+}
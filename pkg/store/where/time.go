@@ -0,0 +1,48 @@
+package where
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// TimeRange adds a condition matching the records whose column is in the time range
+// [from, to): from is included and to excluded, so that consecutive ranges, such as
+// days or months, do not overlap. A zero from or to leaves the range open on that side,
+// and no condition is added when both are zero.
+func (whr *Options) TimeRange(column string, from time.Time, to time.Time) *Options {
+	if !from.IsZero() {
+		whr.Clauses = append(whr.Clauses, clause.Gte{Column: clause.Column{Name: column}, Value: from})
+	}
+	if !to.IsZero() {
+		whr.Clauses = append(whr.Clauses, clause.Lt{Column: clause.Column{Name: column}, Value: to})
+	}
+	return whr
+}
+
+// CreatedBetween adds a condition matching the records created in the time range
+// [from, to), according to their created_at column. See TimeRange.
+func (whr *Options) CreatedBetween(from time.Time, to time.Time) *Options {
+	return whr.TimeRange("created_at", from, to)
+}
+
+// UpdatedSince adds a condition matching the records updated at or after t, according
+// to their updated_at column, such as the records to synchronize since the last run.
+func (whr *Options) UpdatedSince(t time.Time) *Options {
+	return whr.TimeRange("updated_at", t, time.Time{})
+}
+
+// TimeRange is a convenience function to create a new Options matching the records whose column is in the time range [from, to).
+func TimeRange(column string, from time.Time, to time.Time) *Options {
+	return NewWhere().TimeRange(column, from, to)
+}
+
+// CreatedBetween is a convenience function to create a new Options matching the records created in the time range [from, to).
+func CreatedBetween(from time.Time, to time.Time) *Options {
+	return NewWhere().CreatedBetween(from, to)
+}
+
+// UpdatedSince is a convenience function to create a new Options matching the records updated at or after t.
+func UpdatedSince(t time.Time) *Options {
+	return NewWhere().UpdatedSince(t)
+}
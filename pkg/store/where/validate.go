@@ -0,0 +1,217 @@
+package where
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidOptions is returned when options cannot make a valid query, such as a
+// negative offset or an empty IN list.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// FieldError reports an invalid part of options, such as a filter on a column not
+// allowed or a page size out of bounds.
+type FieldError struct {
+	// Field is the column at fault, or offset, limit or order for pagination and
+	// ordering.
+	Field string
+	// Reason describes what is invalid, such as "cannot filter on \"email\"".
+	Reason string
+	// Err is the kind of error, ErrFieldNotAllowed or ErrInvalidOptions.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Reason)
+}
+
+// Unwrap returns the kind of error, so that errors.Is matches it.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned by Validate with every invalid part of options, so that
+// handlers can report them all to clients, as the field violations of a bad request:
+//
+//	var verr *where.ValidationError
+//	if errors.As(err, &verr) {
+//		for _, ferr := range verr.Errors {
+//			violations = append(violations, ferr.Field+": "+ferr.Reason)
+//		}
+//	}
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors, so that errors.Is matches their kinds.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Validate checks the options and returns a *ValidationError listing their invalid
+// parts, nil when they are valid:
+//
+//   - the columns filtered on, selected and sorted by must be allowed by the
+//     allow-lists set with AllowFields and AllowSorts, or the field error wraps
+//     ErrFieldNotAllowed;
+//   - the offset must not be negative, that is the page at least 1, and the limit
+//     must be between 1 and the maximum set with AllowLimit, if any;
+//   - IN lists must not be empty, and the operators applied with Op must be
+//     registered.
+//
+// Handlers run it on the options built from requests, to report invalid requests.
+// The stores only enforce the allow-lists, with CheckAllowed, as the options built
+// by programs may legitimately query all records or match no values.
+func (whr *Options) Validate() error {
+	return whr.validate(true)
+}
+
+// CheckAllowed checks the options against their allow-lists, set with AllowFields and
+// AllowSorts, and returns a *ValidationError whose field errors wrap
+// ErrFieldNotAllowed, naming the columns not allowed. Where runs it, so that the
+// stores reject such options whoever built them.
+func (whr *Options) CheckAllowed() error {
+	return whr.validate(false)
+}
+
+// validate checks the options against their allow-lists, and their pagination and
+// conditions if full is set.
+func (whr *Options) validate(full bool) error {
+	if whr == nil {
+		return nil
+	}
+
+	var errs []*FieldError
+	invalid := func(field string, kind error, format string, args ...any) {
+		errs = append(errs, &FieldError{Field: field, Reason: fmt.Sprintf(format, args...), Err: kind})
+	}
+
+	if full {
+		if whr.Offset < 0 {
+			invalid("offset", ErrInvalidOptions, "offset %d is negative", whr.Offset)
+		}
+		if whr.MaxLimit > 0 && (whr.Limit < 1 || whr.Limit > whr.MaxLimit) {
+			invalid("limit", ErrInvalidOptions, "page size must be between 1 and %d", whr.MaxLimit)
+		}
+
+		for key, value := range whr.Filters {
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 && rv.Len() == 0 {
+				invalid(fmt.Sprint(key), ErrInvalidOptions, "empty list of values of %q", key)
+			}
+		}
+		for _, expr := range whr.Clauses {
+			visitConditions(expr, func(expr clause.Expression) {
+				switch e := expr.(type) {
+				case clause.IN:
+					if len(e.Values) == 0 {
+						name := columnName(e.Column)
+						invalid(name, ErrInvalidOptions, "empty list of values of %q", name)
+					}
+				case OperatorExpr:
+					if _, ok := registeredOperators.Load(e.Name); !ok {
+						invalid(e.Column, ErrInvalidOptions, "unknown operator %q", e.Name)
+					}
+				}
+			})
+		}
+	}
+
+	if len(whr.AllowedFields) > 0 {
+		check := func(column string) error {
+			if !slices.Contains(whr.AllowedFields, column) {
+				invalid(column, ErrFieldNotAllowed, "cannot filter on %q", column)
+			}
+			return nil
+		}
+		for key := range whr.Filters {
+			_ = check(fmt.Sprint(key))
+		}
+		for _, expr := range whr.Clauses {
+			_ = visitColumns(expr, check)
+		}
+		for _, column := range whr.Columns {
+			// GORM writes the selected columns unknown to the model as raw SQL.
+			if !slices.Contains(whr.AllowedFields, column) {
+				invalid(column, ErrFieldNotAllowed, "cannot select %q", column)
+			}
+		}
+		for _, search := range whr.Searches {
+			for _, column := range search.Columns {
+				_ = check(column)
+			}
+		}
+	}
+
+	if len(whr.AllowedSorts) > 0 && whr.Order != "" {
+		for _, part := range strings.Split(whr.Order, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && !strings.EqualFold(fields[1], "asc") && !strings.EqualFold(fields[1], "desc")) {
+				invalid("order", ErrFieldNotAllowed, "invalid order %q", strings.TrimSpace(part))
+				continue
+			}
+			if !slices.Contains(whr.AllowedSorts, fields[0]) {
+				invalid(fields[0], ErrFieldNotAllowed, "cannot order by %q", fields[0])
+			}
+		}
+	}
+	if len(whr.AllowedSorts) > 0 {
+		for _, sort := range whr.Sorts {
+			if !slices.Contains(whr.AllowedSorts, sort.Column) {
+				invalid(sort.Column, ErrFieldNotAllowed, "cannot order by %q", sort.Column)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// visitConditions calls fn with expr and the conditions it combines, recursively.
+func visitConditions(expr clause.Expression, fn func(clause.Expression)) {
+	fn(expr)
+	var exprs []clause.Expression
+	switch e := expr.(type) {
+	case NotExpr:
+		exprs = []clause.Expression{e.Expr}
+	case clause.AndConditions:
+		exprs = e.Exprs
+	case clause.OrConditions:
+		exprs = e.Exprs
+	case clause.NotConditions:
+		exprs = e.Exprs
+	case clause.Where:
+		exprs = e.Exprs
+	}
+	for _, expr := range exprs {
+		visitConditions(expr, fn)
+	}
+}
+
+// columnName returns the name of a column of a condition, a string or a clause.Column.
+func columnName(column any) string {
+	if c, ok := column.(clause.Column); ok {
+		return c.Name
+	}
+	return fmt.Sprint(column)
+}
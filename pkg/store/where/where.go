@@ -2,6 +2,12 @@ package where
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -12,6 +18,16 @@ const (
 	defaultLimit = -1
 )
 
+// LockStrength defines the strength of the row lock taken by a query.
+type LockStrength string
+
+const (
+	// ForUpdate locks the selected rows against concurrent updates (SELECT ... FOR UPDATE).
+	ForUpdate LockStrength = clause.LockingStrengthUpdate
+	// ForShare locks the selected rows against concurrent writes while allowing reads (SELECT ... FOR SHARE).
+	ForShare LockStrength = clause.LockingStrengthShare
+)
+
 // Tenant represents a tenant with a key and a function to retrieve its value.
 type Tenant struct {
 	Key       string                           // The key associated with the tenant
@@ -35,6 +51,26 @@ type Query struct {
 	Args []interface{}
 }
 
+// Association represents an association to be eager loaded together with the query results.
+type Association struct {
+	// Name is the association name, nested associations are separated by dots (e.g. "Orders.Items").
+	Name string
+
+	// Args holds optional conditions applied when loading the association.
+	// Accepts the same arguments as GORM's Preload, such as a query string with its
+	// parameters or a func(*gorm.DB) *gorm.DB.
+	Args []interface{}
+}
+
+// SearchTerm represents a keyword searched in several columns.
+type SearchTerm struct {
+	// Keyword is the text searched for, matched literally anywhere in the columns.
+	Keyword string
+
+	// Columns lists the columns searched, a record matches when any of them contains Keyword.
+	Columns []string
+}
+
 // Option defines a function type that modifies Options.
 type Option func(*Options)
 
@@ -58,11 +94,63 @@ type Options struct {
 	// Unscoped specifies whether to include soft-deleted records in the query results.
 	// +optional
 	Unscoped bool `json:"unscoped"`
+	// Distinct specifies whether to remove duplicate rows from the query results.
+	// It applies to explicitly selected columns, such as the column queried by Pluck.
+	// +optional
+	Distinct bool `json:"distinct"`
+	// Preloads contains the associations to be eager loaded.
+	Preloads []Association
+	// Joins contains the JOIN clauses to be added to the query.
+	Joins []Query
+	// Locking defines the row lock taken by the query. Locks are held until the end
+	// of the enclosing transaction, so it should be used within a store transaction.
+	// +optional
+	Locking LockStrength `json:"locking"`
+	// SkipCount specifies whether List should skip counting the matching records,
+	// in which case the returned count is -1.
+	// +optional
+	SkipCount bool `json:"skipCount"`
+	// EstimateCount specifies whether List and Count may return an estimate of the
+	// number of records, read from the table statistics of the database.
+	// +optional
+	EstimateCount bool `json:"estimateCount"`
+	// Scopes contains the names of the registered scopes applied to the query.
+	Scopes []string
+	// SkipDefaultWhere specifies whether the default conditions of the store, set with
+	// store.WithDefaultWhere, are ignored by the query.
+	// +optional
+	SkipDefaultWhere bool `json:"skipDefaultWhere"`
+	// Havings contains the HAVING conditions of grouped queries, such as Store.GroupBy.
+	Havings []Query
+	// Columns contains the columns fetched by the query, all columns when empty.
+	Columns []string
+	// TableName overrides the table of the model queried, such as a partition of a
+	// table split by date or tenant. It must be a trusted table name.
+	// +optional
+	TableName string `json:"tableName"`
+	// Searches contains the keywords searched in several columns.
+	Searches []SearchTerm
+	// Sorts contains the columns the query results are sorted by, after Order.
+	Sorts []SortColumn
+	// AllowedFields restricts the columns the filters and clauses may refer to and the
+	// selected columns, any column when empty.
+	AllowedFields []string
+	// AllowedSorts restricts the columns the query may be ordered by, any order when empty.
+	AllowedSorts []string
+	// MaxLimit restricts the page size of the query, unrestricted when zero.
+	// +optional
+	MaxLimit int `json:"maxLimit"`
 }
 
+// defaultTenantColumn is the tenant column filtered by TenantID when no tenant is registered.
+const defaultTenantColumn = "tenant_id"
+
 // tenant holds the registered tenant instance.
 var registeredTenant Tenant
 
+// registeredScopes holds the named scopes registered with RegisterScope.
+var registeredScopes sync.Map
+
 // WithOffset initializes the Offset field in Options with the given offset value.
 func WithOffset(offset int64) Option {
 	return func(whr *Options) {
@@ -136,6 +224,90 @@ func WithUnscoped(unscoped bool) Option {
 	}
 }
 
+// WithDistinct creates an Option that sets the Distinct flag for the query.
+func WithDistinct(distinct bool) Option {
+	return func(whr *Options) {
+		whr.Distinct = distinct
+	}
+}
+
+// WithPreload creates an Option that eager loads the named association, optionally filtered by conds.
+func WithPreload(name string, conds ...interface{}) Option {
+	return func(whr *Options) {
+		whr.Preloads = append(whr.Preloads, Association{Name: name, Args: conds})
+	}
+}
+
+// WithJoin creates an Option that adds a JOIN clause with arguments to the query.
+func WithJoin(query string, args ...interface{}) Option {
+	return func(whr *Options) {
+		whr.Joins = append(whr.Joins, Query{Query: query, Args: args})
+	}
+}
+
+// WithLock creates an Option that sets the row lock taken by the query.
+func WithLock(strength LockStrength) Option {
+	return func(whr *Options) {
+		whr.Locking = strength
+	}
+}
+
+// WithSkipCount creates an Option that sets the SkipCount flag for the query.
+func WithSkipCount(skip bool) Option {
+	return func(whr *Options) {
+		whr.SkipCount = skip
+	}
+}
+
+// WithSkipDefaultWhere creates an Option that sets the SkipDefaultWhere flag for the query.
+func WithSkipDefaultWhere(skip bool) Option {
+	return func(whr *Options) {
+		whr.SkipDefaultWhere = skip
+	}
+}
+
+// WithEstimateCount creates an Option that sets the EstimateCount flag for the query.
+func WithEstimateCount(estimate bool) Option {
+	return func(whr *Options) {
+		whr.EstimateCount = estimate
+	}
+}
+
+// WithScope creates an Option that applies the named scopes to the query.
+func WithScope(names ...string) Option {
+	return func(whr *Options) {
+		whr.Scopes = append(whr.Scopes, names...)
+	}
+}
+
+// WithHaving creates an Option that adds a HAVING condition with arguments to a grouped query.
+func WithHaving(query interface{}, args ...interface{}) Option {
+	return func(whr *Options) {
+		whr.Havings = append(whr.Havings, Query{Query: query, Args: args})
+	}
+}
+
+// WithSelect creates an Option that fetches only the given columns.
+func WithSelect(columns ...string) Option {
+	return func(whr *Options) {
+		whr.Columns = append(whr.Columns, columns...)
+	}
+}
+
+// WithTable creates an Option that queries the given table instead of the table of the model.
+func WithTable(name string) Option {
+	return func(whr *Options) {
+		whr.TableName = name
+	}
+}
+
+// WithSearch creates an Option that searches keyword in the given columns.
+func WithSearch(keyword string, columns ...string) Option {
+	return func(whr *Options) {
+		whr.Searches = append(whr.Searches, SearchTerm{Keyword: keyword, Columns: columns})
+	}
+}
+
 // NewWhere constructs a new Options object, applying the given where options.
 func NewWhere(opts ...Option) *Options {
 	whr := &Options{
@@ -145,6 +317,7 @@ func NewWhere(opts ...Option) *Options {
 		Clauses:  make([]clause.Expression, 0),
 		Order:    "",
 		Unscoped: false,
+		Distinct: false,
 	}
 
 	for _, opt := range opts {
@@ -198,9 +371,10 @@ func (whr *Options) Q(query interface{}, args ...interface{}) *Options {
 	return whr
 }
 
-// Or sets the ordering for the query.
+// Or sets the ordering for the query, replacing the sorts set by Sort.
 func (whr *Options) Or(order string) *Options {
 	whr.Order = order
+	whr.Sorts = nil
 	return whr
 }
 
@@ -210,6 +384,253 @@ func (whr *Options) U(unscoped bool) *Options {
 	return whr
 }
 
+// D sets the Distinct flag for the query, which removes duplicate rows when true.
+func (whr *Options) D(distinct bool) *Options {
+	whr.Distinct = distinct
+	return whr
+}
+
+// Preload adds associations to be eager loaded with the query results.
+func (whr *Options) Preload(names ...string) *Options {
+	for _, name := range names {
+		whr.Preloads = append(whr.Preloads, Association{Name: name})
+	}
+	return whr
+}
+
+// PreloadWhere adds an association to be eager loaded, filtered by the given conditions.
+func (whr *Options) PreloadWhere(name string, conds ...interface{}) *Options {
+	whr.Preloads = append(whr.Preloads, Association{Name: name, Args: conds})
+	return whr
+}
+
+// Join adds a JOIN clause with arguments to the query, for example
+// "LEFT JOIN profiles ON profiles.user_id = users.id". Columns of the joined
+// tables can then be used in filters and conditions by qualifying them with the table name.
+func (whr *Options) Join(query string, args ...interface{}) *Options {
+	whr.Joins = append(whr.Joins, Query{Query: query, Args: args})
+	return whr
+}
+
+// Lock sets the row lock taken by the query, such as ForUpdate or ForShare.
+func (whr *Options) Lock(strength LockStrength) *Options {
+	whr.Locking = strength
+	return whr
+}
+
+// NoCount makes List skip the COUNT query and return a count of -1, for listings
+// that do not display the total number of records, such as infinite scrolling.
+func (whr *Options) NoCount() *Options {
+	whr.SkipCount = true
+	return whr
+}
+
+// Key adds filters matching the object identified by the given primary key parts,
+// such as map[string]any{"tenant_id": 1, "code": "FR"} for a composite key.
+func (whr *Options) Key(key map[string]any) *Options {
+	for column, value := range key {
+		whr.Filters[column] = value
+	}
+	return whr
+}
+
+// NoDefaultWhere makes the query ignore the default conditions of the store, set
+// with store.WithDefaultWhere, for example to list the drafts hidden by default.
+func (whr *Options) NoDefaultWhere() *Options {
+	whr.SkipDefaultWhere = true
+	return whr
+}
+
+// ApproxCount makes List and Count return the number of rows of the table estimated
+// by the database statistics instead of running COUNT(*), which takes seconds on huge
+// tables. The estimate may be off by a large margin and includes the soft-deleted
+// records. It is only used for queries without conditions, the other queries and the
+// databases without statistics, such as SQLite, still count exactly.
+func (whr *Options) ApproxCount() *Options {
+	whr.EstimateCount = true
+	return whr
+}
+
+// Scope applies the named scopes, registered with RegisterScope, to the query.
+func (whr *Options) Scope(names ...string) *Options {
+	whr.Scopes = append(whr.Scopes, names...)
+	return whr
+}
+
+// Having adds a condition on the groups of a grouped query, for example
+// Having("COUNT(*) > ?", 10). It only applies to grouped queries, such as Store.GroupBy.
+func (whr *Options) Having(query interface{}, args ...interface{}) *Options {
+	whr.Havings = append(whr.Havings, Query{Query: query, Args: args})
+	return whr
+}
+
+// Select makes the query fetch only the given columns, leaving the other fields of
+// the returned objects zero, to avoid transferring wide columns such as blobs that
+// are not used. The primary key should be selected when the objects are updated
+// or paginated with a cursor afterwards. Columns read from user input, such as the
+// field mask of a request, must be restricted with AllowFields.
+func (whr *Options) Select(columns ...string) *Options {
+	whr.Columns = append(whr.Columns, columns...)
+	return whr
+}
+
+// Table makes the query target the given table instead of the table of the model,
+// for models stored in several physical tables with the same schema, such as
+// monthly partitions (e.g. "events_2024_05").
+func (whr *Options) Table(name string) *Options {
+	whr.TableName = name
+	return whr
+}
+
+// Search adds a condition matching the records containing keyword in any of the given
+// columns, such as Search("john", "name", "email", "phone"), for the search boxes of
+// list pages. It expands into LIKE conditions joined with OR, in which the wildcards
+// of keyword are escaped, so that keyword is matched literally. An empty keyword adds
+// no condition. Case sensitivity follows the collation of the columns, LIKE being case
+// sensitive on PostgreSQL. Columns must be trusted column names.
+func (whr *Options) Search(keyword string, columns ...string) *Options {
+	whr.Searches = append(whr.Searches, SearchTerm{Keyword: keyword, Columns: columns})
+	return whr
+}
+
+// In adds a condition matching the records whose column is any of values, which may
+// be a slice of any type, such as In("status", []string{"active", "pending"}).
+// No values match no record.
+func (whr *Options) In(column string, values any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.IN{Column: clause.Column{Name: column}, Values: valuesOf(values)})
+	return whr
+}
+
+// NotIn adds a condition matching the records whose column is none of values, which
+// may be a slice of any type. No values match the records whose column is not NULL.
+func (whr *Options) NotIn(column string, values any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Not(clause.IN{Column: clause.Column{Name: column}, Values: valuesOf(values)}))
+	return whr
+}
+
+// Like adds a condition matching the records whose column matches the LIKE pattern,
+// in which % matches any sequence of characters and _ any single character. Patterns
+// built from user input should escape its wildcards with Contains, Prefix or Suffix,
+// such as Like("name", Contains(keyword)); ! is the escape character of the patterns.
+func (whr *Options) Like(column string, pattern string) *Options {
+	whr.Clauses = append(whr.Clauses, LikeExpr{Column: column, Pattern: pattern})
+	return whr
+}
+
+// NotLike adds a condition matching the records whose column does not match the LIKE
+// pattern, like Like.
+func (whr *Options) NotLike(column string, pattern string) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Not(LikeExpr{Column: column, Pattern: pattern}))
+	return whr
+}
+
+// ILike adds a condition matching the records whose column matches the LIKE pattern
+// regardless of case, like Like. It uses ILIKE on PostgreSQL and compares the lower
+// case column and pattern on the other databases.
+func (whr *Options) ILike(column string, pattern string) *Options {
+	whr.Clauses = append(whr.Clauses, LikeExpr{Column: column, Pattern: pattern, CaseInsensitive: true})
+	return whr
+}
+
+// Between adds a condition matching the records whose column is between from and to,
+// both included, such as Between("created_at", from, to) for date filters.
+func (whr *Options) Between(column string, from any, to any) *Options {
+	whr.Clauses = append(whr.Clauses, BetweenExpr{Column: column, From: from, To: to})
+	return whr
+}
+
+// NotBetween adds a condition matching the records whose column is lower than from or
+// greater than to.
+func (whr *Options) NotBetween(column string, from any, to any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Not(BetweenExpr{Column: column, From: from, To: to}))
+	return whr
+}
+
+// IsNull adds a condition matching the records whose column is NULL, such as
+// IsNull("deleted_by").
+func (whr *Options) IsNull(column string) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Eq{Column: clause.Column{Name: column}, Value: nil})
+	return whr
+}
+
+// NotNull adds a condition matching the records whose column is not NULL, such as
+// NotNull("verified_at").
+func (whr *Options) NotNull(column string) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Neq{Column: clause.Column{Name: column}, Value: nil})
+	return whr
+}
+
+// Gt adds a condition matching the records whose column is greater than value, such as
+// Gt("amount", 100).
+func (whr *Options) Gt(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Gt{Column: clause.Column{Name: column}, Value: value})
+	return whr
+}
+
+// Gte adds a condition matching the records whose column is greater than or equal to
+// value, such as Gte("created_at", since).
+func (whr *Options) Gte(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Gte{Column: clause.Column{Name: column}, Value: value})
+	return whr
+}
+
+// Lt adds a condition matching the records whose column is lower than value.
+func (whr *Options) Lt(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Lt{Column: clause.Column{Name: column}, Value: value})
+	return whr
+}
+
+// Lte adds a condition matching the records whose column is lower than or equal to value.
+func (whr *Options) Lte(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Lte{Column: clause.Column{Name: column}, Value: value})
+	return whr
+}
+
+// Neq adds a condition matching the records whose column is not equal to value. Like in
+// SQL, records whose column is NULL do not match, unless value is nil, which matches the
+// records whose column is not NULL as NotNull does.
+func (whr *Options) Neq(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Neq{Column: clause.Column{Name: column}, Value: value})
+	return whr
+}
+
+// JSONEq adds a condition matching the records whose JSON column holds value at the
+// JSON path, such as JSONEq("meta", "$.plan", "pro").
+func (whr *Options) JSONEq(column string, path string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, JSONEqExpr{Column: column, Path: path, Value: value})
+	return whr
+}
+
+// JSONContains adds a condition matching the records whose JSON column contains value,
+// such as JSONContains("tags", []string{"go"}) for the arrays holding "go", or
+// JSONContains("meta", map[string]any{"plan": "pro"}) for the objects with that plan.
+func (whr *Options) JSONContains(column string, value any) *Options {
+	whr.Clauses = append(whr.Clauses, JSONContainsExpr{Column: column, Value: value})
+	return whr
+}
+
+// Raw adds a raw SQL condition with ? placeholders bound to args, for the predicates
+// the other builders cannot express, such as Raw("JSON_CONTAINS(tags, ?)", tag). Unlike
+// Q, the condition is always SQL, and the stores not backed by SQL reject it.
+func (whr *Options) Raw(sql string, args ...any) *Options {
+	whr.Clauses = append(whr.Clauses, clause.Expr{SQL: sql, Vars: args})
+	return whr
+}
+
+// Not adds a condition matching the records that do not match all the conditions of
+// cond, its filters, clauses, queries and searches, such as Not(F("status", "archived"))
+// for exclusion filters. Several calls exclude the records matching any of the groups:
+//
+//	where.Not(where.F("archived", true)).Not(where.F("locked", true))
+//
+// Queries of cond must be maps or SQL strings with ? placeholders.
+func (whr *Options) Not(cond *Options) *Options {
+	if expr, ok := cond.expression(); ok {
+		whr.Clauses = append(whr.Clauses, NotExpr{Expr: expr})
+	}
+	return whr
+}
+
 // T retrieves the value associated with the registered tenant using the provided context.
 func (whr *Options) T(ctx context.Context) *Options {
 	if registeredTenant.Key != "" && registeredTenant.ValueFunc != nil {
@@ -218,6 +639,18 @@ func (whr *Options) T(ctx context.Context) *Options {
 	return whr
 }
 
+// TenantID adds a filter matching the records of the tenant identified by id, for the
+// code knowing the tenant explicitly rather than from the context, such as background
+// jobs. The filtered column is the key of the registered tenant, tenant_id when no
+// tenant is registered.
+func (whr *Options) TenantID(id any) *Options {
+	column := registeredTenant.Key
+	if column == "" {
+		column = defaultTenantColumn
+	}
+	return whr.F(column, id)
+}
+
 // F adds filters to the query.
 func (whr *Options) F(kvs ...any) *Options {
 	if len(kvs)%2 != 0 {
@@ -236,9 +669,25 @@ func (whr *Options) F(kvs ...any) *Options {
 
 // Where applies the filters, clauses, order and unscoped options to the given gorm.DB instance.
 func (whr *Options) Where(db *gorm.DB) *gorm.DB {
+	if whr == nil {
+		return db
+	}
+
+	if err := whr.CheckAllowed(); err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+
+	// Build the queries into a copy of the clauses, so that applying the options again
+	// does not repeat them.
+	clauses := slices.Clone(whr.Clauses)
 	for _, query := range whr.Queries {
 		conds := db.Statement.BuildCondition(query.Query, query.Args...)
-		whr.Clauses = append(whr.Clauses, conds...)
+		clauses = append(clauses, conds...)
+	}
+
+	if whr.TableName != "" {
+		db = db.Table(whr.TableName)
 	}
 
 	// Apply unscoped option if specified
@@ -246,12 +695,56 @@ func (whr *Options) Where(db *gorm.DB) *gorm.DB {
 		db = db.Unscoped()
 	}
 
-	db = db.Where(whr.Filters).Clauses(whr.Clauses...).Offset(whr.Offset).Limit(whr.Limit)
+	if len(whr.Columns) > 0 {
+		db = db.Select(whr.Columns)
+	}
+
+	if whr.Distinct {
+		db = db.Distinct()
+	}
+
+	if whr.Locking != "" {
+		db = db.Clauses(clause.Locking{Strength: string(whr.Locking)})
+	}
+
+	for _, join := range whr.Joins {
+		if query, ok := join.Query.(string); ok {
+			db = db.Joins(query, join.Args...)
+		}
+	}
+
+	for _, preload := range whr.Preloads {
+		db = db.Preload(preload.Name, preload.Args...)
+	}
+
+	for _, name := range whr.Scopes {
+		scope, ok := registeredScopes.Load(name)
+		if !ok {
+			_ = db.AddError(fmt.Errorf("unknown scope %q", name))
+			continue
+		}
+		db = db.Scopes(scope.(func(*gorm.DB) *gorm.DB))
+	}
+
+	db = db.Where(whr.Filters).Clauses(clauses...).Offset(whr.Offset).Limit(whr.Limit)
+
+	for _, search := range whr.Searches {
+		if cond, ok := search.condition(); ok {
+			db = db.Where(cond)
+		}
+	}
+
+	for _, having := range whr.Havings {
+		db = db.Having(having.Query, having.Args...)
+	}
 
 	// Apply ordering if specified
 	if whr.Order != "" {
 		db = db.Order(whr.Order)
 	}
+	if len(whr.Sorts) > 0 {
+		db = db.Scopes(sortScope(whr.Sorts))
+	}
 
 	return db
 }
@@ -271,6 +764,20 @@ func P(page int, pageSize int) *Options {
 	return NewWhere().P(page, pageSize)
 }
 
+// Limit is a convenience function to create a new Options returning at most limit
+// records, for callers windowing the results themselves, such as sync jobs. It is
+// chained with O to set the offset, as in where.Limit(100).O(offset).
+func Limit(limit int) *Options {
+	return NewWhere().L(limit)
+}
+
+// Offset is a convenience function to create a new Options skipping the first offset
+// records, for callers windowing the results themselves. It is chained with L to set
+// the limit, as in where.Offset(offset).L(100).
+func Offset(offset int) *Options {
+	return NewWhere().O(offset)
+}
+
 // C is a convenience function to create a new Options with conditions.
 func C(conds ...clause.Expression) *Options {
 	return NewWhere().C(conds...)
@@ -278,7 +785,12 @@ func C(conds ...clause.Expression) *Options {
 
 // T is a convenience function to create a new Options with tenant.
 func T(ctx context.Context) *Options {
-	return NewWhere().F(registeredTenant.Key, registeredTenant.ValueFunc(ctx))
+	return NewWhere().T(ctx)
+}
+
+// TenantID is a convenience function to create a new Options matching the records of the given tenant.
+func TenantID(id any) *Options {
+	return NewWhere().TenantID(id)
 }
 
 // F is a convenience function to create a new Options with filters.
@@ -296,7 +808,285 @@ func U(unscoped bool) *Options {
 	return NewWhere().U(unscoped)
 }
 
+// D is a convenience function to create a new Options with Distinct flag.
+func D(distinct bool) *Options {
+	return NewWhere().D(distinct)
+}
+
+// Preload is a convenience function to create a new Options with associations to be eager loaded.
+func Preload(names ...string) *Options {
+	return NewWhere().Preload(names...)
+}
+
+// PreloadWhere is a convenience function to create a new Options with a filtered association to be eager loaded.
+func PreloadWhere(name string, conds ...interface{}) *Options {
+	return NewWhere().PreloadWhere(name, conds...)
+}
+
+// Join is a convenience function to create a new Options with a JOIN clause.
+func Join(query string, args ...interface{}) *Options {
+	return NewWhere().Join(query, args...)
+}
+
+// Lock is a convenience function to create a new Options with a row lock.
+func Lock(strength LockStrength) *Options {
+	return NewWhere().Lock(strength)
+}
+
+// NoCount is a convenience function to create a new Options skipping the COUNT query of List.
+func NoCount() *Options {
+	return NewWhere().NoCount()
+}
+
+// Key is a convenience function to create a new Options matching the object identified by the given primary key parts.
+func Key(key map[string]any) *Options {
+	return NewWhere().Key(key)
+}
+
+// NoDefaultWhere is a convenience function to create a new Options ignoring the default conditions of the store.
+func NoDefaultWhere() *Options {
+	return NewWhere().NoDefaultWhere()
+}
+
+// ApproxCount is a convenience function to create a new Options counting records approximately.
+func ApproxCount() *Options {
+	return NewWhere().ApproxCount()
+}
+
+// Scope is a convenience function to create a new Options with named scopes.
+func Scope(names ...string) *Options {
+	return NewWhere().Scope(names...)
+}
+
+// Having is a convenience function to create a new Options with a HAVING condition.
+func Having(query interface{}, args ...interface{}) *Options {
+	return NewWhere().Having(query, args...)
+}
+
+// Select is a convenience function to create a new Options fetching only the given columns.
+func Select(columns ...string) *Options {
+	return NewWhere().Select(columns...)
+}
+
+// Table is a convenience function to create a new Options targeting the given table.
+func Table(name string) *Options {
+	return NewWhere().Table(name)
+}
+
+// Search is a convenience function to create a new Options searching keyword in the given columns.
+func Search(keyword string, columns ...string) *Options {
+	return NewWhere().Search(keyword, columns...)
+}
+
+// In is a convenience function to create a new Options matching the records whose column is any of values.
+func In(column string, values any) *Options {
+	return NewWhere().In(column, values)
+}
+
+// NotIn is a convenience function to create a new Options matching the records whose column is none of values.
+func NotIn(column string, values any) *Options {
+	return NewWhere().NotIn(column, values)
+}
+
+// Like is a convenience function to create a new Options matching the records whose column matches the LIKE pattern.
+func Like(column string, pattern string) *Options {
+	return NewWhere().Like(column, pattern)
+}
+
+// NotLike is a convenience function to create a new Options matching the records whose column does not match the LIKE pattern.
+func NotLike(column string, pattern string) *Options {
+	return NewWhere().NotLike(column, pattern)
+}
+
+// ILike is a convenience function to create a new Options matching the records whose column matches the LIKE pattern regardless of case.
+func ILike(column string, pattern string) *Options {
+	return NewWhere().ILike(column, pattern)
+}
+
+// Between is a convenience function to create a new Options matching the records whose column is between from and to.
+func Between(column string, from any, to any) *Options {
+	return NewWhere().Between(column, from, to)
+}
+
+// NotBetween is a convenience function to create a new Options matching the records whose column is not between from and to.
+func NotBetween(column string, from any, to any) *Options {
+	return NewWhere().NotBetween(column, from, to)
+}
+
+// BetweenExpr is the condition matching the records whose column is between From and
+// To, both included, built by Between and NotBetween.
+type BetweenExpr struct {
+	// Column is the column compared.
+	Column string
+	// From is the lower bound of the range.
+	From any
+	// To is the upper bound of the range.
+	To any
+}
+
+// Build implements clause.Expression.
+func (e BetweenExpr) Build(builder clause.Builder) {
+	e.build(builder, " BETWEEN ")
+}
+
+// NegationBuild implements clause.NegationExpressionBuilder, used by clause.Not.
+func (e BetweenExpr) NegationBuild(builder clause.Builder) {
+	e.build(builder, " NOT BETWEEN ")
+}
+
+// build writes the condition with the BETWEEN or NOT BETWEEN operator.
+func (e BetweenExpr) build(builder clause.Builder, operator string) {
+	builder.WriteQuoted(clause.Column{Name: e.Column})
+	builder.WriteString(operator)
+	builder.AddVar(builder, e.From)
+	builder.WriteString(" AND ")
+	builder.AddVar(builder, e.To)
+}
+
+// IsNull is a convenience function to create a new Options matching the records whose column is NULL.
+func IsNull(column string) *Options {
+	return NewWhere().IsNull(column)
+}
+
+// NotNull is a convenience function to create a new Options matching the records whose column is not NULL.
+func NotNull(column string) *Options {
+	return NewWhere().NotNull(column)
+}
+
+// Gt is a convenience function to create a new Options matching the records whose column is greater than value.
+func Gt(column string, value any) *Options {
+	return NewWhere().Gt(column, value)
+}
+
+// Gte is a convenience function to create a new Options matching the records whose column is greater than or equal to value.
+func Gte(column string, value any) *Options {
+	return NewWhere().Gte(column, value)
+}
+
+// Lt is a convenience function to create a new Options matching the records whose column is lower than value.
+func Lt(column string, value any) *Options {
+	return NewWhere().Lt(column, value)
+}
+
+// Lte is a convenience function to create a new Options matching the records whose column is lower than or equal to value.
+func Lte(column string, value any) *Options {
+	return NewWhere().Lte(column, value)
+}
+
+// Neq is a convenience function to create a new Options matching the records whose column is not equal to value.
+func Neq(column string, value any) *Options {
+	return NewWhere().Neq(column, value)
+}
+
+// JSONEq is a convenience function to create a new Options matching the records whose JSON column holds value at the JSON path.
+func JSONEq(column string, path string, value any) *Options {
+	return NewWhere().JSONEq(column, path, value)
+}
+
+// JSONContains is a convenience function to create a new Options matching the records whose JSON column contains value.
+func JSONContains(column string, value any) *Options {
+	return NewWhere().JSONContains(column, value)
+}
+
+// Raw is a convenience function to create a new Options with a raw SQL condition.
+func Raw(sql string, args ...any) *Options {
+	return NewWhere().Raw(sql, args...)
+}
+
+// Not is a convenience function to create a new Options matching the records that do not match cond.
+func Not(cond *Options) *Options {
+	return NewWhere().Not(cond)
+}
+
+// NotExpr is the condition negating Expr as a whole, built by Not.
+type NotExpr struct {
+	// Expr is the condition negated.
+	Expr clause.Expression
+}
+
+// Build implements clause.Expression.
+func (e NotExpr) Build(builder clause.Builder) {
+	// Groups of several conditions are already enclosed in parentheses.
+	if and, ok := e.Expr.(clause.AndConditions); ok && len(and.Exprs) > 1 {
+		builder.WriteString("NOT ")
+		e.Expr.Build(builder)
+		return
+	}
+	builder.WriteString("NOT (")
+	e.Expr.Build(builder)
+	builder.WriteString(")")
+}
+
+// expression returns the conditions of whr joined with AND, or false when whr has none.
+func (whr *Options) expression() (clause.Expression, bool) {
+	if whr == nil {
+		return nil, false
+	}
+
+	var exprs []clause.Expression
+	keys := make([]any, 0, len(whr.Filters))
+	for key := range whr.Filters {
+		keys = append(keys, key)
+	}
+	// Sort the keys so that the same options always produce the same statement.
+	slices.SortFunc(keys, func(a, b any) int { return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)) })
+	for _, key := range keys {
+		column := clause.Column{Name: fmt.Sprint(key)}
+		value := whr.Filters[key]
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			exprs = append(exprs, clause.IN{Column: column, Values: valuesOf(value)})
+		} else {
+			exprs = append(exprs, clause.Eq{Column: column, Value: value})
+		}
+	}
+
+	for _, query := range whr.Queries {
+		switch q := query.Query.(type) {
+		case map[string]any:
+			for _, column := range slices.Sorted(maps.Keys(q)) {
+				exprs = append(exprs, clause.Eq{Column: clause.Column{Name: column}, Value: q[column]})
+			}
+		case string:
+			exprs = append(exprs, clause.Expr{SQL: q, Vars: query.Args})
+		}
+	}
+	exprs = append(exprs, whr.Clauses...)
+	for _, search := range whr.Searches {
+		if cond, ok := search.condition(); ok {
+			exprs = append(exprs, cond)
+		}
+	}
+
+	if len(exprs) == 0 {
+		return nil, false
+	}
+	return clause.And(exprs...), true
+}
+
+// valuesOf returns the elements of values when it is a slice or an array, byte slices
+// excepted, and values alone otherwise.
+func valuesOf(values any) []any {
+	rv := reflect.ValueOf(values)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{values}
+	}
+	ret := make([]any, rv.Len())
+	for i := range ret {
+		ret[i] = rv.Index(i).Interface()
+	}
+	return ret
+}
+
+// RegisterScope registers a named scope, such as a common predicate like "not banned"
+// or "published", to be applied by name with Scope. Registering a scope under an
+// existing name replaces it. Scopes are usually registered during initialization.
+func RegisterScope(name string, scope func(*gorm.DB) *gorm.DB) {
+	registeredScopes.Store(name, scope)
+}
+
 // RegisterTenant registers a new tenant with the specified key and value function.
+// The key is the tenant column filtered by T and TenantID. The value function is only
+// used by T and may be nil when the tenant is always given explicitly.
 func RegisterTenant(key string, valueFunc func(context.Context) string) {
 	registeredTenant = Tenant{
 		Key:       key,
@@ -0,0 +1,558 @@
+package where
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/utils/tests"
+)
+
+type testModel struct {
+	ID     uint
+	Name   string
+	Status string
+}
+
+// toSQL renders the SELECT statement generated for opts without executing it.
+func toSQL(t *testing.T, opts *Options) string {
+	t.Helper()
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open dummy database: %v", err)
+	}
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return opts.Where(tx).Find(&[]testModel{})
+	})
+	// Collapse the spaces GORM leaves between empty clauses.
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+func TestWhereSQL(t *testing.T) {
+	cases := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{
+			name: "filter and pagination",
+			opts: F("name", "john").P(2, 10),
+			want: "SELECT * FROM `test_models` WHERE `name` = \"john\" LIMIT 10 OFFSET 10",
+		},
+		{
+			name: "window",
+			opts: Offset(25).L(5).F("id", 1),
+			want: "SELECT * FROM `test_models` WHERE `id` = 1 LIMIT 5 OFFSET 25",
+		},
+		{
+			name: "limit",
+			opts: Limit(100).O(-1),
+			want: "SELECT * FROM `test_models` LIMIT 100",
+		},
+		{
+			name: "lock",
+			opts: F("id", 1).Lock(ForUpdate),
+			want: "SELECT * FROM `test_models` WHERE `id` = 1 FOR UPDATE",
+		},
+		{
+			name: "scope",
+			opts: Scope("active").F("name", "john"),
+			want: "SELECT * FROM `test_models` WHERE `name` = \"john\" AND status = \"active\"",
+		},
+		{
+			name: "select",
+			opts: Select("id", "name").F("status", "active"),
+			want: "SELECT `id`,`name` FROM `test_models` WHERE `status` = \"active\"",
+		},
+		{
+			name: "search",
+			opts: Search("50%_off!", "name", "status").Search(""),
+			want: "SELECT * FROM `test_models` WHERE (`name` LIKE \"%50!%!_off!!%\" ESCAPE '!' OR `status` LIKE \"%50!%!_off!!%\" ESCAPE '!')",
+		},
+		{
+			name: "in",
+			opts: In("status", []string{"active", "pending"}).NotIn("id", []int{1, 2}).P(1, 10).Or("id"),
+			want: "SELECT * FROM `test_models` WHERE `status` IN (\"active\",\"pending\") AND `id` NOT IN (1,2) ORDER BY id LIMIT 10",
+		},
+		{
+			name: "like",
+			opts: Like("name", Prefix("50%")).NotLike("status", "%ed").ILike("name", Contains("Jo")),
+			want: "SELECT * FROM `test_models` WHERE `name` LIKE \"50!%%\" ESCAPE '!' AND `status` NOT LIKE \"%ed\" ESCAPE '!' AND LOWER(`name`) LIKE LOWER(\"%Jo%\") ESCAPE '!'",
+		},
+		{
+			name: "between",
+			opts: Between("id", 10, 20).NotBetween("status", "a", "m"),
+			want: "SELECT * FROM `test_models` WHERE `id` BETWEEN 10 AND 20 AND `status` NOT BETWEEN \"a\" AND \"m\"",
+		},
+		{
+			name: "not",
+			opts: F("id", 1).Not(F("status", "archived", "name", []string{"a", "b"})).Not(NewWhere().Q("name LIKE ? OR name = ?", "x%", "y")),
+			want: "SELECT * FROM `test_models` WHERE `id` = 1 AND (NOT (`name` IN (\"a\",\"b\") AND `status` = \"archived\") AND NOT (name LIKE \"x%\" OR name = \"y\"))",
+		},
+		{
+			name: "null",
+			opts: IsNull("name").NotNull("status"),
+			want: "SELECT * FROM `test_models` WHERE `name` IS NULL AND `status` IS NOT NULL",
+		},
+		{
+			name: "comparison",
+			opts: Gt("id", 10).Lte("id", 20).Gte("name", "a").Lt("name", "m").Neq("status", "archived"),
+			want: "SELECT * FROM `test_models` WHERE `id` > 10 AND `id` <= 20 AND `name` >= \"a\" AND `name` < \"m\" AND `status` <> \"archived\"",
+		},
+		{
+			name: "raw",
+			opts: F("id", 1).Raw("JSON_CONTAINS(tags, ?)", "go").Raw("name = ? OR status IN ?", "x", []string{"a", "b"}),
+			want: "SELECT * FROM `test_models` WHERE `id` = 1 AND (JSON_CONTAINS(tags, \"go\") AND (name = \"x\" OR status IN (\"a\",\"b\")))",
+		},
+		{
+			name: "json",
+			opts: JSONEq("name", "$.items[0]", 3).JSONContains("status", []int{1, 2}),
+			want: "SELECT * FROM `test_models` WHERE JSON_EXTRACT(`name`, \"$.items[0]\") = CAST(\"3\" AS JSON) AND JSON_CONTAINS(`status`, \"[1,2]\")",
+		},
+		{
+			name: "tenant",
+			opts: TenantID(7).Gt("id", 1),
+			want: "SELECT * FROM `test_models` WHERE `tenant_id` = 7 AND `id` > 1",
+		},
+		{
+			name: "sort",
+			opts: Or("status").Sort("Name", Asc).ThenBy("id", Desc),
+			want: "SELECT * FROM `test_models` ORDER BY `test_models`.`name`,`test_models`.`id` DESC",
+		},
+		{
+			name: "order by case",
+			opts: Or("id desc").OrderByCase("status", []string{"urgent", "open"}).ThenBy("name", Desc),
+			want: "SELECT * FROM `test_models` ORDER BY id desc,CASE `test_models`.`status` WHEN \"urgent\" THEN 0 WHEN \"open\" THEN 1 ELSE 2 END,`test_models`.`name` DESC",
+		},
+		{
+			name: "time range",
+			opts: CreatedBetween(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)).UpdatedSince(time.Time{}).TimeRange("id", time.Time{}, time.Time{}),
+			want: "SELECT * FROM `test_models` WHERE `created_at` >= \"2024-05-01 00:00:00\" AND `created_at` < \"2024-06-01 00:00:00\"",
+		},
+		{
+			name: "table",
+			opts: Table("test_models_2024_05").F("id", 1),
+			want: "SELECT * FROM `test_models_2024_05` WHERE `id` = 1",
+		},
+	}
+	RegisterScope("active", func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", "active")
+	})
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := toSQL(t, c.opts); got != c.want {
+				t.Errorf("Expected SQL:\n%s\ngot:\n%s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestOrderByCaseCombined(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open dummy database: %v", err)
+	}
+	cases := []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+		want  string
+	}{
+		{
+			name: "two sorts by values",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return OrderByCase("status", []string{"open"}).OrderByCase("name", []string{"b", "a"}).Where(tx)
+			},
+			want: "SELECT * FROM `test_models` ORDER BY CASE `test_models`.`status` WHEN \"open\" THEN 0 ELSE 1 END,CASE `test_models`.`name` WHEN \"b\" THEN 0 WHEN \"a\" THEN 1 ELSE 2 END",
+		},
+		{
+			name: "sort by values after another",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return OrderByCase("name", []string{"a"}).Where(OrderByCase("status", []string{"open"}).Where(tx))
+			},
+			want: "SELECT * FROM `test_models` ORDER BY CASE `test_models`.`status` WHEN \"open\" THEN 0 ELSE 1 END,CASE `test_models`.`name` WHEN \"a\" THEN 0 ELSE 1 END",
+		},
+		{
+			name: "sort by column after sort by values",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return Sort("id", Desc).Where(Or("name").OrderByCase("status", []string{"open"}).Where(tx))
+			},
+			want: "SELECT * FROM `test_models` ORDER BY name,CASE `test_models`.`status` WHEN \"open\" THEN 0 ELSE 1 END,`test_models`.`id` DESC",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return c.query(tx).Find(&[]testModel{})
+			})
+			if got := strings.Join(strings.Fields(sql), " "); got != c.want {
+				t.Errorf("Expected SQL:\n%s\ngot:\n%s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestUnknownSort(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to open dummy database: %v", err)
+	}
+	for _, opts := range []*Options{Sort("nmae", Asc), Sort("name", "acs")} {
+		if err := opts.Where(db).Find(&[]testModel{}).Error; err == nil {
+			t.Errorf("Expected an error for sorts %v", opts.Sorts)
+		}
+	}
+}
+
+func TestOperator(t *testing.T) {
+	RegisterOperator("array_has", func(dialect, column string, args []any) (clause.Expression, error) {
+		if len(args) != 1 {
+			return nil, errors.New("requires 1 argument")
+		}
+		return clause.Expr{SQL: "? @> ARRAY[?]", Vars: []any{clause.Column{Name: column}, args[0]}}, nil
+	})
+
+	want := "SELECT * FROM `test_models` WHERE `name` @> ARRAY[\"go\"]"
+	if got := toSQL(t, Op("array_has", "name", "go")); got != want {
+		t.Errorf("Expected SQL:\n%s\ngot:\n%s", want, got)
+	}
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to open dummy database: %v", err)
+	}
+	for _, opts := range []*Options{Op("array_has", "name"), Op("missing", "name")} {
+		if err := opts.Where(db).Find(&[]testModel{}).Error; err == nil {
+			t.Errorf("Expected an error for %+v", opts.Clauses)
+		}
+	}
+}
+
+func TestUnknownScope(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to open dummy database: %v", err)
+	}
+	if err := Scope("missing").Where(db).Find(&[]testModel{}).Error; err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected unknown scope error, got %v", err)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		want   string
+	}{
+		{``, "SELECT * FROM `test_models`"},
+		{`name="john" AND id>30 OR status:("a", "b")`, "SELECT * FROM `test_models` WHERE `name` = \"john\" AND (`id` > 30 OR `status` IN (\"a\",\"b\"))"},
+		{`(id <= 1.5 OR id >= 10) status != archived -(id = 3)`, "SELECT * FROM `test_models` WHERE (`id` <= 1.5 OR `id` >= 10) AND `status` <> \"archived\" AND NOT (`id` = 3)"},
+		{`NOT status = null -name:* name = 'jo*n\'s'`, "SELECT * FROM `test_models` WHERE NOT (`status` IS NULL) AND NOT (`name` IS NOT NULL) AND `name` LIKE \"jo%n's\" ESCAPE '!'"},
+	}
+	for _, c := range cases {
+		opts, err := ParseFilter(c.filter)
+		if err != nil {
+			t.Errorf("ParseFilter(%s) failed: %v", c.filter, err)
+			continue
+		}
+		if got := toSQL(t, opts); got != c.want {
+			t.Errorf("Expected SQL for %s:\n%s\ngot:\n%s", c.filter, c.want, got)
+		}
+	}
+
+	for _, filter := range []string{`name`, `name = `, `name = "john`, `(id = 1`, `id = 1 AND`, `id ! 1`, `1d = 1`, `status:("a" "b")`, strings.Repeat("NOT ", 40) + "id = 1"} {
+		if _, err := ParseFilter(filter); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Expected ErrInvalidFilter for %s, got %v", filter, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := AllowFields("name", "status", "tenant_id").F("tenant_id", 1).Q("status <> ?", "deleted").Or("id")
+	opts := Merge(base, nil, F("tenant_id", 2).Like("name", "jo%").P(2, 10).AllowFields("tenant_id", "name", "id"))
+
+	want := "SELECT * FROM `test_models` WHERE `tenant_id` = 2 AND (`name` LIKE \"jo%\" ESCAPE '!' AND status <> \"deleted\") ORDER BY id LIMIT 10 OFFSET 10"
+	for range 2 {
+		if got := toSQL(t, opts); got != want {
+			t.Errorf("Expected SQL:\n%s\ngot:\n%s", want, got)
+		}
+	}
+	if strings.Join(opts.AllowedFields, ",") != "name,tenant_id" {
+		t.Errorf("Expected the allowed fields to be intersected, got %v", opts.AllowedFields)
+	}
+	if len(base.Filters) != 1 || base.Limit != defaultLimit || len(base.Clauses) != 0 {
+		t.Errorf("Expected the merged options to be unchanged, got %+v", base)
+	}
+
+	clone := base.Clone().F("name", "x").Gt("id", 1)
+	if len(base.Filters) != 1 || len(base.Clauses) != 0 || len(clone.Filters) != 2 {
+		t.Errorf("Expected changes to a clone to leave the original unchanged, got %+v", base)
+	}
+}
+
+func TestOptionsJSON(t *testing.T) {
+	opts := F("id", int64(1)<<60).
+		In("status", []string{"active", "pending"}).
+		ILike("name", Prefix("jo")).
+		NotBetween("id", 1.5, 3).
+		Not(NewWhere().IsNull("name").Gte("id", 10)).
+		JSONEq("name", "$.plan", "pro").
+		Raw("LENGTH(name) > ?", 3).
+		Q("status <> ?", "deleted").
+		Search("x", "name").
+		Select("id", "name").
+		Or("id desc").
+		P(2, 10)
+
+	data, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	decoded, err := DecodeTrusted(data)
+	if err != nil {
+		t.Fatalf("DecodeTrusted failed: %v", err)
+	}
+	if want, got := toSQL(t, opts), toSQL(t, decoded); got != want {
+		t.Errorf("Expected decoded options to build:\n%s\ngot:\n%s\nfrom %s", want, got, data)
+	}
+
+	// Options from clients cannot hold raw SQL or change the queried rows.
+	safe := F("status", "active").In("id", []int{1, 2}).Not(NewWhere().IsNull("name")).Select("id").P(1, 10)
+	if data, err = json.Marshal(safe); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var untrusted Options
+	if err := json.Unmarshal(data, &untrusted); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if want, got := toSQL(t, safe), toSQL(t, &untrusted); got != want {
+		t.Errorf("Expected decoded options to build:\n%s\ngot:\n%s", want, got)
+	}
+	unsafe := []*Options{
+		Raw("1 = 1"),
+		Not(NewWhere().Raw("1 = 1")),
+		NewWhere().Q("status <> ?", "deleted"),
+		Join("JOIN orders ON orders.user_id = users.id"),
+		Having("COUNT(*) > ?", 1),
+		PreloadWhere("Orders", "status = ?", "paid"),
+		Or("id desc"),
+		Table("admins"),
+		U(true),
+		NoDefaultWhere(),
+	}
+	for _, opts := range unsafe {
+		data, err := json.Marshal(opts)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if err := json.Unmarshal(data, new(Options)); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Expected ErrInvalidOptions decoding %s, got %v", data, err)
+		}
+		if _, err := DecodeTrusted(data); err != nil {
+			t.Errorf("Expected %s to be decoded as trusted, got %v", data, err)
+		}
+	}
+
+	if _, err := json.Marshal(PreloadWhere("Orders", func(db *gorm.DB) *gorm.DB { return db })); err == nil {
+		t.Error("Expected an error encoding a preload filtered by a function")
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`status=active AND id>"10" ORDER BY id DESC LIMIT 50`, "SELECT * FROM `test_models` WHERE `status` = \"active\" AND `id` > \"10\" ORDER BY `test_models`.`id` DESC LIMIT 50"},
+		{`ORDER BY name, id ASC LIMIT 10 OFFSET 20`, "SELECT * FROM `test_models` ORDER BY `test_models`.`name`,`test_models`.`id` LIMIT 10 OFFSET 20"},
+		{`name:"jo*" OFFSET 5`, "SELECT * FROM `test_models` WHERE `name` LIKE \"jo%\" ESCAPE '!' OFFSET 5"},
+	}
+	for _, c := range cases {
+		opts, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", c.query, err)
+			continue
+		}
+		if got := toSQL(t, opts); got != c.want {
+			t.Errorf("Expected SQL for %s:\n%s\ngot:\n%s", c.query, c.want, got)
+		}
+	}
+
+	for _, query := range []string{`id = 1 ORDER id`, `ORDER BY`, `LIMIT -1`, `LIMIT 10 ORDER BY id`, `id = 1 LIMIT 1 2`} {
+		if _, err := Parse(query); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Expected ErrInvalidFilter for %s, got %v", query, err)
+		}
+	}
+}
+
+func TestJSONPathKeys(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"$", ""},
+		{"$.plan", "plan"},
+		{`$.items[0]."first name"`, "items,0,first name"},
+		{"plan", "error"},
+		{"$.items[x]", "error"},
+		{"$..plan", "error"},
+	}
+	for _, c := range cases {
+		keys, err := JSONPathKeys(c.path)
+		got := strings.Join(keys, ",")
+		if err != nil {
+			got = "error"
+		}
+		if got != c.want {
+			t.Errorf("Expected %q for %s, got %q (%v)", c.want, c.path, got, err)
+		}
+	}
+}
+
+func TestLikeRegexp(t *testing.T) {
+	cases := []struct {
+		expr LikeExpr
+		want string
+	}{
+		{LikeExpr{Pattern: "jo%n_"}, `(?s)^jo.*n.$`},
+		{LikeExpr{Pattern: Contains("50%_!.")}, `(?s)^.*50%_!\..*$`},
+		{LikeExpr{Pattern: "a%", CaseInsensitive: true}, `(?si)^a.*$`},
+	}
+	for _, c := range cases {
+		if got := c.expr.Regexp(); got != c.want {
+			t.Errorf("Expected %s for %q, got %s", c.want, c.expr.Pattern, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := P(2, 50).AllowLimit(100).F("id", []int{1}).Validate(); err != nil {
+		t.Errorf("Expected valid options, got %v", err)
+	}
+
+	opts := AllowFields("name").AllowLimit(100).P(1, 500).F("email", "x").In("id", []any{}).Op("missing", "name")
+	err := opts.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	var fields []string
+	for _, ferr := range verr.Errors {
+		fields = append(fields, ferr.Field)
+	}
+	if want := []string{"limit", "id", "name", "email", "id"}; !slices.Equal(fields, want) {
+		t.Errorf("Expected errors on %v, got %v", want, fields)
+	}
+	if !errors.Is(err, ErrFieldNotAllowed) || !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected ErrFieldNotAllowed and ErrInvalidOptions, got %v", err)
+	}
+
+	if err := AllowLimit(100).Validate(); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected an unlimited query to exceed the maximum, got %v", err)
+	}
+	if err := (&Options{Offset: -10}).Validate(); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected a negative offset to be invalid, got %v", err)
+	}
+}
+
+func TestFromStruct(t *testing.T) {
+	type page struct {
+		Deleted *bool `where:"deleted_at,null"`
+	}
+	type filter struct {
+		page
+		Status string   `where:"status"`
+		MinID  int      `where:"id,gte"`
+		Name   string   `where:",prefix"`
+		IDs    []int    `where:"id,in"`
+		Secret string   `where:"-"`
+		Roles  []string `where:"role,in"`
+	}
+	deleted := false
+	opts, err := FromStruct(&filter{page: page{Deleted: &deleted}, Status: "active", MinID: 10, Name: "jo_", IDs: []int{1, 2}, Secret: "x"})
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	want := "SELECT * FROM `test_models` WHERE `status` = \"active\" AND (`deleted_at` IS NOT NULL AND `id` >= 10 AND `name` LIKE \"jo!_%\" ESCAPE '!' AND `id` IN (1,2))"
+	if got := toSQL(t, opts); got != want {
+		t.Errorf("Expected SQL:\n%s\ngot:\n%s", want, got)
+	}
+
+	if got := toSQL(t, FromMap(map[string]any{"id": []int{1, 2}})); got != "SELECT * FROM `test_models` WHERE `id` IN (1,2)" {
+		t.Errorf("Unexpected SQL for FromMap: %s", got)
+	}
+
+	for _, filter := range []any{"status", struct {
+		Name int `where:"name,contains"`
+	}{1}, struct {
+		Name string `where:"name,regexp"`
+	}{}} {
+		if _, err := FromStruct(filter); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Expected ErrInvalidOptions for %+v, got %v", filter, err)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	sorts := []SortColumn{{Column: "created_at", Direction: Desc}, {Column: "id", Direction: Desc}}
+	cursor, err := Cursor(sorts, "2024-05-01T00:00:00Z", 42)
+	if err != nil {
+		t.Fatalf("Cursor failed: %v", err)
+	}
+	values, err := DecodeCursor(cursor, sorts)
+	if err != nil || len(values) != 2 || string(values[0]) != `"2024-05-01T00:00:00Z"` || string(values[1]) != "42" {
+		t.Fatalf("Expected the encoded values, got %s, %v", values, err)
+	}
+
+	tampered := []byte(cursor)
+	tampered[len(tampered)-2] ^= 1
+	other := []SortColumn{{Column: "created_at", Direction: Asc}, {Column: "id", Direction: Desc}}
+	for _, c := range []struct {
+		cursor string
+		sorts  []SortColumn
+	}{{string(tampered), sorts}, {cursor, other}, {"not a cursor", sorts}, {"", sorts}} {
+		if _, err := DecodeCursor(c.cursor, c.sorts); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for %q and %v, got %v", c.cursor, c.sorts, err)
+		}
+	}
+
+	defer SetCursorKey(*cursorKey.Load())
+	SetCursorKey([]byte("secret"))
+	if _, err := DecodeCursor(cursor, sorts); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected a cursor signed with another key to be invalid, got %v", err)
+	}
+}
+
+func TestCursorForged(t *testing.T) {
+	defer SetCursorKey(*cursorKey.Load())
+	sorts := []SortColumn{{Column: "id", Direction: Desc}}
+
+	// Without configuration cursors are signed with a random key, so clients cannot
+	// forge them with the empty key or any key of their own.
+	issued, err := Cursor(sorts, 42)
+	if err != nil {
+		t.Fatalf("Cursor failed: %v", err)
+	}
+	for _, key := range [][]byte{nil, []byte("attacker")} {
+		random := *cursorKey.Load()
+		SetCursorKey(key)
+		forged, err := Cursor(sorts, 1000)
+		if err != nil {
+			t.Fatalf("Cursor failed: %v", err)
+		}
+		SetCursorKey(random)
+
+		if _, err := DecodeCursor(forged, sorts); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected a cursor forged with key %q to be rejected, got %v", key, err)
+		}
+	}
+	if _, err := DecodeCursor(issued, sorts); err != nil {
+		t.Errorf("Expected the issued cursor to be valid, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package token
 
 import (
 	"context"
+	"crypto"
 	"errors"
 	"fmt"
 	"strings"
@@ -26,6 +27,14 @@ type Config struct {
 	expiration time.Duration
 	// skipPaths 需要跳过认证的路径列表
 	skipPaths []string
+	// signingMethod 是签发 token 的签名算法，默认为 HS256
+	signingMethod jwt.SigningMethod
+	// privateKey 是 RS256/ES256 等非对称算法签发 token 的私钥
+	privateKey crypto.Signer
+	// publicKey 是非对称算法验证 token 的公钥，未设置时使用私钥对应的公钥
+	publicKey crypto.PublicKey
+	// keyErr 记录解析 PEM 密钥时的错误，在签发和解析 token 时返回
+	keyErr error
 }
 
 // Option 用于配置 token 包的选项
@@ -33,10 +42,11 @@ type Option func(*Config)
 
 var (
 	config = Config{
-		key:         "",
-		identityKey: "",
-		expiration:  2 * time.Hour,
-		skipPaths:   []string{}, // 默认不跳过任何路径
+		key:           "",
+		identityKey:   "",
+		expiration:    2 * time.Hour,
+		skipPaths:     []string{}, // 默认不跳过任何路径
+		signingMethod: jwt.SigningMethodHS256,
 	}
 	once sync.Once // 确保配置只被初始化一次
 )
@@ -119,6 +129,51 @@ func WithSkipPathsPattern(patterns ...string) Option {
 	}
 }
 
+// WithSigningMethod 设置签名算法，如 jwt.SigningMethodRS256 或 jwt.SigningMethodES256。
+// 非对称算法使用 WithPrivateKeyPEM 设置的私钥签发 token，使用 WithPublicKeyPEM 设置的公钥验证 token，
+// 这样只验证 token 的服务无需持有签名私钥
+func WithSigningMethod(method jwt.SigningMethod) Option {
+	return func(c *Config) {
+		if method != nil {
+			c.signingMethod = method
+		}
+	}
+}
+
+// WithPrivateKeyPEM 设置 PEM 编码的 RSA 或 ECDSA 私钥，用于非对称算法签发 token，
+// 未设置公钥时也用于验证 token。密钥无效时，签发和解析 token 会返回错误
+func WithPrivateKeyPEM(pemData []byte) Option {
+	return func(c *Config) {
+		if key, err := jwt.ParseRSAPrivateKeyFromPEM(pemData); err == nil {
+			c.privateKey = key
+			return
+		}
+		key, err := jwt.ParseECPrivateKeyFromPEM(pemData)
+		if err != nil {
+			c.keyErr = fmt.Errorf("invalid private key: %w", err)
+			return
+		}
+		c.privateKey = key
+	}
+}
+
+// WithPublicKeyPEM 设置 PEM 编码的 RSA 或 ECDSA 公钥（或证书），用于非对称算法验证 token。
+// 密钥无效时，解析 token 会返回错误
+func WithPublicKeyPEM(pemData []byte) Option {
+	return func(c *Config) {
+		if key, err := jwt.ParseRSAPublicKeyFromPEM(pemData); err == nil {
+			c.publicKey = key
+			return
+		}
+		key, err := jwt.ParseECPublicKeyFromPEM(pemData)
+		if err != nil {
+			c.keyErr = fmt.Errorf("invalid public key: %w", err)
+			return
+		}
+		c.publicKey = key
+	}
+}
+
 // 4. 配置初始化和管理
 
 // Init 设置包级别的配置 config, config 会用于本包后面的 token 签发和解析
@@ -139,10 +194,11 @@ func Init(key string, opts ...Option) {
 func Reset() {
 	once = sync.Once{}
 	config = Config{
-		key:         "Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5",
-		identityKey: "identityKey",
-		expiration:  2 * time.Hour,
-		skipPaths:   []string{},
+		key:           "Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5",
+		identityKey:   "identityKey",
+		expiration:    2 * time.Hour,
+		skipPaths:     []string{},
+		signingMethod: jwt.SigningMethodHS256,
 	}
 }
 
@@ -222,6 +278,83 @@ func matchWildcard(str, pattern string) bool {
 
 // 6. Token 解析功能
 
+// isAsymmetric 检查配置的签名算法是否为非对称算法
+func isAsymmetric() bool {
+	switch config.signingMethod.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodRSAPSS:
+		return true
+	default:
+		return false
+	}
+}
+
+// verifyKey 返回按配置验证 token 签名的密钥
+func verifyKey() (interface{}, error) {
+	if config.keyErr != nil {
+		return nil, config.keyErr
+	}
+	if !isAsymmetric() {
+		if config.key == "" {
+			return nil, jwt.ErrInvalidKey
+		}
+		return []byte(config.key), nil
+	}
+	if config.publicKey != nil {
+		return config.publicKey, nil
+	}
+	if config.privateKey != nil {
+		return config.privateKey.Public(), nil
+	}
+	return nil, jwt.ErrInvalidKey
+}
+
+// signingKey 返回按配置签发 token 的密钥
+func signingKey() (interface{}, error) {
+	if config.keyErr != nil {
+		return nil, config.keyErr
+	}
+	if !isAsymmetric() {
+		if config.key == "" {
+			return nil, jwt.ErrInvalidKey
+		}
+		return []byte(config.key), nil
+	}
+	// 只持有公钥的服务不能签发 token
+	if config.privateKey == nil {
+		return nil, jwt.ErrInvalidKey
+	}
+	return config.privateKey, nil
+}
+
+// parseWithConfig 使用配置的签名算法和密钥解析并验证 token
+func parseWithConfig(tokenString string) (*jwt.Token, error) {
+	if tokenString == "" {
+		return nil, ErrEmptyToken
+	}
+
+	key, err := verifyKey()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// 确保 token 加密算法与配置的算法一致，防止算法混淆攻击
+		if token.Method.Alg() != config.signingMethod.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return token, nil
+}
+
 // ParseIdentity 使用指定的密钥 key 解析 token，解析成功返回 token 上下文，否则报错
 func ParseIdentity(tokenString string, key string) (string, error) {
 	if tokenString == "" {
@@ -257,6 +390,16 @@ func ParseIdentity(tokenString string, key string) (string, error) {
 	return extractIdentity(claims)
 }
 
+// parseIdentity 使用配置的签名算法和密钥解析 token，返回其中的身份信息
+func parseIdentity(tokenString string) (string, error) {
+	claims, err := GetClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	return extractIdentity(claims)
+}
+
 // extractIdentity 从 claims 中提取身份信息
 func extractIdentity(claims jwt.MapClaims) (string, error) {
 	// 如果没有配置身份键，返回空字符串（表示不需要身份验证）
@@ -286,55 +429,17 @@ func extractIdentity(claims jwt.MapClaims) (string, error) {
 
 // Parse 验证 token 字符串的有效性（不解析身份信息）
 func Parse(tokenString string) error {
-	if tokenString == "" {
-		return ErrEmptyToken
-	}
-
-	if config.key == "" {
-		return jwt.ErrInvalidKey
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.key), nil
-	})
-	if err != nil {
-		return err
-	}
-
-	if !token.Valid {
-		return jwt.ErrSignatureInvalid
-	}
-
-	return nil
+	_, err := parseWithConfig(tokenString)
+	return err
 }
 
 // GetClaims 获取 token 中的所有 claims
 func GetClaims(tokenString string) (jwt.MapClaims, error) {
-	if tokenString == "" {
-		return nil, ErrEmptyToken
-	}
-
-	if config.key == "" {
-		return nil, jwt.ErrInvalidKey
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.key), nil
-	})
+	token, err := parseWithConfig(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, jwt.ErrSignatureInvalid
-	}
-
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return nil, ErrInvalidTokenClaims
@@ -377,10 +482,11 @@ func ParseWithKey(tokenString, key string) (jwt.MapClaims, error) {
 
 // 7. Token 签发功能
 
-// Sign 使用 jwtSecret 签发 token，token 的 claims 中会存放传入的 subject
+// Sign 使用配置的签名算法和密钥签发 token，token 的 claims 中会存放传入的 subject
 func Sign(identityValue string) (string, time.Time, error) {
-	if config.key == "" {
-		return "", time.Time{}, jwt.ErrInvalidKey
+	key, err := signingKey()
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
 	now := time.Now()
@@ -399,10 +505,10 @@ func Sign(identityValue string) (string, time.Time, error) {
 	}
 
 	// 创建 token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(config.signingMethod, claims)
 
 	// 签发 token
-	tokenString, err := token.SignedString([]byte(config.key))
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -412,8 +518,9 @@ func Sign(identityValue string) (string, time.Time, error) {
 
 // SignWithClaims 使用自定义 claims 签发 token
 func SignWithClaims(customClaims jwt.MapClaims) (string, time.Time, error) {
-	if config.key == "" {
-		return "", time.Time{}, jwt.ErrInvalidKey
+	key, err := signingKey()
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
 	now := time.Now()
@@ -437,10 +544,10 @@ func SignWithClaims(customClaims jwt.MapClaims) (string, time.Time, error) {
 	}
 
 	// 创建 token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(config.signingMethod, claims)
 
 	// 签发 token
-	tokenString, err := token.SignedString([]byte(config.key))
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -462,7 +569,7 @@ func ParseRequest(ctx context.Context) (string, error) {
 		return "", err
 	}
 
-	return ParseIdentity(token, config.key)
+	return parseIdentity(token)
 }
 
 // shouldSkipRequestPath 检查请求路径是否应该跳过认证
@@ -484,7 +591,7 @@ func ParseRequestIgnoreSkip(ctx context.Context) (string, error) {
 		return "", err
 	}
 
-	return ParseIdentity(token, config.key)
+	return parseIdentity(token)
 }
 
 // extractTokenFromRequest 从不同类型的请求上下文中提取 token
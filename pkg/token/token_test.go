@@ -2,6 +2,12 @@ package token
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -163,6 +169,68 @@ func TestSign(t *testing.T) {
 	}
 }
 
+// TestAsymmetricSigning 测试 RS256/ES256 非对称签名，只持有公钥的服务只能验证 token
+func TestAsymmetricSigning(t *testing.T) {
+	defer Reset()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+
+	testCases := []struct {
+		method     jwt.SigningMethod
+		privatePEM []byte
+		public     any
+	}{
+		{jwt.SigningMethodRS256, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), &rsaKey.PublicKey},
+		{jwt.SigningMethodES256, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), &ecKey.PublicKey},
+	}
+	for _, tc := range testCases {
+		publicDER, _ := x509.MarshalPKIXPublicKey(tc.public)
+		publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+		// 签发服务持有私钥
+		Reset()
+		Init("", WithIdentityKey("user_id"), WithSigningMethod(tc.method), WithPrivateKeyPEM(tc.privatePEM))
+		tokenString, _, err := Sign("user-1")
+		if err != nil {
+			t.Fatalf("%s: Sign failed: %v", tc.method.Alg(), err)
+		}
+
+		// 验证服务只持有公钥
+		Reset()
+		Init("", WithIdentityKey("user_id"), WithSigningMethod(tc.method), WithPublicKeyPEM(publicPEM))
+		if err := Parse(tokenString); err != nil {
+			t.Errorf("%s: Parse failed: %v", tc.method.Alg(), err)
+		}
+		claims, err := GetClaims(tokenString)
+		if err != nil || claims["user_id"] != "user-1" {
+			t.Errorf("%s: Expected user-1 in claims, got %v, %v", tc.method.Alg(), claims, err)
+		}
+		if _, _, err := Sign("user-1"); err != jwt.ErrInvalidKey {
+			t.Errorf("%s: Expected ErrInvalidKey when signing without private key, got %v", tc.method.Alg(), err)
+		}
+
+		// 使用 HMAC 签发的 token 不能通过非对称算法的验证
+		hmacToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "user-1"}).SignedString(publicPEM)
+		if err := Parse(hmacToken); err == nil {
+			t.Errorf("%s: Expected HS256 token to be rejected", tc.method.Alg())
+		}
+	}
+
+	Reset()
+	Init("", WithSigningMethod(jwt.SigningMethodRS256), WithPrivateKeyPEM([]byte("invalid")))
+	if _, _, err := Sign("user-1"); err == nil {
+		t.Error("Expected an error for an invalid private key")
+	}
+}
+
 // TestTokenParsing 测试Token解析功能
 func TestTokenParsing(t *testing.T) {
 	Reset()